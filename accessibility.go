@@ -0,0 +1,67 @@
+package rnzml
+
+import (
+	"bytes"
+)
+
+// AccessibilityMode controls how links that fail accessibility checks are
+// reported
+type AccessibilityMode int
+
+const (
+	// AccessibilityOff does not check links
+	AccessibilityOff AccessibilityMode = iota
+	// AccessibilityWarn reports a Warning for each link that fails a check
+	AccessibilityWarn
+	// AccessibilityError returns a *SyntaxError for the first link that fails
+	// a check
+	AccessibilityError
+)
+
+// vagueLabels are link labels that do not describe where a link goes
+var vagueLabels = [][]byte{
+	[]byte("click here"), []byte("here"), []byte("link"), []byte("more"),
+	[]byte("read more"), []byte("this"), []byte("this link"),
+}
+
+// WithAccessibilityChecks checks that the label of each link describes where
+// it goes, so a screen reader listing the links of a page is useful. Links
+// labelled with their URL, as [url] links are, and links labelled with words
+// like "here" or "read more" or with no label fail the check.
+func WithAccessibilityChecks(mode AccessibilityMode) Option {
+	return func(re *Renderer) {
+		re.accessibility = mode
+	}
+}
+
+// checkLinkLabel checks the label of a link at position
+func (re *Renderer) checkLinkLabel(st *renderState, line, position int, rawURL, label []byte) error {
+	if re.accessibility == AccessibilityOff || describesLink(rawURL, label) {
+		return nil
+	}
+	if re.accessibility == AccessibilityError {
+		return &SyntaxError{Problem: VagueLinkLabel, Position: position, Link: string(label)}
+	}
+	re.warn(st, line, position, "link label does not describe the link: "+string(label))
+	return nil
+}
+
+// describesLink reports whether label describes a link to rawURL
+func describesLink(rawURL, label []byte) bool {
+	label = bytes.TrimSpace(label)
+	if len(label) == 0 || bytes.Equal(label, rawURL) {
+		return false
+	}
+	for _, vague := range vagueLabels {
+		if bytes.EqualFold(label, vague) {
+			return false
+		}
+	}
+	return true
+}
+
+// DescribesLink reports whether label describes a link to rawURL, the check
+// WithAccessibilityChecks makes of each link
+func DescribesLink(rawURL, label string) bool {
+	return describesLink([]byte(rawURL), []byte(label))
+}
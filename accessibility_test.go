@@ -0,0 +1,76 @@
+package rnzml
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var accessibilitytests = []struct {
+	in       string
+	warnings []string
+	err      string
+}{
+	{"[https://res.nz Res NZ] `[https://res.nz]`", nil, ""},
+	{"a [https://res.nz]", []string{"line 1: link label does not describe the link: https://res.nz at position: 2"}, "line 1: link label does not describe the link at position: 2"},
+	{"a\n[/a Click Here]", []string{"line 2: link label does not describe the link: Click Here at position: 0"}, "line 2: link label does not describe the link at position: 0"},
+	{"[/a  here ]", []string{
+		"line 1: link label starts or ends with whitespace at position: 0",
+		"line 1: link label does not describe the link:  here  at position: 0",
+	}, "line 1: link label does not describe the link at position: 0"},
+	{"[/a here and there]", nil, ""},
+}
+
+func TestAccessibilityChecks(t *testing.T) {
+	for _, tt := range accessibilitytests {
+		t.Run(tt.in, func(t *testing.T) {
+			var warnings []string
+			re := NewRenderer(WithAccessibilityChecks(AccessibilityWarn), WithWarnings(func(w Warning) {
+				warnings = append(warnings, w.String())
+			}))
+			if err := re.Render(strings.NewReader(tt.in), io.Discard); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(tt.warnings, "\n") != strings.Join(warnings, "\n") {
+				t.Errorf("expected: '%v' got: '%v'", tt.warnings, warnings)
+			}
+
+			err := NewRenderer(WithAccessibilityChecks(AccessibilityError)).Render(strings.NewReader(tt.in), io.Discard)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("expected no error got: '%v'", err)
+				}
+				return
+			}
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) || syntaxErr.Problem != VagueLinkLabel {
+				t.Fatalf("expected *SyntaxError got: '%v'", err)
+			}
+			if tt.err != err.Error() {
+				t.Errorf("expected: '%s' got: '%s'", tt.err, err.Error())
+			}
+		})
+	}
+	t.Run("Should not check links by default", func(t *testing.T) {
+		if err := r.Render(strings.NewReader("[https://res.nz]"), io.Discard); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestDescribesLink(t *testing.T) {
+	for _, tt := range []struct {
+		url, label string
+		describes  bool
+	}{
+		{"https://res.nz", "The res.nz website", true},
+		{"https://res.nz", "https://res.nz", false},
+		{"https://res.nz", " Click Here ", false},
+		{"https://res.nz", "", false},
+	} {
+		if describes := DescribesLink(tt.url, tt.label); describes != tt.describes {
+			t.Errorf("expected %q to describe %q: %t got: %t", tt.label, tt.url, tt.describes, describes)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package rnzml
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// htmlName returns the name of the file name of an archive or tree once it is
+// rendered, and whether it is rendered
+func htmlName(name string) (string, bool) {
+	if !strings.HasSuffix(name, fileExtension) {
+		return name, false
+	}
+	return strings.TrimSuffix(name, fileExtension) + ".html", true
+}
+
+// RenderTar reads the tar archive in and writes a tar archive of its entries
+// to out, with each .rnzml file rendered with a Renderer configured by opts to
+// a .html file of the same name. Other entries are copied. Entries are read,
+// rendered and written one at a time, so neither archive is held in memory or
+// extracted to disk. An error rendering a file is returned with its name, and
+// out is left incomplete.
+func RenderTar(in io.Reader, out io.Writer, opts ...Option) error {
+	re := NewRenderer(opts...)
+	r, w := tar.NewReader(in), tar.NewWriter(out)
+	var buf bytes.Buffer
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			return w.Close()
+		}
+		if err != nil {
+			return err
+		}
+		name, render := htmlName(header.Name)
+		if !render || header.Typeflag != tar.TypeReg {
+			if err := w.WriteHeader(header); err != nil {
+				return err
+			}
+			if _, err := io.Copy(w, r); err != nil {
+				return err
+			}
+			continue
+		}
+		// The size of an entry is written before its content
+		buf.Reset()
+		if err := re.Render(r, &buf); err != nil {
+			return fmt.Errorf("%s: %w", header.Name, err)
+		}
+		rendered := *header
+		rendered.Name, rendered.Size = name, int64(buf.Len())
+		if err := w.WriteHeader(&rendered); err != nil {
+			return err
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+}
+
+// RenderZip reads the zip archive in of size bytes and writes a zip archive of
+// its entries to out as RenderTar does for tar archives. Rendered files are
+// streamed into out as they are rendered.
+func RenderZip(in io.ReaderAt, size int64, out io.Writer, opts ...Option) error {
+	re := NewRenderer(opts...)
+	r, err := zip.NewReader(in, size)
+	if err != nil {
+		return err
+	}
+	w := zip.NewWriter(out)
+	for _, f := range r.File {
+		name, render := htmlName(f.Name)
+		header := &zip.FileHeader{Name: name, Comment: f.Comment, Method: f.Method, Modified: f.Modified}
+		header.SetMode(f.Mode())
+		entry, err := w.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := copyZipFile(re, f, entry, render); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// copyZipFile writes the content of f to out, rendered when render is true
+func copyZipFile(re *Renderer, f *zip.File, out io.Writer, render bool) error {
+	in, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if !render {
+		_, err = io.Copy(out, in)
+		return err
+	}
+	if err := re.Render(in, out); err != nil {
+		return fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package rnzml
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// archiveEntries are the entries of the archives rendered by tests, rendered
+// to the entries of expectedEntries
+var (
+	archiveEntries = []struct {
+		name, content string
+	}{
+		{"docs/", ""},
+		{"docs/index.rnzml", "*a*"},
+		{"docs/guide/intro.rnzml", "[/b b]"},
+		{"docs/image.png", "*"},
+	}
+	expectedEntries = "docs/: docs/index.html:<p><strong>a</strong>\n</p>\n docs/guide/intro.html:<p><a href=\"/b\">b</a>\n</p>\n docs/image.png:*"
+)
+
+func testTar(t *testing.T, entries ...string) []byte {
+	var b bytes.Buffer
+	w := tar.NewWriter(&b)
+	for _, e := range archiveEntries {
+		header := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(e.name, "/") {
+			header.Typeflag, header.Mode = tar.TypeDir, 0o755
+		}
+		if err := w.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(e.content))
+	}
+	for i := 0; i < len(entries); i += 2 {
+		w.WriteHeader(&tar.Header{Name: entries[i], Mode: 0o644, Size: int64(len(entries[i+1])), Typeflag: tar.TypeReg})
+		w.Write([]byte(entries[i+1]))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func testZip(t *testing.T, entries ...string) []byte {
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for _, e := range archiveEntries {
+		f, err := w.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(e.content))
+	}
+	for i := 0; i < len(entries); i += 2 {
+		f, _ := w.Create(entries[i])
+		f.Write([]byte(entries[i+1]))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestRenderTar(t *testing.T) {
+	t.Run("Should render .rnzml entries and copy the others", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := RenderTar(bytes.NewReader(testTar(t)), &out); err != nil {
+			t.Fatal(err)
+		}
+		var entries []string
+		r := tar.NewReader(&out)
+		for {
+			header, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := io.ReadAll(r)
+			entries = append(entries, header.Name+":"+string(content))
+			if header.Name == "docs/" && header.Typeflag != tar.TypeDir {
+				t.Errorf("expected a directory got: %v", header.Typeflag)
+			}
+		}
+		if got := strings.Join(entries, " "); got != expectedEntries {
+			t.Errorf("expected: %q got: %q", expectedEntries, got)
+		}
+	})
+	t.Run("Should return errors with the name of the entry", func(t *testing.T) {
+		err := RenderTar(bytes.NewReader(testTar(t, "b.rnzml", "*b")), io.Discard)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || !strings.HasPrefix(err.Error(), "b.rnzml: line 1: ") {
+			t.Errorf("expected *SyntaxError for b.rnzml got: '%v'", err)
+		}
+	})
+}
+
+func TestRenderZip(t *testing.T) {
+	t.Run("Should render .rnzml entries and copy the others", func(t *testing.T) {
+		in := testZip(t)
+		var out bytes.Buffer
+		if err := RenderZip(bytes.NewReader(in), int64(len(in)), &out); err != nil {
+			t.Fatal(err)
+		}
+		r, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var entries []string
+		for _, f := range r.File {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+			entries = append(entries, f.Name+":"+string(content))
+		}
+		if got := strings.Join(entries, " "); got != expectedEntries {
+			t.Errorf("expected: %q got: %q", expectedEntries, got)
+		}
+	})
+	t.Run("Should return errors with the name of the entry", func(t *testing.T) {
+		in := testZip(t, "b.rnzml", "a\n[ b]")
+		err := RenderZip(bytes.NewReader(in), int64(len(in)), io.Discard)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || !strings.HasPrefix(err.Error(), "b.rnzml: line 2: ") {
+			t.Errorf("expected *SyntaxError for b.rnzml got: '%v'", err)
+		}
+	})
+	t.Run("Should return errors reading the archive", func(t *testing.T) {
+		if err := RenderZip(strings.NewReader("a"), 1, io.Discard); err != zip.ErrFormat {
+			t.Errorf("expected: '%v' got: '%v'", zip.ErrFormat, err)
+		}
+	})
+}
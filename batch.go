@@ -0,0 +1,92 @@
+package rnzml
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrDraft is the error of a job skipped by RenderAll as it is a draft
+var ErrDraft = errors.New("document is a draft")
+
+// RenderJob is a document rendered by RenderAll
+type RenderJob struct {
+	In  io.Reader
+	Out io.Writer
+	// FrontMatter reads front matter from the start of In with ParseMeta and
+	// renders the body after it. Drafts are not rendered and fail with
+	// ErrDraft unless IncludeDrafts is set.
+	FrontMatter   bool
+	IncludeDrafts bool
+}
+
+// RenderAll renders each job with up to workers jobs rendering at once and
+// returns the error of each job, with the error of jobs[i] at index i. Once ctx
+// is done jobs that have not started fail with the error of ctx, and jobs
+// rendering fail the next time they read from their input. Values of workers
+// below 1 render one job at a time. A Renderer using a normalizer or
+// URLRewriter must be safe for concurrent use.
+func (re *Renderer) RenderAll(ctx context.Context, jobs []RenderJob, workers int) []error {
+	errs := make([]error, len(jobs))
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = re.renderJob(ctx, jobs[i])
+			}
+		}()
+	}
+	for i := range jobs {
+		if ctx.Err() != nil {
+			errs[i] = ctx.Err()
+			continue
+		}
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+		}
+	}
+	close(next)
+	wg.Wait()
+	return errs
+}
+
+// renderJob renders job, reading from its input until ctx is done
+func (re *Renderer) renderJob(ctx context.Context, job RenderJob) error {
+	var in io.Reader = &contextReader{ctx: ctx, r: job.In}
+	if job.FrontMatter {
+		meta, body, err := ParseMeta(in)
+		if err != nil {
+			return err
+		}
+		if meta.Draft && !job.IncludeDrafts {
+			return ErrDraft
+		}
+		in = body
+	}
+	return re.Render(in, job.Out)
+}
+
+// contextReader reads from r until ctx is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,110 @@
+package rnzml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRenderAll(t *testing.T) {
+	t.Run("Should return the error of each job", func(t *testing.T) {
+		inputs := []string{"a", "*b", "c", "```"}
+		jobs := make([]RenderJob, len(inputs))
+		outs := make([]*strings.Builder, len(inputs))
+		for i, in := range inputs {
+			outs[i] = &strings.Builder{}
+			jobs[i] = RenderJob{In: strings.NewReader(in), Out: outs[i]}
+		}
+		errs := r.RenderAll(context.Background(), jobs, 2)
+		for i, in := range inputs {
+			expected := &strings.Builder{}
+			expectedErr := r.Render(strings.NewReader(in), expected)
+			if fmt.Sprint(expectedErr) != fmt.Sprint(errs[i]) {
+				t.Errorf("job %d expected error: '%v' got: '%v'", i, expectedErr, errs[i])
+			}
+			if expected.String() != outs[i].String() {
+				t.Errorf("job %d expected: '%s' got: '%s'", i, expected.String(), outs[i].String())
+			}
+		}
+	})
+	t.Run("Should render at most workers jobs at once", func(t *testing.T) {
+		var rendering, most int32
+		jobs := make([]RenderJob, 20)
+		for i := range jobs {
+			jobs[i] = RenderJob{In: &trackingReader{r: strings.NewReader(largeDocument), rendering: &rendering, most: &most}, Out: &strings.Builder{}}
+		}
+		for i, err := range r.RenderAll(context.Background(), jobs, 3) {
+			if err != nil {
+				t.Errorf("job %d error: %s", i, err.Error())
+			}
+		}
+		if most > 3 {
+			t.Errorf("expected at most 3 jobs at once got: %d", most)
+		}
+	})
+	t.Run("Should skip drafts", func(t *testing.T) {
+		draft := "---\ndraft: true\n---\na"
+		var jobs []RenderJob
+		var outs []*strings.Builder
+		for _, job := range []RenderJob{
+			{In: strings.NewReader(draft)},
+			{In: strings.NewReader(draft), FrontMatter: true},
+			{In: strings.NewReader(draft), FrontMatter: true, IncludeDrafts: true},
+			{In: strings.NewReader("---\ndraft: false\n---\nb"), FrontMatter: true},
+		} {
+			outs = append(outs, &strings.Builder{})
+			job.Out = outs[len(outs)-1]
+			jobs = append(jobs, job)
+		}
+		errs := r.RenderAll(context.Background(), jobs, 2)
+		expected := []string{"<p>---\n</p>\n<p>draft: true\n</p>\n<p>---\n</p>\n<p>a\n</p>\n", "", "<p>a\n</p>\n", "<p>b\n</p>\n"}
+		expectedErrs := []error{nil, ErrDraft, nil, nil}
+		for i := range jobs {
+			if expectedErrs[i] != errs[i] {
+				t.Errorf("job %d expected error: '%v' got: '%v'", i, expectedErrs[i], errs[i])
+			}
+			if expected[i] != outs[i].String() {
+				t.Errorf("job %d expected: %q got: %q", i, expected[i], outs[i].String())
+			}
+		}
+	})
+	t.Run("Should fail jobs once the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		jobs := []RenderJob{{In: strings.NewReader("a"), Out: &strings.Builder{}}}
+		errs := r.RenderAll(ctx, jobs, 1)
+		if !errors.Is(errs[0], context.Canceled) {
+			t.Errorf("expected: '%v' got: '%v'", context.Canceled, errs[0])
+		}
+	})
+}
+
+// trackingReader tracks the most readers being read from at once, a reader
+// counts as being read from until it returns an error
+type trackingReader struct {
+	r               *strings.Reader
+	started, done   bool
+	rendering, most *int32
+}
+
+func (t *trackingReader) Read(p []byte) (int, error) {
+	if !t.started {
+		t.started = true
+		n := atomic.AddInt32(t.rendering, 1)
+		for {
+			most := atomic.LoadInt32(t.most)
+			if n <= most || atomic.CompareAndSwapInt32(t.most, most, n) {
+				break
+			}
+		}
+	}
+	n, err := t.r.Read(p)
+	if err != nil && !t.done {
+		t.done = true
+		atomic.AddInt32(t.rendering, -1)
+	}
+	return n, err
+}
@@ -0,0 +1,215 @@
+package rnzml
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache stores rendered output by key. Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	// Get returns the output stored for key
+	Get(key string) ([]byte, bool)
+	// Add stores output for key
+	Add(key string, output []byte)
+}
+
+// CachingRenderer renders documents with a Renderer and stores the output in a
+// Cache, so rendering the same document again copies the stored output instead
+// of parsing it. Only output rendered without an error is stored, and
+// warnings are only reported when a document is rendered.
+type CachingRenderer struct {
+	re    *Renderer
+	cache Cache
+	// fingerprint identifies the configuration of re in keys
+	fingerprint []byte
+}
+
+// NewCachingRenderer returns a CachingRenderer configured by opts storing
+// output in cache. Keys are a hash of the document and the options, options
+// taking a function such as WithNormalizer or WithURLRewriter only add
+// whether they are set, so renderers using different functions must not share
+// a Cache.
+func NewCachingRenderer(cache Cache, opts ...Option) *CachingRenderer {
+	re := NewRenderer(opts...)
+	return &CachingRenderer{re: re, cache: cache, fingerprint: re.fingerprint()}
+}
+
+// cacheVersion changes when a change to rendering changes output, so output
+// stored on disk by an earlier version is not used
+const cacheVersion = 4
+
+// fingerprint returns the configuration of re that affects output
+func (re *Renderer) fingerprint() []byte {
+	return []byte(fmt.Sprintf("rnzml %d %q %q %q %q %q %q %q %q %q %t %t %d %t %t %d %d %d %d %d %t %d %t %v %t %q %t %q %t %d %t %d %t %q",
+		cacheVersion,
+		re.codeBlockStart, re.codeBlockEnd, re.textBlockStart, re.textBlockEnd,
+		re.boldTextStart, re.boldTextEnd, re.codeTextStart, re.codeTextEnd, re.newline,
+		re.externalLinksInNewTab, re.rewriteURL != nil, re.trailingBackslash, re.normalize != nil,
+		re.canonical, re.maxLineLength, re.maxInputBytes, re.maxLines, re.maxOutputBytes,
+		re.tabWidth, re.blankWhitespaceLines, re.accessibility, re.print,
+		re.profiles, re.profiles != nil, re.shortcodeNames(), re.regions, re.region, re.sourceLines, re.lineBreaks, re.preformatted, re.typography,
+		re.lineNumbers, re.copyableStart,
+	))
+}
+
+// key returns the cache key of in
+func (c *CachingRenderer) key(in []byte) string {
+	h := sha256.New()
+	h.Write(c.fingerprint) //nolint: errcheck
+	h.Write(in)            //nolint: errcheck
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RenderToBytes returns the stored output for in, or renders in and stores the
+// output
+func (c *CachingRenderer) RenderToBytes(in []byte) ([]byte, error) {
+	key := c.key(in)
+	if output, ok := c.cache.Get(key); ok {
+		return append([]byte(nil), output...), nil
+	}
+	output, err := c.re.RenderToBytes(in)
+	if err != nil {
+		return output, err
+	}
+	c.cache.Add(key, append([]byte(nil), output...))
+	return output, nil
+}
+
+// Render reads all of in and writes the stored output for it to out, or
+// renders it and stores the output. Unlike Renderer.Render it holds the whole
+// document in memory.
+func (c *CachingRenderer) Render(in io.Reader, out io.Writer) error {
+	if c.re.maxInputBytes > 0 {
+		// Read one byte past the limit so that rendering fails on it
+		in = io.LimitReader(in, int64(c.re.maxInputBytes)+1)
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	key := c.key(data)
+	if output, ok := c.cache.Get(key); ok {
+		_, err := out.Write(output)
+		return err
+	}
+	buf := &bytes.Buffer{}
+	buf.Grow(c.re.estimateOutputSize(len(data)))
+	err = c.re.Render(bytes.NewReader(data), buf)
+	if err == nil {
+		c.cache.Add(key, buf.Bytes())
+	}
+	if _, writeErr := out.Write(buf.Bytes()); err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// LRUCache is an in memory Cache that evicts the least recently used output
+// once the output stored exceeds its size
+type LRUCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	// entries is ordered from most to least recently used
+	entries *list.List
+	keys    map[string]*list.Element
+}
+
+type lruEntry struct {
+	key    string
+	output []byte
+}
+
+// NewLRUCache returns an LRUCache storing up to maxBytes of output
+func NewLRUCache(maxBytes int) *LRUCache {
+	return &LRUCache{maxBytes: maxBytes, entries: list.New(), keys: map[string]*list.Element{}}
+}
+
+// Get returns the output stored for key and marks it as recently used
+func (c *LRUCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.keys[key]
+	if !ok {
+		return nil, false
+	}
+	c.entries.MoveToFront(e)
+	return e.Value.(*lruEntry).output, true
+}
+
+// Add stores output for key, evicting least recently used output to make
+// room. Output larger than the cache is not stored.
+func (c *LRUCache) Add(key string, output []byte) {
+	if len(output) > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.keys[key]; ok {
+		c.size -= len(e.Value.(*lruEntry).output)
+		c.entries.Remove(e)
+	}
+	c.keys[key] = c.entries.PushFront(&lruEntry{key: key, output: output})
+	c.size += len(output)
+	for c.size > c.maxBytes {
+		e := c.entries.Back()
+		entry := e.Value.(*lruEntry)
+		c.entries.Remove(e)
+		delete(c.keys, entry.key)
+		c.size -= len(entry.output)
+	}
+}
+
+// Len returns the number of outputs stored
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Len()
+}
+
+// DiskCache is a Cache storing each output in a file in a directory. Failing
+// to read or write a file is treated as the output not being stored.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a DiskCache storing files in dir, which must exist
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{dir: dir}
+}
+
+// Get returns the output stored in the file for key
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	output, err := os.ReadFile(filepath.Join(c.dir, key))
+	if err != nil {
+		return nil, false
+	}
+	return output, true
+}
+
+// Add writes output to the file for key. The file is written under a temporary
+// name then renamed, so concurrent readers never see partial output.
+func (c *DiskCache) Add(key string, output []byte) {
+	f, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(output)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(c.dir, key))
+	}
+	if err != nil {
+		os.Remove(f.Name()) //nolint: errcheck
+	}
+}
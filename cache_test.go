@@ -0,0 +1,130 @@
+package rnzml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// countingCache counts calls to a Cache
+type countingCache struct {
+	Cache
+	hits, adds int
+}
+
+func (c *countingCache) Get(key string) ([]byte, bool) {
+	output, ok := c.Cache.Get(key)
+	if ok {
+		c.hits++
+	}
+	return output, ok
+}
+
+func (c *countingCache) Add(key string, output []byte) {
+	c.adds++
+	c.Cache.Add(key, output)
+}
+
+func TestCachingRenderer(t *testing.T) {
+	caches := []struct {
+		name  string
+		cache func(t *testing.T) Cache
+	}{
+		{"lru", func(t *testing.T) Cache { return NewLRUCache(1 << 20) }},
+		{"disk", func(t *testing.T) Cache { return NewDiskCache(t.TempDir()) }},
+	}
+	for _, tt := range caches {
+		t.Run("Should store output in the "+tt.name+" cache", func(t *testing.T) {
+			cache := &countingCache{Cache: tt.cache(t)}
+			c := NewCachingRenderer(cache)
+			expected, _ := r.RenderToBytes([]byte(benchmarkDocument))
+			for i := 0; i < 2; i++ {
+				got, err := c.RenderToBytes([]byte(benchmarkDocument))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(expected) != string(got) {
+					t.Errorf("expected %d bytes of output got: %d", len(expected), len(got))
+				}
+				out := &strings.Builder{}
+				if err := c.Render(strings.NewReader(benchmarkDocument), out); err != nil {
+					t.Fatal(err)
+				}
+				if string(expected) != out.String() {
+					t.Errorf("expected %d bytes of output got: %d", len(expected), out.Len())
+				}
+			}
+			if cache.adds != 1 || cache.hits != 3 {
+				t.Errorf("expected: 1 add and 3 hits got: %d adds and %d hits", cache.adds, cache.hits)
+			}
+		})
+	}
+	t.Run("Should key output by options", func(t *testing.T) {
+		cache := NewLRUCache(1 << 20)
+		plain, _ := NewCachingRenderer(cache).RenderToBytes([]byte("a\n\nb"))
+		canonical, _ := NewCachingRenderer(cache, WithCanonicalOutput()).RenderToBytes([]byte("a\n\nb"))
+		if string(plain) == string(canonical) || cache.Len() != 2 {
+			t.Errorf("expected separate output got: '%s' and '%s'", plain, canonical)
+		}
+	})
+	t.Run("Should not store output when rendering fails", func(t *testing.T) {
+		cache := &countingCache{Cache: NewLRUCache(1 << 20)}
+		c := NewCachingRenderer(cache, WithMaxInputBytes(3))
+		for i := 0; i < 2; i++ {
+			err := c.Render(strings.NewReader("abcd"), &strings.Builder{})
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Errorf("expected *LimitError got: '%v'", err)
+			}
+		}
+		if cache.adds != 0 {
+			t.Errorf("expected: 0 adds got: %d", cache.adds)
+		}
+	})
+}
+
+func TestLRUCache(t *testing.T) {
+	t.Run("Should evict the least recently used output", func(t *testing.T) {
+		c := NewLRUCache(6)
+		c.Add("a", []byte("aa"))
+		c.Add("b", []byte("bb"))
+		c.Add("c", []byte("cc"))
+		c.Get("a")
+		c.Add("d", []byte("dd"))
+		if _, ok := c.Get("b"); ok {
+			t.Error("expected b to be evicted")
+		}
+		for _, key := range []string{"a", "c", "d"} {
+			if _, ok := c.Get(key); !ok {
+				t.Errorf("expected %s to be stored", key)
+			}
+		}
+	})
+	t.Run("Should not store output larger than the cache", func(t *testing.T) {
+		c := NewLRUCache(1)
+		c.Add("a", []byte("aa"))
+		if c.Len() != 0 {
+			t.Errorf("expected: 0 entries got: %d", c.Len())
+		}
+	})
+	t.Run("Should replace output for the same key", func(t *testing.T) {
+		c := NewLRUCache(4)
+		c.Add("a", []byte("aa"))
+		c.Add("a", []byte("bbb"))
+		if got, _ := c.Get("a"); string(got) != "bbb" || c.size != 3 {
+			t.Errorf("expected: 'bbb' got: '%s' with size %d", got, c.size)
+		}
+	})
+}
+
+func BenchmarkCachingRenderer(b *testing.B) {
+	c := NewCachingRenderer(NewLRUCache(1 << 20))
+	in := []byte(benchmarkDocument)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(in)))
+	for i := 0; i < b.N; i++ {
+		if _, err := c.RenderToBytes(in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Command capi builds rnzml as a C library, the functions exported are
+// declared in rnzml.h. See rnzml.h for how to build it.
+package main
+
+// #include <stdlib.h>
+import "C"
+
+import (
+	"errors"
+	"math"
+	"unsafe"
+)
+
+func main() {}
+
+// errInputTooLong is returned for inputs C.GoBytes cannot copy, as it takes
+// their length as a C int
+var errInputTooLong = errors.New("input is longer than 2147483647 bytes")
+
+//export rnzml_render
+func rnzml_render(in *C.char, inLen C.size_t, flags C.int, out **C.char, outLen *C.size_t, errMessage **C.char) C.int {
+	var output []byte
+	var err error
+	if uint64(inLen) > math.MaxInt32 {
+		err = errInputTooLong
+	} else {
+		output, err = render(C.GoBytes(unsafe.Pointer(in), C.int(inLen)), int(flags))
+	}
+	*out = (*C.char)(C.CBytes(output))
+	*outLen = C.size_t(len(output))
+	*errMessage = nil
+	if err != nil {
+		*errMessage = C.CString(err.Error())
+		return 1
+	}
+	return 0
+}
+
+//export rnzml_free
+func rnzml_free(p unsafe.Pointer) {
+	C.free(p)
+}
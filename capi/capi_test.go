@@ -0,0 +1,77 @@
+//go:build cgo
+// +build cgo
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+var rendertests = []struct {
+	in    string
+	flags int
+	out   string
+	err   string
+}{
+	{"a *b*", 0, "<p>a <strong>b</strong>\n</p>\n", ""},
+	{"a\n\nb", flagCanonical, "<p>a</p>\n<p>b</p>\n", ""},
+	{"[https://res.nz]", flagExternalLinksInNewTab, "<p><a href=\"https://res.nz\" target=\"_blank\" rel=\"noopener\">https://res.nz</a>\n</p>\n", ""},
+	{"*a", 0, "<p><strong>a", "line 1: unclosed bold text (*) at position: 0"},
+}
+
+func TestRender(t *testing.T) {
+	for _, tt := range rendertests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := render([]byte(tt.in), tt.flags)
+			if tt.out != string(out) {
+				t.Errorf("expected: '%s' got: '%s'", tt.out, out)
+			}
+			if err == nil && tt.err != "" || err != nil && err.Error() != tt.err {
+				t.Errorf("expected error: '%s' got: '%v'", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestCArchive(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a C archive and links a C program")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler")
+	}
+	t.Run("Should render from C", func(t *testing.T) {
+		dir := t.TempDir()
+		archive := filepath.Join(dir, "librnzml.a")
+		if out, err := exec.Command(goTool, "build", "-buildmode=c-archive", "-o", archive, ".").CombinedOutput(); err != nil {
+			t.Fatalf("error: %s\n%s", err.Error(), out)
+		}
+		example := filepath.Join(dir, "example")
+		cmd := exec.Command(cc, "-I.", "-o", example, filepath.Join("testdata", "example.c"), archive, "-lpthread")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("error: %s\n%s", err.Error(), out)
+		}
+		for _, tt := range rendertests {
+			if tt.flags != flagCanonical {
+				continue
+			}
+			cmd := exec.Command(example, tt.in)
+			cmd.Stderr = os.Stderr
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != string(out) {
+				t.Errorf("expected: '%s' got: '%s'", tt.out, out)
+			}
+		}
+	})
+}
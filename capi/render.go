@@ -0,0 +1,30 @@
+//go:build cgo
+// +build cgo
+
+package main
+
+import (
+	"github.com/Resonance1584/rnzml"
+)
+
+// Flags from rnzml.h
+const (
+	flagCanonical             = 1
+	flagExternalLinksInNewTab = 2
+	flagBlankWhitespaceLines  = 4
+)
+
+// render renders in with the options set in flags
+func render(in []byte, flags int) ([]byte, error) {
+	var opts []rnzml.Option
+	if flags&flagCanonical != 0 {
+		opts = append(opts, rnzml.WithCanonicalOutput())
+	}
+	if flags&flagExternalLinksInNewTab != 0 {
+		opts = append(opts, rnzml.WithExternalLinksInNewTab())
+	}
+	if flags&flagBlankWhitespaceLines != 0 {
+		opts = append(opts, rnzml.WithBlankWhitespaceLines())
+	}
+	return rnzml.NewRenderer(opts...).RenderToBytes(in)
+}
@@ -0,0 +1,30 @@
+/*
+ * C interface to rnzml, built as a shared library with
+ *
+ *     go build -buildmode=c-shared -o librnzml.so ./capi
+ *
+ * or as a static archive with -buildmode=c-archive.
+ */
+#ifndef RNZML_H
+#define RNZML_H
+
+#include <stddef.h>
+
+/* Flags passed to rnzml_render, combined with | */
+#define RNZML_CANONICAL 1
+#define RNZML_EXTERNAL_LINKS_IN_NEW_TAB 2
+#define RNZML_BLANK_WHITESPACE_LINES 4
+
+/*
+ * rnzml_render renders the in_len bytes at in. On success it returns 0 and sets
+ * *out and *out_len to the rendered HTML. On failure it returns 1 and sets *out
+ * and *out_len to the output rendered before the error and *err to a NUL
+ * terminated error message, *err is NULL on success. Inputs longer than
+ * 2147483647 bytes fail. *out and *err must be released with rnzml_free.
+ */
+int rnzml_render(const char *in, size_t in_len, int flags, char **out, size_t *out_len, char **err);
+
+/* rnzml_free releases memory returned by rnzml_render, p may be NULL */
+void rnzml_free(void *p);
+
+#endif
@@ -0,0 +1,18 @@
+#include <stdio.h>
+#include <string.h>
+
+#include "rnzml.h"
+
+int main(int argc, char **argv) {
+	const char *in = argc > 1 ? argv[1] : "Hello *world*";
+	char *out, *err;
+	size_t out_len;
+	int failed = rnzml_render(in, strlen(in), RNZML_CANONICAL, &out, &out_len, &err);
+	fwrite(out, 1, out_len, stdout);
+	if (failed) {
+		fprintf(stderr, "%s\n", err);
+	}
+	rnzml_free(out);
+	rnzml_free(err);
+	return failed;
+}
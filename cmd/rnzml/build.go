@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+// build runs the build command, which renders the .rnzml files of a directory
+// tree to .html files of another directory and copies the other files
+func build(args []string, s stdio) error {
+	flags := flag.NewFlagSet("rnzml build", flag.ContinueOnError)
+	canonical := flags.Bool("canonical", false, "render canonical output")
+	if err := parseFlags(flags, args, s); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		fmt.Fprintln(s.err, "usage: rnzml build [flags] src dst")
+		flags.PrintDefaults()
+		return errUsage
+	}
+	var opts []rnzml.Option
+	if *canonical {
+		opts = append(opts, rnzml.WithCanonicalOutput())
+	}
+	results, err := rnzml.RenderFS(os.DirFS(flags.Arg(0)), rnzml.DirWriteFS(flags.Arg(1)), opts...)
+	failed := 0
+	for _, r := range results {
+		for _, w := range r.Warnings {
+			fmt.Fprintf(s.err, "%s: warning: %s\n", r.Source, w)
+		}
+		if r.Err != nil {
+			fmt.Fprintln(s.err, r.Err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(results))
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	t.Run("Should render a directory to a directory", func(t *testing.T) {
+		src, dst := t.TempDir(), t.TempDir()
+		os.MkdirAll(filepath.Join(src, "guide"), 0o700)
+		os.WriteFile(filepath.Join(src, "guide", "intro.rnzml"), []byte("*a*\n"), 0o600)
+		os.WriteFile(filepath.Join(src, "style.css"), []byte("p {}"), 0o600)
+		stderr := &strings.Builder{}
+		if status := run([]string{"build", "-canonical", src, dst}, stdio{out: io.Discard, err: stderr}); status != 0 {
+			t.Fatalf("expected status: 0 got: %d %s", status, stderr)
+		}
+		if b, err := os.ReadFile(filepath.Join(dst, "guide", "intro.html")); err != nil || string(b) != "<p><strong>a</strong></p>\n" {
+			t.Errorf("expected rendered intro.html got: %q %v", b, err)
+		}
+		if b, err := os.ReadFile(filepath.Join(dst, "style.css")); err != nil || string(b) != "p {}" {
+			t.Errorf("expected copied style.css got: %q %v", b, err)
+		}
+	})
+	t.Run("Should report the files that failed", func(t *testing.T) {
+		src := t.TempDir()
+		os.WriteFile(filepath.Join(src, "a.rnzml"), []byte("*a"), 0o600)
+		os.WriteFile(filepath.Join(src, "b.rnzml"), []byte("b"), 0o600)
+		stderr := &strings.Builder{}
+		if status := run([]string{"build", src, t.TempDir()}, stdio{out: io.Discard, err: stderr}); status != 1 {
+			t.Errorf("expected status: 1 got: %d", status)
+		}
+		expected := "render a.rnzml: line 1: unclosed bold text (*) at position: 0\nrnzml build: 1 of 2 files failed\n"
+		if stderr.String() != expected {
+			t.Errorf("expected: %q got: %q", expected, stderr.String())
+		}
+	})
+	t.Run("Should require a source and destination", func(t *testing.T) {
+		if status := run([]string{"build", "a"}, stdio{out: io.Discard, err: io.Discard}); status != 2 {
+			t.Errorf("expected status: 2 got: %d", status)
+		}
+	})
+}
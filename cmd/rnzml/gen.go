@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+// gen runs the gen command, which renders .rnzml files into Go source
+// declaring their HTML as constants, for go:generate:
+//
+//	//go:generate rnzml gen -o help.go help.rnzml getting-started.rnzml
+//
+// Each constant is named after its file, getting-started.rnzml is declared as
+// GettingStarted. The package defaults to $GOPACKAGE, which go generate sets.
+func gen(args []string, s stdio) error {
+	flags := flag.NewFlagSet("rnzml gen", flag.ContinueOnError)
+	pkg := flags.String("package", os.Getenv("GOPACKAGE"), "package of the generated source, $GOPACKAGE by default")
+	output := flags.String("o", "", "file to write the source to, stdout by default")
+	html := flags.Bool("template", false, "declare constants of type template.HTML")
+	prefix := flags.String("prefix", "", "prefix of the constant names")
+	canonical := flags.Bool("canonical", false, "render canonical output")
+	if err := parseFlags(flags, args, s); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 || *pkg == "" {
+		fmt.Fprintln(s.err, "usage: rnzml gen [flags] file.rnzml...")
+		flags.PrintDefaults()
+		return errUsage
+	}
+	var opts []rnzml.Option
+	if *canonical {
+		opts = append(opts, rnzml.WithCanonicalOutput())
+	}
+	src, err := generate(*pkg, *prefix, *html, flags.Args(), opts...)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = s.out.Write(src)
+		return err
+	}
+	return os.WriteFile(*output, src, 0o666)
+}
+
+// generate returns the Go source of package pkg declaring the HTML of files
+// rendered with opts
+func generate(pkg, prefix string, html bool, files []string, opts ...rnzml.Option) ([]byte, error) {
+	re := rnzml.NewRenderer(opts...)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by rnzml gen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	typ := ""
+	if html {
+		b.WriteString("import \"html/template\"\n\n")
+		typ = " template.HTML"
+	}
+	names := map[string]string{}
+	for _, file := range files {
+		name := prefix + identifier(strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)))
+		if !isExported(name) {
+			return nil, fmt.Errorf("%s: cannot name a constant after the file, use -prefix", file)
+		}
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("%s: constant %s is already declared for %s", file, name, other)
+		}
+		names[name] = file
+		in, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		out, err := re.RenderToBytes(in)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		fmt.Fprintf(&b, "// %s is the HTML of %s\nconst %s%s = %s\n\n", name, filepath.ToSlash(file), name, typ, quote(string(out)))
+	}
+	return format.Source(b.Bytes())
+}
+
+// identifier returns name as an exported Go identifier, with the words
+// separated by characters that cannot be in an identifier capitalized
+func identifier(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isExported reports whether name is an exported Go identifier
+func isExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// quote returns s as a Go string literal, a raw string when it can be one so
+// the generated source is readable
+func quote(s string) string {
+	if strings.ContainsAny(s, "`\r\x00\ufeff") || !utf8.ValidString(s) {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var gentests = []struct {
+	files    map[string]string
+	prefix   string
+	html     bool
+	expected string
+}{
+	{map[string]string{"getting-started.rnzml": "a *b*"}, "", false, "// Code generated by rnzml gen. DO NOT EDIT.\n\npackage docs\n\n" +
+		"// GettingStarted is the HTML of getting-started.rnzml\nconst GettingStarted = `<p>a <strong>b</strong>\n</p>\n`\n"},
+	{map[string]string{"help.rnzml": "`\\``"}, "Doc", true, "// Code generated by rnzml gen. DO NOT EDIT.\n\npackage docs\n\nimport \"html/template\"\n\n" +
+		"// DocHelp is the HTML of help.rnzml\nconst DocHelp template.HTML = \"<p><code>`</code>\\n</p>\\n\"\n"},
+}
+
+func TestGen(t *testing.T) {
+	for _, tt := range gentests {
+		t.Run(tt.expected, func(t *testing.T) {
+			dir := t.TempDir()
+			var files []string
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				files = append(files, path)
+			}
+			src, err := generate("docs", tt.prefix, tt.html, files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := strings.ReplaceAll(tt.expected, "is the HTML of ", "is the HTML of "+filepath.ToSlash(dir)+"/")
+			if expected != string(src) {
+				t.Errorf("expected: %q got: %q", expected, src)
+			}
+		})
+	}
+	t.Run("Should return errors naming the file", func(t *testing.T) {
+		dir := t.TempDir()
+		for name, content := range map[string]string{"a.rnzml": "*a", "1.rnzml": "a", "b-c.rnzml": "b", "b_c.rnzml": "c"} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, tt := range []struct {
+			files    []string
+			expected string
+		}{
+			{[]string{"a.rnzml"}, "a.rnzml: line 1: unclosed bold text (*) at position: 0"},
+			{[]string{"1.rnzml"}, "1.rnzml: cannot name a constant after the file, use -prefix"},
+			{[]string{"b-c.rnzml", "b_c.rnzml"}, "b_c.rnzml: constant BC is already declared for " + filepath.Join(dir, "b-c.rnzml")},
+			{[]string{"d.rnzml"}, "d.rnzml: no such file or directory"},
+		} {
+			var files []string
+			for _, file := range tt.files {
+				files = append(files, filepath.Join(dir, file))
+			}
+			_, err := generate("docs", "", false, files)
+			if err == nil || !strings.HasSuffix(err.Error(), tt.expected) {
+				t.Errorf("expected: '...%s' got: '%v'", tt.expected, err)
+			}
+		}
+	})
+	t.Run("Should write to the output file", func(t *testing.T) {
+		dir := t.TempDir()
+		in, out := filepath.Join(dir, "a.rnzml"), filepath.Join(dir, "a.go")
+		if err := os.WriteFile(in, []byte("a"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		stderr := &strings.Builder{}
+		if status := run([]string{"gen", "-package", "a", "-o", out, in}, stdio{out: stderr, err: stderr}); status != 0 {
+			t.Fatalf("expected status: 0 got: %d %s", status, stderr)
+		}
+		src, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(src), "const A = `<p>a\n</p>\n`") {
+			t.Errorf("expected a constant got: %s", src)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Resonance1584/rnzml/lint"
+)
+
+// runLint runs the lint command, which reports the issues of documents read
+// from files or stdin, as text or as JSON with -json
+func runLint(args []string, s stdio) error {
+	flags := flag.NewFlagSet("rnzml lint", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "write the issues as a JSON array of diagnostics")
+	if err := parseFlags(flags, args, s); err != nil {
+		return err
+	}
+	linter := lint.New()
+	var diagnostics []lint.Diagnostic
+	if flags.NArg() == 0 {
+		issues, err := linter.Lint(s.in)
+		if err != nil {
+			return err
+		}
+		diagnostics = lint.Diagnostics("-", issues)
+	}
+	for _, file := range flags.Args() {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		issues, err := linter.Lint(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		diagnostics = append(diagnostics, lint.Diagnostics(file, issues)...)
+	}
+	if *asJSON {
+		if err := lint.WriteJSON(s.out, diagnostics); err != nil {
+			return err
+		}
+	} else {
+		for _, d := range diagnostics {
+			fmt.Fprintf(s.out, "%s:%d:%d: %s: %s (%s)\n", d.File, d.Line, d.Column, d.Severity, d.Message, d.Rule)
+		}
+	}
+	errors := 0
+	for _, d := range diagnostics {
+		if d.Severity == lint.Error {
+			errors++
+		}
+	}
+	if errors > 0 {
+		return fmt.Errorf("%d of %d issues are errors", errors, len(diagnostics))
+	}
+	return nil
+}
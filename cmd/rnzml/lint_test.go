@@ -0,0 +1,41 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	t.Run("Should report the issues of stdin", func(t *testing.T) {
+		stdout := &strings.Builder{}
+		if status := run([]string{"lint"}, stdio{in: strings.NewReader("a \n"), out: stdout, err: io.Discard}); status != 0 {
+			t.Errorf("expected status: 0 got: %d", status)
+		}
+		if expected := "-:1:2: warning: trailing whitespace (trailing-whitespace)\n"; stdout.String() != expected {
+			t.Errorf("expected: %q got: %q", expected, stdout.String())
+		}
+	})
+	t.Run("Should write JSON and fail for errors", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "a.rnzml")
+		if err := os.WriteFile(file, []byte("*a"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		stdout, stderr := &strings.Builder{}, &strings.Builder{}
+		if status := run([]string{"lint", "-json", file}, stdio{out: stdout, err: stderr}); status != 1 {
+			t.Errorf("expected status: 1 got: %d", status)
+		}
+		expected := `[
+  {"file":"` + file + `","line":1,"column":1,"rule":"syntax","severity":"error","message":"line 1: unclosed bold text (*) at position: 0"}
+]
+`
+		if stdout.String() != expected {
+			t.Errorf("expected: %s got: %s", expected, stdout.String())
+		}
+		if stderr.String() != "rnzml lint: 1 of 1 issues are errors\n" {
+			t.Errorf("unexpected stderr: %q", stderr.String())
+		}
+	})
+}
@@ -0,0 +1,102 @@
+// Command rnzml works with rnzml documents from the command line. Usage:
+//
+//	rnzml <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	build      render a directory of documents to a directory of HTML
+//	gen        generate Go constants of the HTML of .rnzml files
+//	lint       report the issues of documents, as text or JSON
+//	render     render a document or an archive of documents to HTML
+//	repl       render lines as they are entered
+//	serve-api  serve rendering and linting as an HTTP JSON API
+//
+// Run rnzml <command> -h for the flags of a command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// stdio is the input and outputs of a command
+type stdio struct {
+	in       io.Reader
+	out, err io.Writer
+}
+
+// command is a subcommand of rnzml
+type command struct {
+	summary string
+	run     func(args []string, s stdio) error
+}
+
+var commands = map[string]command{
+	"build":     {"render a directory of documents to a directory of HTML", build},
+	"gen":       {"generate Go constants of the HTML of .rnzml files", gen},
+	"lint":      {"report the issues of documents, as text or JSON", runLint},
+	"render":    {"render a document or an archive of documents to HTML", render},
+	"repl":      {"render lines as they are entered", repl},
+	"serve-api": {"serve rendering and linting as an HTTP JSON API", serveAPI},
+}
+
+// errUsage is returned by commands given invalid arguments, after printing
+// their usage
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	os.Exit(run(os.Args[1:], stdio{in: os.Stdin, out: os.Stdout, err: os.Stderr}))
+}
+
+// run runs the command named by args[0] and returns the exit status
+func run(args []string, s stdio) int {
+	if len(args) == 0 {
+		usage(s.err)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(s.err, "rnzml: unknown command %q\n", args[0])
+		usage(s.err)
+		return 2
+	}
+	err := cmd.run(args[1:], s)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	}
+	fmt.Fprintf(s.err, "rnzml %s: %v\n", args[0], err)
+	return 1
+}
+
+// usage prints the commands of rnzml to w
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: rnzml <command> [flags] [arguments]")
+	fmt.Fprintln(w, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+// parseFlags parses args with flags, returning errUsage for invalid flags
+func parseFlags(flags *flag.FlagSet, args []string, s stdio) error {
+	flags.SetOutput(s.err)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
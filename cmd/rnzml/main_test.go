@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var runtests = []struct {
+	args   []string
+	status int
+	stderr string
+}{
+	{nil, 2, "usage: rnzml <command> [flags] [arguments]"},
+	{[]string{"a"}, 2, `rnzml: unknown command "a"`},
+	{[]string{"gen", "-package", "a"}, 2, "usage: rnzml gen [flags] file.rnzml..."},
+	{[]string{"render", "a", "b"}, 2, "usage: rnzml render [flags] [file]"},
+	{[]string{"render", "missing.rnzml"}, 1, "rnzml render: open missing.rnzml"},
+	{[]string{"repl", "a"}, 2, "Usage of rnzml repl:"},
+	{[]string{"serve-api", "-h"}, 0, "Usage of rnzml serve-api:"},
+	{[]string{"serve-api", "-a"}, 2, "flag provided but not defined: -a"},
+	{[]string{"serve-api", "a"}, 2, "Usage of rnzml serve-api:"},
+	{[]string{"serve-api", "-addr", "256.0.0.1:a"}, 1, "rnzml serve-api: listen tcp"},
+}
+
+func TestRun(t *testing.T) {
+	for _, tt := range runtests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			stdout, stderr := &strings.Builder{}, &strings.Builder{}
+			status := run(tt.args, stdio{in: strings.NewReader(""), out: stdout, err: stderr})
+			if status != tt.status {
+				t.Errorf("expected status: %d got: %d", tt.status, status)
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("expected stderr containing: %s got: %s", tt.stderr, stderr.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+// render runs the render command, which renders a document, or the documents
+// of an archive with -archive, read from a file or stdin
+func render(args []string, s stdio) (err error) {
+	flags := flag.NewFlagSet("rnzml render", flag.ContinueOnError)
+	output := flags.String("o", "", "file to write to, stdout by default")
+	archive := flags.Bool("archive", false, "read a tar, tar.gz or zip archive and write one of the same format with its .rnzml files rendered to .html")
+	canonical := flags.Bool("canonical", false, "render canonical output")
+	if err := parseFlags(flags, args, s); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(s.err, "usage: rnzml render [flags] [file]")
+		flags.PrintDefaults()
+		return errUsage
+	}
+	var opts []rnzml.Option
+	if *canonical {
+		opts = append(opts, rnzml.WithCanonicalOutput())
+	}
+	in := s.in
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	out := s.out
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	if *archive {
+		err = renderArchive(in, w, opts)
+	} else {
+		err = rnzml.NewRenderer(opts...).Render(in, w)
+	}
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// Magic numbers starting the archive formats render reads
+var (
+	zipMagic      = []byte("PK\x03\x04")
+	emptyZipMagic = []byte("PK\x05\x06")
+	gzipMagic     = []byte{0x1f, 0x8b}
+)
+
+// renderArchive renders the archive in to out, in the format of in
+func renderArchive(in io.Reader, out io.Writer, opts []rnzml.Option) error {
+	r := bufio.NewReader(in)
+	magic, _ := r.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, zipMagic), bytes.HasPrefix(magic, emptyZipMagic):
+		// Zip archives are read from their end, files are read in place
+		if f, ok := in.(*os.File); ok {
+			if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+				return rnzml.RenderZip(f, info.Size(), out, opts...)
+			}
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return rnzml.RenderZip(bytes.NewReader(data), int64(len(data)), out, opts...)
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		w := gzip.NewWriter(out)
+		if err := rnzml.RenderTar(gz, w, opts...); err != nil {
+			return err
+		}
+		return w.Close()
+	}
+	return rnzml.RenderTar(r, out, opts...)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	t.Run("Should render stdin to stdout", func(t *testing.T) {
+		stdout, stderr := &strings.Builder{}, &strings.Builder{}
+		if status := run([]string{"render", "-canonical"}, stdio{in: strings.NewReader("*a*\r\n"), out: stdout, err: stderr}); status != 0 {
+			t.Fatalf("expected status: 0 got: %d %s", status, stderr)
+		}
+		if expected := "<p><strong>a</strong></p>\n"; stdout.String() != expected {
+			t.Errorf("expected: %q got: %q", expected, stdout.String())
+		}
+	})
+	t.Run("Should report errors", func(t *testing.T) {
+		stderr := &strings.Builder{}
+		if status := run([]string{"render"}, stdio{in: strings.NewReader("*a"), out: io.Discard, err: stderr}); status != 1 {
+			t.Errorf("expected status: 1 got: %d", status)
+		}
+		if expected := "rnzml render: line 1: unclosed bold text (*) at position: 0\n"; stderr.String() != expected {
+			t.Errorf("expected: %q got: %q", expected, stderr.String())
+		}
+	})
+	t.Run("Should render tar.gz archives", func(t *testing.T) {
+		var in bytes.Buffer
+		gz := gzip.NewWriter(&in)
+		w := tar.NewWriter(gz)
+		w.WriteHeader(&tar.Header{Name: "a.rnzml", Mode: 0o644, Size: 1, Typeflag: tar.TypeReg})
+		w.Write([]byte("a"))
+		w.Close()
+		gz.Close()
+		var out bytes.Buffer
+		if status := run([]string{"render", "-archive"}, stdio{in: &in, out: &out, err: os.Stderr}); status != 0 {
+			t.Fatalf("expected status: 0 got: %d", status)
+		}
+		r, err := gzip.NewReader(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(r)
+		header, err := tr.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(tr)
+		if header.Name != "a.html" || string(content) != "<p>a\n</p>\n" {
+			t.Errorf("expected a.html got: %s %q", header.Name, content)
+		}
+	})
+	t.Run("Should render zip archive files to a file", func(t *testing.T) {
+		dir := t.TempDir()
+		in, out := filepath.Join(dir, "in.zip"), filepath.Join(dir, "out.zip")
+		var b bytes.Buffer
+		w := zip.NewWriter(&b)
+		f, _ := w.Create("a/b.rnzml")
+		f.Write([]byte("[/c c]"))
+		w.Close()
+		if err := os.WriteFile(in, b.Bytes(), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		stderr := &strings.Builder{}
+		if status := run([]string{"render", "-archive", "-o", out, in}, stdio{out: io.Discard, err: stderr}); status != 0 {
+			t.Fatalf("expected status: 0 got: %d %s", status, stderr)
+		}
+		r, err := zip.OpenReader(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		if len(r.File) != 1 || r.File[0].Name != "a/b.html" {
+			t.Fatalf("expected a/b.html got: %v", r.File)
+		}
+		rc, _ := r.File[0].Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		if expected := "<p><a href=\"/c\">c</a>\n</p>\n"; string(content) != expected {
+			t.Errorf("expected: %q got: %q", expected, content)
+		}
+	})
+}
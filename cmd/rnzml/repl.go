@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+// ANSI escape codes the repl formats text with
+const (
+	ansiBold        = "\x1b[1m"
+	ansiNormal      = "\x1b[22m"
+	ansiGreen       = "\x1b[32m"
+	ansiRed         = "\x1b[31m"
+	ansiDefault     = "\x1b[39m"
+	ansiUnderline   = "\x1b[4m"
+	ansiNoUnderline = "\x1b[24m"
+)
+
+const (
+	prompt         = "rnzml> "
+	continuePrompt = "...> "
+)
+
+const replHelp = `Each line is rendered when it is entered, code blocks when they are closed.
+Lines starting with : are commands, start a line with \: to render a colon.
+
+  :html   show rendered HTML
+  :text   show formatted text
+  :help   show this help
+  :quit   exit, as does the end of input
+`
+
+// repl runs the repl command, which renders the lines read from stdin as they
+// are entered
+func repl(args []string, s stdio) error {
+	flags := flag.NewFlagSet("rnzml repl", flag.ContinueOnError)
+	html := flags.Bool("html", false, "show rendered HTML instead of formatted text")
+	if err := parseFlags(flags, args, s); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return errUsage
+	}
+	session := &replSession{out: s.out, html: *html}
+	return session.run(s.in)
+}
+
+// replSession is the state of a running repl
+type replSession struct {
+	out  io.Writer
+	html bool
+	// code is the code block being entered and fence the line that started it
+	code  []string
+	fence string
+}
+
+// run reads and renders lines from in until its end or :quit
+func (r *replSession) run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	fmt.Fprint(r.out, prompt)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if r.code == nil && strings.HasPrefix(line, ":") {
+			if !r.command(line) {
+				return nil
+			}
+		} else if r.code != nil || isFence(line) {
+			if r.code == nil {
+				r.fence = line
+			}
+			r.code = append(r.code, line)
+			if len(r.code) > 1 && line == r.fence {
+				r.render(strings.Join(r.code, "\n"))
+				r.code = nil
+			}
+		} else {
+			r.render(line)
+		}
+		if r.code != nil {
+			fmt.Fprint(r.out, continuePrompt)
+		} else {
+			fmt.Fprint(r.out, prompt)
+		}
+	}
+	if r.code != nil {
+		// Show the error for the unclosed code block
+		r.render(strings.Join(r.code, "\n"))
+	}
+	fmt.Fprintln(r.out)
+	return scanner.Err()
+}
+
+// isFence reports whether line starts a code block, it contains only three or
+// more backticks
+func isFence(line string) bool {
+	return len(line) >= 3 && strings.Trim(line, "`") == ""
+}
+
+// command runs a repl command and reports whether the repl continues
+func (r *replSession) command(line string) bool {
+	switch strings.TrimSpace(line) {
+	case ":html":
+		r.html = true
+	case ":text":
+		r.html = false
+	case ":help":
+		fmt.Fprint(r.out, replHelp)
+	case ":quit", ":q":
+		return false
+	default:
+		fmt.Fprintf(r.out, "unknown command %s, :help lists the commands\n", line)
+	}
+	return true
+}
+
+// render writes doc rendered, or its error, and its warnings
+func (r *replSession) render(doc string) {
+	var warnings []rnzml.Warning
+	opts := []rnzml.Option{rnzml.WithWarnings(func(w rnzml.Warning) {
+		warnings = append(warnings, w)
+	})}
+	var out []byte
+	var err error
+	if r.html {
+		out, err = rnzml.NewRenderer(opts...).RenderToBytes([]byte(doc))
+	} else {
+		out, err = formatText(doc, opts...)
+	}
+	if err != nil {
+		r.showError(doc, err)
+		return
+	}
+	r.out.Write(out)
+	for _, w := range warnings {
+		fmt.Fprintf(r.out, "%swarning:%s %s\n", ansiRed, ansiDefault, w)
+	}
+}
+
+// showError writes err with the line of doc it is on and a ^ under its
+// position
+func (r *replSession) showError(doc string, err error) {
+	var syntaxErr *rnzml.SyntaxError
+	lines := strings.Split(doc, "\n")
+	if errors.As(err, &syntaxErr) && inlineProblem(syntaxErr.Problem) && syntaxErr.Line <= len(lines) {
+		line := lines[syntaxErr.Line-1]
+		if syntaxErr.Position <= len(line) {
+			fmt.Fprintf(r.out, "  %s\n  %s%s^%s\n", rnzml.HighlightLine(line), indent(line[:syntaxErr.Position]), ansiRed, ansiDefault)
+		}
+	}
+	fmt.Fprintf(r.out, "%serror:%s %v\n", ansiRed, ansiDefault, err)
+}
+
+// inlineProblem reports whether p is a problem at a position in a text block
+func inlineProblem(p rnzml.Problem) bool {
+	switch p {
+	case rnzml.UnclosedBold, rnzml.UnclosedCode, rnzml.UnclosedLink, rnzml.UnclosedEscape, rnzml.MissingLinkURL, rnzml.VagueLinkLabel:
+		return true
+	}
+	return false
+}
+
+// indent returns whitespace as wide as prefix, keeping its tabs
+func indent(prefix string) string {
+	var b strings.Builder
+	for _, c := range prefix {
+		if c == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// formatText renders doc as text formatted with ANSI escape codes for a
+// terminal. Bold text is bold, code is green and links show their URL
+// underlined after their label.
+func formatText(doc string, opts ...rnzml.Option) ([]byte, error) {
+	var b bytes.Buffer
+	l := rnzml.NewLexer(strings.NewReader(doc), opts...)
+	for {
+		t, err := l.Next()
+		if err == io.EOF {
+			return b.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t.Kind {
+		case rnzml.TokenText, rnzml.TokenEscaped:
+			b.Write(t.Text)
+		case rnzml.TokenBoldStart:
+			b.WriteString(ansiBold)
+		case rnzml.TokenBoldEnd:
+			b.WriteString(ansiNormal)
+		case rnzml.TokenCodeStart:
+			b.WriteString(ansiGreen)
+		case rnzml.TokenCodeEnd:
+			b.WriteString(ansiDefault)
+		case rnzml.TokenLink:
+			rawURL, label := t.Text, t.Text
+			if i := bytes.IndexByte(t.Text, ' '); i != -1 {
+				rawURL, label = t.Text[:i], t.Text[i+1:]
+			}
+			if !bytes.Equal(rawURL, label) {
+				b.Write(label)
+				b.WriteString(" <")
+			}
+			b.WriteString(ansiUnderline)
+			b.Write(rawURL)
+			b.WriteString(ansiNoUnderline)
+			if !bytes.Equal(rawURL, label) {
+				b.WriteByte('>')
+			}
+		case rnzml.TokenTextEnd, rnzml.TokenBlankLine:
+			b.WriteByte('\n')
+		case rnzml.TokenCodeBlockLine:
+			if len(t.Text) > 0 {
+				fmt.Fprintf(&b, "  %s%s%s", ansiGreen, t.Text, ansiDefault)
+			}
+			b.WriteByte('\n')
+		}
+	}
+}
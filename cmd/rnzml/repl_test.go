@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var repltests = []struct {
+	in   string
+	html bool
+	out  string
+}{
+	{"", false, "rnzml> \n"},
+	{"a *b* `c`\n", false, "rnzml> a \x1b[1mb\x1b[22m \x1b[32mc\x1b[39m\nrnzml> \n"},
+	{"[/a A] [/b]\n", false, "rnzml> A <\x1b[4m/a\x1b[24m> \x1b[4m/b\x1b[24m\nrnzml> \n"},
+	{"```\n\ta\n\n```\n", false, "rnzml> ...> ...> ...>   \x1b[32m\ta\x1b[39m\n\nrnzml> \n"},
+	{"a *b\n", false, "rnzml>   a \x1b[33m*\x1b[0mb\n    \x1b[31m^\x1b[39m\n\x1b[31merror:\x1b[39m line 1: unclosed bold text (*) at position: 2\nrnzml> \n"},
+	{"é\t*b\n", false, "rnzml>   é\t\x1b[33m*\x1b[0mb\n   \t\x1b[31m^\x1b[39m\n\x1b[31merror:\x1b[39m line 1: unclosed bold text (*) at position: 3\nrnzml> \n"},
+	{"````\n```\n````\n", true, "rnzml> ...> ...> <pre><code>```\n</code></pre>\nrnzml> \n"},
+	{"```\na\n", false, "rnzml> ...> ...> \x1b[31merror:\x1b[39m unclosed code block (```) on line: 1\n\n"},
+	{"*a*\n:text\n", true, "rnzml> <p><strong>a</strong>\n</p>\nrnzml> rnzml> \n"},
+	{":html\n\\:a\n:quit\na\n", false, "rnzml> rnzml> <p>:a\n</p>\nrnzml> "},
+	{":a\n:help\n", false, "rnzml> unknown command :a, :help lists the commands\nrnzml> " + replHelp + "rnzml> \n"},
+	{"[ftp://a.nz]\n", false, "rnzml> \x1b[4mftp://a.nz\x1b[24m\n\x1b[31mwarning:\x1b[39m line 1: link URL has unsupported scheme ftp at position: 0\nrnzml> \n"},
+}
+
+func TestRepl(t *testing.T) {
+	for _, tt := range repltests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := &strings.Builder{}
+			session := &replSession{out: out, html: tt.html}
+			if err := session.run(strings.NewReader(tt.in)); err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != out.String() {
+				t.Errorf("expected: %q got: %q", tt.out, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Resonance1584/rnzml"
+	"github.com/Resonance1584/rnzml/lint"
+)
+
+// serveAPI runs the serve-api command, which serves
+//
+//	POST /render {"source": "...", "options": {...}} -> {"html": "...", "warnings": [...]}
+//	POST /lint   {"source": "...", "options": {...}} -> {"issues": [...]}
+//
+// Documents that cannot be rendered return 422 with {"error": "..."}, and the
+// line and position of syntax errors. The options of a request override the
+// defaults set with flags for that request only.
+func serveAPI(args []string, s stdio) error {
+	flags := flag.NewFlagSet("rnzml serve-api", flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	maxRequestBytes := flags.Int64("max-request-bytes", 1<<20, "maximum size of a request body")
+	canonical := flags.Bool("canonical", false, "render canonical output by default")
+	newTab := flags.Bool("external-links-in-new-tab", false, "open external links in a new tab by default")
+	tabWidth := flags.Int("tab-width", 0, "default width of tabs in code, 0 keeps tabs")
+	maxOutputBytes := flags.Int("max-output-bytes", 10<<20, "maximum size of rendered output, requests may lower it")
+	if err := parseFlags(flags, args, s); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return errUsage
+	}
+	defaults := apiOptions{Canonical: canonical, ExternalLinksInNewTab: newTab, TabWidth: tabWidth, MaxOutputBytes: maxOutputBytes}
+	fmt.Fprintf(s.err, "rnzml serve-api: listening on %s\n", *addr)
+	return http.ListenAndServe(*addr, newAPI(defaults, *maxRequestBytes))
+}
+
+// apiOptions are the render options of a request, options that are not set
+// keep the default of the server
+type apiOptions struct {
+	Canonical             *bool   `json:"canonical"`
+	ExternalLinksInNewTab *bool   `json:"externalLinksInNewTab"`
+	BlankWhitespaceLines  *bool   `json:"blankWhitespaceLines"`
+	SourceLines           *bool   `json:"sourceLines"`
+	TabWidth              *int    `json:"tabWidth"`
+	MaxOutputBytes        *int    `json:"maxOutputBytes"`
+	TrailingBackslash     *string `json:"trailingBackslash"`
+	LineBreaks            *string `json:"lineBreaks"`
+}
+
+// trailingBackslashModes are the values of the trailingBackslash option
+var trailingBackslashModes = map[string]rnzml.TrailingBackslashMode{
+	"error":   rnzml.TrailingBackslashError,
+	"literal": rnzml.TrailingBackslashLiteral,
+	"join":    rnzml.TrailingBackslashJoin,
+}
+
+// lineBreakModes are the values of the lineBreaks option
+var lineBreakModes = map[string]rnzml.LineBreakMode{
+	"newline": rnzml.LineBreakNewline,
+	"space":   rnzml.LineBreakSpace,
+	"br":      rnzml.LineBreakTag,
+}
+
+// renderOptions returns the rnzml options of o, which override those of
+// defaults. A maximum output size can only be lowered.
+func (o apiOptions) renderOptions(defaults apiOptions) ([]rnzml.Option, error) {
+	var opts []rnzml.Option
+	if set(o.Canonical, defaults.Canonical) {
+		opts = append(opts, rnzml.WithCanonicalOutput())
+	}
+	if set(o.ExternalLinksInNewTab, defaults.ExternalLinksInNewTab) {
+		opts = append(opts, rnzml.WithExternalLinksInNewTab())
+	}
+	if set(o.BlankWhitespaceLines, defaults.BlankWhitespaceLines) {
+		opts = append(opts, rnzml.WithBlankWhitespaceLines())
+	}
+	if set(o.SourceLines, defaults.SourceLines) {
+		opts = append(opts, rnzml.WithSourceLines())
+	}
+	if n := value(o.TabWidth, defaults.TabWidth); n < 0 {
+		return nil, errors.New("tabWidth must not be negative")
+	} else if n > rnzml.MaxTabWidth {
+		return nil, fmt.Errorf("tabWidth must not be above %d", rnzml.MaxTabWidth)
+	} else if n > 0 {
+		opts = append(opts, rnzml.WithTabWidth(n))
+	}
+	max := value(defaults.MaxOutputBytes, nil)
+	if n := value(o.MaxOutputBytes, nil); n > 0 && (max == 0 || n < max) {
+		max = n
+	}
+	if max > 0 {
+		opts = append(opts, rnzml.WithMaxOutputBytes(max))
+	}
+	if o.TrailingBackslash != nil {
+		mode, ok := trailingBackslashModes[*o.TrailingBackslash]
+		if !ok {
+			return nil, fmt.Errorf("unknown trailingBackslash: %q", *o.TrailingBackslash)
+		}
+		opts = append(opts, rnzml.WithTrailingBackslash(mode))
+	}
+	if o.LineBreaks != nil {
+		mode, ok := lineBreakModes[*o.LineBreaks]
+		if !ok {
+			return nil, fmt.Errorf("unknown lineBreaks: %q", *o.LineBreaks)
+		}
+		opts = append(opts, rnzml.WithJoinedLineBreaks(mode))
+	}
+	return opts, nil
+}
+
+// set returns the value of override if it is set, or of b
+func set(override, b *bool) bool {
+	if override != nil {
+		return *override
+	}
+	return b != nil && *b
+}
+
+// value returns the value of override if it is set, or of n
+func value(override, n *int) int {
+	if override != nil {
+		return *override
+	}
+	if n != nil {
+		return *n
+	}
+	return 0
+}
+
+// apiRequest is the body of a request to the API
+type apiRequest struct {
+	Source  string     `json:"source"`
+	Options apiOptions `json:"options"`
+}
+
+// apiError is the body of an error response
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// syntaxError is the body of the response for a document with a syntax error
+type syntaxError struct {
+	Error    string `json:"error"`
+	Line     int    `json:"line"`
+	Position int    `json:"position"`
+}
+
+type renderResponse struct {
+	HTML     string   `json:"html"`
+	Warnings []string `json:"warnings"`
+}
+
+type lintIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Line     int    `json:"line"`
+	Position int    `json:"position"`
+	Message  string `json:"message"`
+}
+
+type lintResponse struct {
+	Issues []lintIssue `json:"issues"`
+}
+
+// api handles the requests of serve-api
+type api struct {
+	defaults        apiOptions
+	maxRequestBytes int64
+}
+
+// newAPI returns the handler of serve-api
+func newAPI(defaults apiOptions, maxRequestBytes int64) http.Handler {
+	a := &api{defaults: defaults, maxRequestBytes: maxRequestBytes}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/render", a.handle(a.render))
+	mux.HandleFunc("/lint", a.handle(a.lint))
+	return mux
+}
+
+// handle returns a handler decoding the request for fn and writing its
+// response as JSON
+func (a *api) handle(fn func(req apiRequest, opts []rnzml.Option) (int, interface{})) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, apiError{Error: "method not allowed"})
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, a.maxRequestBytes+1))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, apiError{Error: err.Error()})
+			return
+		}
+		if int64(len(body)) > a.maxRequestBytes {
+			writeJSON(w, http.StatusRequestEntityTooLarge, apiError{Error: fmt.Sprintf("request body exceeds %d bytes", a.maxRequestBytes)})
+			return
+		}
+		var req apiRequest
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, apiError{Error: "invalid request: " + err.Error()})
+			return
+		}
+		opts, err := req.Options.renderOptions(a.defaults)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, apiError{Error: "invalid options: " + err.Error()})
+			return
+		}
+		status, response := fn(req, opts)
+		writeJSON(w, status, response)
+	}
+}
+
+// render handles POST /render
+func (a *api) render(req apiRequest, opts []rnzml.Option) (int, interface{}) {
+	response := renderResponse{Warnings: []string{}}
+	opts = append(opts, rnzml.WithWarnings(func(w rnzml.Warning) {
+		response.Warnings = append(response.Warnings, w.String())
+	}))
+	html, err := rnzml.NewRenderer(opts...).RenderToBytes([]byte(req.Source))
+	if err != nil {
+		var syntaxErr *rnzml.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return http.StatusUnprocessableEntity, syntaxError{Error: err.Error(), Line: syntaxErr.Line, Position: syntaxErr.Position}
+		}
+		return http.StatusUnprocessableEntity, apiError{Error: err.Error()}
+	}
+	response.HTML = string(html)
+	return http.StatusOK, response
+}
+
+// lint handles POST /lint
+func (a *api) lint(req apiRequest, opts []rnzml.Option) (int, interface{}) {
+	issues, err := lint.New(lint.WithRenderOptions(opts...)).Lint(bytes.NewReader([]byte(req.Source)))
+	if err != nil {
+		return http.StatusInternalServerError, apiError{Error: err.Error()}
+	}
+	response := lintResponse{Issues: []lintIssue{}}
+	for _, issue := range issues {
+		response.Issues = append(response.Issues, lintIssue{
+			Rule:     issue.Rule,
+			Severity: issue.Severity.String(),
+			Line:     issue.Line,
+			Position: issue.Position,
+			Message:  issue.Message,
+		})
+	}
+	return http.StatusOK, response
+}
+
+// writeJSON writes v as the JSON body of a response with status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(v)
+}
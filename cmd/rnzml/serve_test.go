@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var apitests = []struct {
+	path   string
+	body   string
+	status int
+	out    string
+}{
+	{"/render", `{"source": "a *b*"}`, 200, `{"html":"<p>a <strong>b</strong>\n</p>\n","warnings":[]}`},
+	{"/render", `{"source": "[http://a.nz a]\n` + "```\\n\\tb\\n```" + `"}`, 200,
+		`{"html":"<p><a href=\"http://a.nz\" target=\"_blank\" rel=\"noopener\">a</a>\n</p>\n<pre><code>  b\n</code></pre>\n","warnings":[]}`},
+	{"/render", `{"source": "[http://a.nz a]\n` + "```\\n\\tb\\n```" + `", "options": {"externalLinksInNewTab": false, "tabWidth": 0}}`, 200,
+		`{"html":"<p><a href=\"http://a.nz\">a</a>\n</p>\n<pre><code>\tb\n</code></pre>\n","warnings":[]}`},
+	{"/render", `{"source": "a\n\nb", "options": {"sourceLines": true}}`, 200, `{"html":"<p data-line=\"1\">a\n</p>\n\n<p data-line=\"3\">b\n</p>\n","warnings":[]}`},
+	{"/render", `{"source": "a\n*b"}`, 422, `{"error":"line 2: unclosed bold text (*) at position: 0","line":2,"position":0}`},
+	{"/render", `{"source": "a\\", "options": {"trailingBackslash": "literal"}}`, 200, `{"html":"<p>a\\\n</p>\n","warnings":[]}`},
+	{"/render", `{"source": "a", "options": {"trailingBackslash": "b"}}`, 400, `{"error":"invalid options: unknown trailingBackslash: \"b\""}`},
+	{"/render", `{"source": "a\\\nb", "options": {"trailingBackslash": "join", "lineBreaks": "br"}}`, 200, `{"html":"<p>a<br>\nb\n</p>\n","warnings":[]}`},
+	{"/render", `{"source": "a", "options": {"lineBreaks": "b"}}`, 400, `{"error":"invalid options: unknown lineBreaks: \"b\""}`},
+	{"/render", `{"source": "a", "options": {"tabWidth": -1}}`, 400, `{"error":"invalid options: tabWidth must not be negative"}`},
+	{"/render", `{"source": "a", "options": {"tabWidth": 4611686018427387904}}`, 400, `{"error":"invalid options: tabWidth must not be above 16"}`},
+	{"/render", `{"source": "abcdefghijkl", "options": {"maxOutputBytes": 4}}`, 422, `{"error":"exceeded limit of 4 output bytes"}`},
+	{"/render", `{"source": "` + strings.Repeat("a", 128) + `", "options": {"maxOutputBytes": 200}}`, 422, `{"error":"exceeded limit of 128 output bytes"}`},
+	{"/render", `{"source": 1}`, 400, `{"error":"invalid request: json: cannot unmarshal number into Go struct field apiRequest.source of type string"}`},
+	{"/render", `{"text": "a"}`, 400, `{"error":"invalid request: json: unknown field \"text\""}`},
+	{"/render", `{"source": "` + strings.Repeat("a", 256) + `"}`, 413, `{"error":"request body exceeds 256 bytes"}`},
+	{"/lint", `{"source": "[/a here] \n*b"}`, 200, `{"issues":[` +
+		`{"rule":"link-label","severity":"warning","line":1,"position":0,"message":"link label \"here\" does not describe the link"},` +
+		`{"rule":"trailing-whitespace","severity":"warning","line":1,"position":9,"message":"trailing whitespace"},` +
+		`{"rule":"syntax","severity":"error","line":2,"position":0,"message":"line 2: unclosed bold text (*) at position: 0"}]}`},
+	{"/lint", `{"source": "a"}`, 200, `{"issues":[]}`},
+}
+
+func TestAPI(t *testing.T) {
+	yes, tabWidth, maxOutputBytes := true, 2, 128
+	handler := newAPI(apiOptions{ExternalLinksInNewTab: &yes, TabWidth: &tabWidth, MaxOutputBytes: &maxOutputBytes}, 256)
+	for _, tt := range apitests {
+		t.Run(tt.path+" "+tt.body, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Errorf("expected status: %d got: %d", tt.status, w.Code)
+			}
+			if got := strings.TrimSuffix(w.Body.String(), "\n"); got != tt.out {
+				t.Errorf("expected: %s got: %s", tt.out, got)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("expected Content-Type: application/json got: %s", ct)
+			}
+		})
+	}
+	t.Run("Should only allow POST", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/render", nil))
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != http.MethodPost {
+			t.Errorf("expected status: 405 got: %d", w.Code)
+		}
+	})
+}
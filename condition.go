@@ -0,0 +1,96 @@
+package rnzml
+
+import (
+	"bytes"
+)
+
+var (
+	ifDirective    = []byte("!if ")
+	elseDirective  = []byte("!else")
+	endIfDirective = []byte("!endif")
+	profilePrefix  = []byte("profile=")
+)
+
+// WithProfiles enables conditional content and sets the profiles it is
+// rendered for, so one document can produce variants such as public and
+// internal. Lines between a line !if profile=name and a line !endif are only
+// rendered when name is one of profiles, and lines between !else and !endif
+// only when it is not. Conditions can be nested, and the !if, !else and !endif
+// lines are not rendered. Directives inside code blocks and joined text blocks
+// are rendered as they are written.
+func WithProfiles(profiles ...string) Option {
+	return func(re *Renderer) {
+		re.profiles = make(map[string]bool, len(profiles))
+		for _, profile := range profiles {
+			re.profiles[profile] = true
+		}
+	}
+}
+
+// condition is an !if directive being scanned
+type condition struct {
+	line int
+	// holds is the value of the condition, and parent is whether the lines
+	// around the !if are rendered
+	holds, parent bool
+	// rendered is whether the lines after the last directive are rendered
+	rendered bool
+}
+
+// directive handles a line that may be a directive, returning true if the line
+// is a directive or is not rendered
+func (s *blockScanner) directive(line []byte, lineNumber int) (bool, error) {
+	conditionRendered := len(s.conditions) == 0 || s.conditions[len(s.conditions)-1].rendered
+	rendered := conditionRendered && (s.re.region == "" || s.named > 0)
+	if f := s.re.openingFence(line); !rendered && s.skippedFence.n == 0 && f.n > 0 {
+		// Directives in code blocks are not rendered either
+		s.skippedFence = f
+		return true, nil
+	}
+	if s.skippedFence.n > 0 {
+		if s.skippedFence.closedBy(line) {
+			s.skippedFence = fence{}
+		}
+		return true, nil
+	}
+	if s.re.profiles != nil {
+		if ok, err := s.condition(line, lineNumber, conditionRendered); ok {
+			return true, err
+		}
+	}
+	if s.re.regions {
+		if ok, err := s.region(line, lineNumber); ok {
+			return true, err
+		}
+	}
+	return !rendered, nil
+}
+
+// condition handles an !if, !else or !endif directive, returning true if line
+// is one. rendered is whether the lines around the directive are rendered.
+func (s *blockScanner) condition(line []byte, lineNumber int, rendered bool) (bool, error) {
+	switch {
+	case bytes.HasPrefix(line, ifDirective):
+		name := bytes.TrimPrefix(line[len(ifDirective):], profilePrefix)
+		if len(name) == len(line)-len(ifDirective) || len(name) == 0 {
+			return true, &SyntaxError{Problem: InvalidCondition, Line: lineNumber}
+		}
+		holds := s.re.profiles[string(name)]
+		s.conditions = append(s.conditions, condition{line: lineNumber, holds: holds, parent: rendered, rendered: rendered && holds})
+		return true, nil
+	case bytes.Equal(line, elseDirective):
+		if len(s.conditions) == 0 {
+			return true, &SyntaxError{Problem: UnmatchedDirective, Line: lineNumber}
+		}
+		c := &s.conditions[len(s.conditions)-1]
+		c.rendered = c.parent && !c.holds
+		return true, nil
+	case bytes.Equal(line, endIfDirective):
+		if len(s.conditions) == 0 {
+			return true, &SyntaxError{Problem: UnmatchedDirective, Line: lineNumber}
+		}
+		s.conditions = s.conditions[:len(s.conditions)-1]
+		return true, nil
+	}
+	return false, nil
+}
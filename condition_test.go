@@ -0,0 +1,62 @@
+package rnzml
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var conditiontests = []struct {
+	in  string
+	out string
+}{
+	{"a\n!if profile=internal\nb\n!endif\nc", "<p>a</p>\n<p>b</p>\n<p>c</p>\n"},
+	{"a\n!if profile=beta\nb\n!else\nc\n!endif", "<p>a</p>\n<p>c</p>\n"},
+	{"!if profile=beta\n```\nb\n!endif\n```\n!endif", ""},
+	{"!if profile=internal\n!if profile=beta\nb\n!else\nc\n!endif\n!endif", "<p>c</p>\n"},
+	{"!if profile=beta\n!if profile=internal\nb\n!else\nc\n!endif\n!endif", ""},
+	{"```\n!if profile=beta\n```", "<pre><code>!if profile=beta\n</code></pre>\n"},
+}
+
+var conditionerrortests = []struct {
+	in  string
+	err string
+}{
+	{"a\n!if profile=internal\nb", "unclosed condition (!if) on line: 2"},
+	{"a\n!endif", "line 2: !else or !endif without !if"},
+	{"!else", "line 1: !else or !endif without !if"},
+	{"!if internal\n!endif", "line 1: conditions must be of the form !if profile=name"},
+	{"!if  profile=internal\n!endif", "line 1: conditions must be of the form !if profile=name"},
+	{"!if profile=\n!endif", "line 1: conditions must be of the form !if profile=name"},
+}
+
+func TestProfiles(t *testing.T) {
+	re := NewRenderer(WithProfiles("internal"), WithCanonicalOutput())
+	for _, tt := range conditiontests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := &strings.Builder{}
+			if err := re.Render(strings.NewReader(tt.in), out); err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != out.String() {
+				t.Errorf("expected: %q got: %q", tt.out, out.String())
+			}
+		})
+	}
+	for _, tt := range conditionerrortests {
+		t.Run(fmt.Sprintf("Should return an error for %q", tt.in), func(t *testing.T) {
+			if err := re.Render(strings.NewReader(tt.in), &strings.Builder{}); fmt.Sprint(err) != tt.err {
+				t.Errorf("expected: '%s' got: '%v'", tt.err, err)
+			}
+		})
+	}
+	t.Run("Should render directives without WithProfiles", func(t *testing.T) {
+		out := &strings.Builder{}
+		if err := NewRenderer(WithCanonicalOutput()).Render(strings.NewReader("!if profile=a\n!endif"), out); err != nil {
+			t.Fatal(err)
+		}
+		if expected := "<p>!if profile=a</p>\n<p>!endif</p>\n"; expected != out.String() {
+			t.Errorf("expected: %q got: %q", expected, out.String())
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package rnzml
+
+// Tags around the code of a block with WithCopyableCodeBlocks. HTML parsers
+// drop a newline directly after <textarea>, so one is written to keep the
+// first line of code when it is blank.
+const (
+	copyableCodeStart = "<textarea hidden readonly>\n"
+	copyableCodeEnd   = "</textarea>\n</div>\n"
+)
+
+// WithCopyableCodeBlocks wraps each code block and preformatted block in a
+// <div> of class class, or without a class when it is empty, followed in the
+// <div> by the code of the block in a hidden <textarea>. Front-ends can copy
+// the value of the <textarea> to the clipboard instead of reading the text of
+// the rendered lines, which is split by the markup of WithLineNumbers and has
+// tabs expanded by WithTabWidth. The <textarea> keeps tabs as written. The
+// lines of a code block are held in memory until it ends, so a code block is
+// never split between the chunks of WithParallelism.
+func WithCopyableCodeBlocks(class string) Option {
+	return func(re *Renderer) {
+		re.copyableStart = []byte("<div>")
+		if class != "" {
+			re.copyableStart = append(appendHTMLEscaped([]byte(`<div class="`), []byte(class)), `">`...)
+		}
+	}
+}
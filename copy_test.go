@@ -0,0 +1,55 @@
+package rnzml
+
+import (
+	"strings"
+	"testing"
+)
+
+var copyabletests = []struct {
+	in    string
+	class string
+	opts  []Option
+	out   string
+}{
+	{"```\n<a>\n\n```", "code", nil, "<div class=\"code\"><pre><code>&lt;a&gt;\n\n</code></pre>\n<textarea hidden readonly>\n&lt;a&gt;\n\n</textarea>\n</div>\n"},
+	{"```\n```\na", "", []Option{WithCanonicalOutput()}, "<div><pre><code></code></pre>\n<textarea hidden readonly>\n</textarea>\n</div>\n<p>a</p>\n"},
+	{"```\n\na\n```", `a"b`, nil, "<div class=\"a&#34;b\"><pre><code>\na\n</code></pre>\n<textarea hidden readonly>\n\na\n</textarea>\n</div>\n"},
+	{"``` 7\na\n```", "c", []Option{WithLineNumbers(), WithSourceLines()}, "<div class=\"c\"><pre data-line=\"1\"><code><span data-line-number=\"7\">a</span>\n</code></pre>\n<textarea hidden readonly>\na\n</textarea>\n</div>\n"},
+	{"~~~\n\ta\n~~~\n```\nb\n```", "c", []Option{WithPreformattedBlocks(), WithTabWidth(2)}, "<div class=\"c\"><pre>  a\n</pre>\n<textarea hidden readonly>\n\ta\n</textarea>\n</div>\n<div class=\"c\"><pre><code>b\n</code></pre>\n<textarea hidden readonly>\nb\n</textarea>\n</div>\n"},
+}
+
+func TestCopyableCodeBlocks(t *testing.T) {
+	for _, tt := range copyabletests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := NewRenderer(append([]Option{WithCopyableCodeBlocks(tt.class)}, tt.opts...)...).RenderToBytes([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != string(out) {
+				t.Errorf("expected: %q got: %q", tt.out, out)
+			}
+		})
+	}
+	t.Run("Should render code blocks longer than a chunk the same in parallel", func(t *testing.T) {
+		in := strings.Repeat("a\n```\n"+strings.Repeat("b <c>\n", 600)+"```\n", 20)
+		expected, err := NewRenderer(WithCopyableCodeBlocks("")).RenderToBytes([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, opt := range []Option{WithParallelism(8), WithPipelining()} {
+			out, err := NewRenderer(WithCopyableCodeBlocks(""), opt).RenderToBytes([]byte(in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(expected) != string(out) {
+				t.Errorf("expected %d bytes of output got: %d", len(expected), len(out))
+			}
+		}
+	})
+	t.Run("Should not change text blocks", func(t *testing.T) {
+		out, err := NewRenderer(WithCopyableCodeBlocks("c")).RenderToBytes([]byte("`a`"))
+		if err != nil || string(out) != "<p><code>a</code>\n</p>\n" {
+			t.Errorf("expected text got: %q %v", out, err)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package rnzml
+
+// Theme is a bundled stylesheet for rendered documents
+type Theme int
+
+const (
+	// ThemeAuto follows the light or dark preference of the reader
+	ThemeAuto Theme = iota
+	ThemeLight
+	ThemeDark
+)
+
+// baseCSS styles the elements rnzml renders, with colors from variables set
+// by each theme
+const baseCSS = `body {
+  max-width: 42rem;
+  margin: 0 auto;
+  padding: 1rem;
+  font: 1.0625rem/1.6 system-ui, -apple-system, "Segoe UI", sans-serif;
+  color: var(--rnzml-text);
+  background: var(--rnzml-background);
+}
+p {
+  margin: 0 0 1rem;
+}
+a {
+  color: var(--rnzml-link);
+}
+code {
+  font: 0.9em/1.4 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace;
+  padding: 0.1em 0.3em;
+  border-radius: 3px;
+  background: var(--rnzml-code-background);
+}
+pre {
+  margin: 0 0 1rem;
+  padding: 0.75rem 1rem;
+  overflow-x: auto;
+  border-radius: 4px;
+  background: var(--rnzml-code-background);
+}
+pre code {
+  padding: 0;
+  background: none;
+}
+pre [data-line-number]::before {
+  content: attr(data-line-number);
+  display: inline-block;
+  min-width: 2em;
+  margin-right: 1em;
+  text-align: right;
+  opacity: 0.6;
+  user-select: none;
+}
+`
+
+const lightCSS = `:root {
+  --rnzml-text: #1f2328;
+  --rnzml-background: #ffffff;
+  --rnzml-link: #0b57d0;
+  --rnzml-code-background: #f3f4f6;
+}
+`
+
+const darkCSS = `:root {
+  --rnzml-text: #e6e6e6;
+  --rnzml-background: #16181d;
+  --rnzml-link: #8ab4f8;
+  --rnzml-code-background: #262a33;
+}
+`
+
+// DefaultCSS returns the stylesheet of ThemeAuto
+func DefaultCSS() string {
+	return ThemeCSS(ThemeAuto)
+}
+
+// ThemeCSS returns a minimal stylesheet for rendered documents in theme, to
+// include in a <style> element of a page
+func ThemeCSS(theme Theme) string {
+	switch theme {
+	case ThemeLight:
+		return lightCSS + baseCSS
+	case ThemeDark:
+		return darkCSS + baseCSS
+	}
+	return lightCSS + "@media (prefers-color-scheme: dark) {\n" + darkCSS + "}\n" + baseCSS
+}
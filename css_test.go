@@ -0,0 +1,30 @@
+package rnzml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThemeCSS(t *testing.T) {
+	for _, theme := range []Theme{ThemeAuto, ThemeLight, ThemeDark} {
+		css := ThemeCSS(theme)
+		if strings.Count(css, "{") != strings.Count(css, "}") {
+			t.Errorf("theme %d has unbalanced braces", theme)
+		}
+		for _, selector := range []string{"p {", "a {", "code {", "pre {", "--rnzml-text"} {
+			if !strings.Contains(css, selector) {
+				t.Errorf("theme %d does not contain: %s", theme, selector)
+			}
+		}
+	}
+	t.Run("Should follow the preference of the reader by default", func(t *testing.T) {
+		if css := DefaultCSS(); !strings.Contains(css, "prefers-color-scheme: dark") {
+			t.Errorf("expected a dark media query got: %s", css)
+		}
+	})
+	t.Run("Should not be closed by a style element", func(t *testing.T) {
+		if strings.Contains(strings.ToLower(DefaultCSS()), "</style") {
+			t.Error("stylesheet must not contain </style")
+		}
+	})
+}
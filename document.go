@@ -0,0 +1,268 @@
+package rnzml
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// Document is rendered input that can be edited, re-rendering only the blocks
+// an edit affects. It is meant for live previews in editors, where rendering a
+// whole document on every keystroke is too slow.
+//
+// The input is split into units that render independently: a text block, a
+// blank line, or a whole code block, condition or region, with the lines that
+// are not rendered before it. Where Render stops at the first error, a
+// Document renders every unit, so the output of a unit that fails is the
+// output written before its error and the rest of the document is still
+// rendered. Limits on the input and output size and on the number of lines
+// apply to the whole document. Warnings are reported for the units rendered by
+// NewDocument and Edit. With WithSourceLines an edit changing the number of
+// lines renders every unit after it, as their line numbers change. A Document
+// is not safe for concurrent use.
+type Document struct {
+	re *Renderer
+	// units renders units, it is re without limits as those are checked for
+	// the whole document
+	units    *Renderer
+	src      []byte
+	out      []byte
+	rendered []unit
+}
+
+// unit is a run of lines that renders independently of the rest of the input
+type unit struct {
+	// start and end are the range of the unit in the input, including the
+	// line ending of its last line
+	start, end int
+	// line is the line number of the first line in the unit
+	line  int
+	lines int
+	// outStart and outEnd are the range of the output of the unit
+	outStart, outEnd int
+	failed           bool
+	// open is whether the unit was scanned to the end of the input, so input
+	// added after it may continue it
+	open bool
+}
+
+// Change describes how an edit changed the output of a Document. The output in
+// the range Start to End of the previous output was replaced with Output.
+type Change struct {
+	Start, End int
+	Output     []byte
+}
+
+// NewDocument renders in as a Document. The returned error is a *LimitError
+// when in exceeds a limit of re, errors rendering in are returned by Err.
+func (re *Renderer) NewDocument(in []byte) (*Document, error) {
+	units := *re
+	units.maxInputBytes, units.maxLines, units.maxOutputBytes = 0, 0, 0
+	d := &Document{re: re, units: &units}
+	if _, err := d.Edit(0, 0, in); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Merge returns a Document of the inputs of docs one after another, rendered
+// by the Renderer of the first, for building a compiled document from parts.
+// A line ending is added after a document whose last line has none, so its last
+// line does not join the first line of the next document. Anything left
+// open at the end of a document, such as a code block or a text block joined
+// with a trailing \, continues into the next.
+func Merge(docs ...*Document) (*Document, error) {
+	if len(docs) == 0 {
+		return nil, errors.New("no documents to merge")
+	}
+	var src []byte
+	for i, d := range docs {
+		src = append(src, d.src...)
+		if i < len(docs)-1 && len(d.src) > 0 && !endsLine(d.src) {
+			src = append(src, '\n')
+		}
+	}
+	return docs[0].re.NewDocument(src)
+}
+
+// Source returns the input of d. It must not be modified.
+func (d *Document) Source() []byte {
+	return d.src
+}
+
+// Output returns the rendered output of d. It must not be modified.
+func (d *Document) Output() []byte {
+	return d.out
+}
+
+// Err returns the error of the first unit of d that fails to render, which is
+// the error Render returns for the same input
+func (d *Document) Err() error {
+	for _, u := range d.rendered {
+		if u.failed {
+			// Render the unit again, as rendering the units before it may
+			// have changed its line number since it failed
+			units := *d.units
+			units.warnings = nil
+			_, err := units.renderUnit(d.src, u.start, u.line, io.Discard)
+			return err
+		}
+	}
+	return nil
+}
+
+// Edit replaces the input from start to end with text and renders the units
+// the edit affects. An edit that would exceed a limit of the Renderer returns
+// a *LimitError and leaves d unchanged.
+func (d *Document) Edit(start, end int, text []byte) (Change, error) {
+	if start < 0 || end < start || end > len(d.src) {
+		return Change{}, errors.New("edit range out of bounds")
+	}
+	delta := len(text) - (end - start)
+	re := d.re
+	if re.maxInputBytes > 0 && len(d.src)+delta > re.maxInputBytes {
+		return Change{}, &LimitError{Limit: LimitInputBytes, Max: re.maxInputBytes}
+	}
+	src := make([]byte, 0, len(d.src)+delta)
+	src = append(append(append(src, d.src[:start]...), text...), d.src[end:]...)
+
+	// Find the first unit the edit touches. An edit starting right after the
+	// line ending of a unit does not change it.
+	first := 0
+	for first < len(d.rendered) && d.rendered[first].end <= start {
+		first++
+	}
+	if first > 0 && first == len(d.rendered) && (d.rendered[first-1].open || !endsLine(d.src[:d.rendered[first-1].end])) {
+		// Text added after a last unit left open, or after a last line
+		// without a line ending, continues it
+		first--
+	}
+
+	// Split units from the start of the first unit until a unit ends where
+	// one ended before the edit, as the units after it are unchanged
+	pos, line, outStart := 0, 1, 0
+	if first < len(d.rendered) {
+		pos, line, outStart = d.rendered[first].start, d.rendered[first].line, d.rendered[first].outStart
+	} else if first > 0 {
+		last := d.rendered[first-1]
+		pos, line, outStart = last.end, last.line+last.lines, last.outEnd
+	}
+	last := first
+	var units []unit
+	out := &bytes.Buffer{}
+	for pos < len(src) {
+		unitStart := outStart + out.Len()
+		u, err := d.units.renderUnit(src, pos, line, out)
+		u.outStart, u.outEnd, u.failed = unitStart, outStart+out.Len(), err != nil
+		units = append(units, u)
+		pos, line = u.end, u.line+u.lines
+
+		for last < len(d.rendered) && d.rendered[last].end+delta < pos {
+			last++
+		}
+		if last < len(d.rendered) && d.rendered[last].end >= end && d.rendered[last].end+delta == pos &&
+			(!d.units.sourceLines || d.rendered[last].line+d.rendered[last].lines == line) {
+			last++
+			break
+		}
+	}
+	if pos == len(src) {
+		// Every unit up to the end of the input was split again
+		last = len(d.rendered)
+	}
+
+	change := Change{Start: outStart, End: outStart, Output: out.Bytes()}
+	if last > first {
+		change.End = d.rendered[last-1].outEnd
+	}
+	outDelta := len(change.Output) - (change.End - change.Start)
+	lineDelta := 0
+	if last < len(d.rendered) {
+		lineDelta = line - d.rendered[last].line
+	}
+
+	rendered := make([]unit, 0, len(d.rendered)-(last-first)+len(units))
+	rendered = append(append(rendered, d.rendered[:first]...), units...)
+	for _, u := range d.rendered[last:] {
+		u.start += delta
+		u.end += delta
+		u.line += lineDelta
+		u.outStart += outDelta
+		u.outEnd += outDelta
+		rendered = append(rendered, u)
+	}
+	lines := 0
+	if len(rendered) > 0 {
+		lines = rendered[len(rendered)-1].line + rendered[len(rendered)-1].lines - 1
+	}
+	if re.maxLines > 0 && lines > re.maxLines {
+		return Change{}, &LimitError{Limit: LimitLines, Max: re.maxLines}
+	}
+	if re.maxOutputBytes > 0 && len(d.out)+outDelta > re.maxOutputBytes {
+		return Change{}, &LimitError{Limit: LimitOutputBytes, Max: re.maxOutputBytes}
+	}
+
+	output := make([]byte, 0, len(d.out)+outDelta)
+	output = append(append(append(output, d.out[:change.Start]...), change.Output...), d.out[change.End:]...)
+	d.src, d.out, d.rendered = src, output, rendered
+	return change, nil
+}
+
+// endsLine reports whether src ends with a line ending
+func endsLine(src []byte) bool {
+	return len(src) > 0 && src[len(src)-1] == '\n'
+}
+
+// renderUnit renders the unit starting at start in src, which is on line, to
+// out and returns it. Lines are scanned as Render scans them, and the unit
+// ends after the first block after which no code block, text block joined with
+// a trailing \, condition or region is open, so rendering the units one after
+// another renders what Render does. The output of a unit that fails is the
+// output written before its error.
+func (re *Renderer) renderUnit(src []byte, start, line int, out io.Writer) (unit, error) {
+	st := getRenderState()
+	defer putRenderState(st)
+	st.firstLine = line
+	u := unit{start: start, end: start, line: line}
+	blocks := re.newBlockScanner(bytes.NewReader(src[start:]), st)
+	blocks.scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		u.end += advance
+		return advance, token, err
+	})
+	var renderErr error
+	for {
+		b, ok, err := blocks.next()
+		if err != nil && renderErr == nil {
+			renderErr = err
+		}
+		if err != nil || !ok {
+			break
+		}
+		if renderErr == nil {
+			renderErr = re.renderBlock(st, b, out)
+		}
+		if blocks.closed() {
+			break
+		}
+	}
+	u.lines = blocks.lineCount - (line - 1)
+	if u.end == start {
+		// A line too long to scan is a unit of its own
+		u.end, u.lines = len(src), 1
+		if i := bytes.IndexByte(src[start:], '\n'); i != -1 {
+			u.end = start + i + 1
+		}
+	}
+	// A unit scanned to the end of the input may continue after it
+	u.open = blocks.eof
+	return u, renderErr
+}
+
+// closed reports whether nothing is left open after the last block returned
+// by s
+func (s *blockScanner) closed() bool {
+	return s.codeBlockStartLine == -1 && s.paragraphStartLine == -1 && s.skippedFence.n == 0 &&
+		len(s.conditions) == 0 && len(s.regions) == 0
+}
@@ -0,0 +1,273 @@
+package rnzml
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var documenttests = []struct {
+	in    string
+	start int
+	end   int
+	text  string
+	out   string
+}{
+	{"a\nb\nc", 2, 3, "*d*", "<p>a\n</p>\n<p><strong>d</strong>\n</p>\n<p>c\n</p>\n"},
+	{"a\nb", 3, 3, "c", "<p>a\n</p>\n<p>bc\n</p>\n"},
+	{"a\nb\n", 4, 4, "c", "<p>a\n</p>\n<p>b\n</p>\n<p>c\n</p>\n"},
+	{"a\nb\nc", 1, 1, "\n```", "<p>a\n</p>\n<pre><code>b\nc\n"},
+	{"a\nb\nc", 3, 3, "\n```", "<p>a\n</p>\n<p>b\n</p>\n<pre><code>c\n"},
+	{"```\na\n```\nb", 0, 4, "", "<p>a\n</p>\n<pre><code>b\n"},
+	{"```\na\n```\nb", 6, 10, "", "<pre><code>a\nb\n"},
+	{"", 0, 0, "a", "<p>a\n</p>\n"},
+	{"a\nb", 0, 3, "", ""},
+}
+
+func TestDocument(t *testing.T) {
+	for _, tt := range documenttests {
+		t.Run(fmt.Sprintf("Should replace %d to %d of %q with %q", tt.start, tt.end, tt.in, tt.text), func(t *testing.T) {
+			d, err := r.NewDocument([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := string(d.Output())
+			change, err := d.Edit(tt.start, tt.end, []byte(tt.text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != string(d.Output()) {
+				t.Errorf("expected: %q got: %q", tt.out, d.Output())
+			}
+			if got := before[:change.Start] + string(change.Output) + before[change.End:]; got != tt.out {
+				t.Errorf("expected change to give: %q got: %q", tt.out, got)
+			}
+		})
+	}
+	t.Run("Should report the error Render returns", func(t *testing.T) {
+		d, err := r.NewDocument([]byte("a\n*b\n```"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(d.Err()) != "line 2: unclosed bold text (*) at position: 0" {
+			t.Errorf("expected a bold error got: '%v'", d.Err())
+		}
+		if _, err := d.Edit(0, 0, []byte("x\n")); err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(d.Err()) != "line 3: unclosed bold text (*) at position: 0" {
+			t.Errorf("expected the error to move to line 3 got: '%v'", d.Err())
+		}
+		if _, err := d.Edit(5, 5, []byte("*")); err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(d.Err()) != "unclosed code block (```) on line: 4" {
+			t.Errorf("expected a code block error got: '%v'", d.Err())
+		}
+	})
+	t.Run("Should reject edits out of bounds", func(t *testing.T) {
+		d, _ := r.NewDocument([]byte("abc"))
+		if _, err := d.Edit(2, 4, nil); err == nil {
+			t.Error("expected an error")
+		}
+	})
+	t.Run("Should keep the document within limits", func(t *testing.T) {
+		d, err := NewRenderer(WithMaxLines(2)).NewDocument([]byte("a\nb"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := d.Edit(3, 3, []byte("\nc")); err == nil {
+			t.Error("expected a *LimitError")
+		}
+		if string(d.Source()) != "a\nb" {
+			t.Errorf("expected the document to be unchanged got: %q", d.Source())
+		}
+	})
+}
+
+func TestMerge(t *testing.T) {
+	t.Run("Should render the documents one after another", func(t *testing.T) {
+		var docs []*Document
+		for _, in := range []string{"a", "", "```\nb\n```\n", "*c*"} {
+			d, err := r.NewDocument([]byte(in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			docs = append(docs, d)
+		}
+		merged, err := Merge(docs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "<p>a\n</p>\n<pre><code>b\n</code></pre>\n<p><strong>c</strong>\n</p>\n"
+		if expected != string(merged.Output()) {
+			t.Errorf("expected: %q got: %q", expected, merged.Output())
+		}
+		if expected := "a\n```\nb\n```\n*c*"; expected != string(merged.Source()) {
+			t.Errorf("expected source: %q got: %q", expected, merged.Source())
+		}
+	})
+	t.Run("Should keep the limits of the first document", func(t *testing.T) {
+		d, _ := NewRenderer(WithMaxLines(2)).NewDocument([]byte("a\nb"))
+		if _, err := Merge(d, d); err == nil {
+			t.Error("expected a *LimitError")
+		}
+	})
+	t.Run("Should return an error without documents", func(t *testing.T) {
+		if _, err := Merge(); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+// documentAlphabet is the text random edits are made from
+var documentAlphabet = []string{"a", " ", "*", "`", "[", "]", "\\", "\n", "\r\n", "```\n", "[https://res.nz x]", "!if profile=a\n", "!else\n", "!endif\n", "!region a\n", "!endregion\n", "~~~\n", "````\n", "``` 2\n"}
+
+func TestDocumentRandomEdits(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithCanonicalOutput()},
+		{WithTrailingBackslash(TrailingBackslashJoin), WithBlankWhitespaceLines()},
+		{WithProfiles("a"), WithTrailingBackslash(TrailingBackslashJoin)},
+		{WithRegions()},
+		{WithRegion("a"), WithProfiles("a")},
+		{WithSourceLines(), WithTrailingBackslash(TrailingBackslashJoin), WithProfiles("a")},
+		{WithPreformattedBlocks(), WithProfiles("a"), WithRegions()},
+		{WithLineNumbers(), WithPreformattedBlocks(), WithTrailingBackslash(TrailingBackslashJoin)},
+	} {
+		re := NewRenderer(opts...)
+		rnd := rand.New(rand.NewSource(1))
+		d, err := re.NewDocument([]byte(benchmarkDocument[:2000]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2000; i++ {
+			src := d.Source()
+			start := rnd.Intn(len(src) + 1)
+			end := start + rnd.Intn(4)
+			if end > len(src) {
+				end = len(src)
+			}
+			text := ""
+			for n := rnd.Intn(3); n > 0; n-- {
+				text += documentAlphabet[rnd.Intn(len(documentAlphabet))]
+			}
+			before := string(d.Output())
+			change, err := d.Edit(start, end, []byte(text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			full, _ := re.NewDocument(d.Source())
+			if !bytes.Equal(full.Output(), d.Output()) {
+				t.Fatalf("edit %d of %q at %d to %d: expected: %q got: %q", i, text, start, end, full.Output(), d.Output())
+			}
+			if got := before[:change.Start] + string(change.Output) + before[change.End:]; got != string(d.Output()) {
+				t.Fatalf("edit %d: expected change to give the output", i)
+			}
+			expected := &strings.Builder{}
+			expectedErr := re.Render(bytes.NewReader(d.Source()), expected)
+			if fmt.Sprint(expectedErr) != fmt.Sprint(d.Err()) {
+				t.Fatalf("edit %d: expected error: '%v' got: '%v'", i, expectedErr, d.Err())
+			}
+			if expectedErr == nil && expected.String() != string(d.Output()) {
+				t.Fatalf("edit %d: expected: %q got: %q", i, expected.String(), d.Output())
+			}
+		}
+	}
+}
+
+var documentappendtests = []struct {
+	in, text string
+	opts     []Option
+}{
+	{"```\na\n", "```\n", nil},
+	{"a\\\n", "b\n", []Option{WithTrailingBackslash(TrailingBackslashJoin)}},
+	{"!if profile=q\na\n", "!endif\n", []Option{WithProfiles("a")}},
+	{"!region a\na\n", "!endregion\n", []Option{WithRegion("a")}},
+}
+
+// documentEditAlphabet is documentAlphabet with more of the lines that open
+// and close blocks, so edits leave them open at the end of the input
+var documentEditAlphabet = append([]string{"```\n", "!region a\n", "!endregion\n", "!if profile=a\n", "!endif\n", "a\n", "\n"}, documentAlphabet...)
+
+func TestDocumentEditsMatchRender(t *testing.T) {
+	for _, tt := range documentappendtests {
+		t.Run("Should continue "+strconv.Quote(tt.in)+" with "+strconv.Quote(tt.text), func(t *testing.T) {
+			re := NewRenderer(tt.opts...)
+			d, err := re.NewDocument([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := d.Edit(len(tt.in), len(tt.in), []byte(tt.text)); err != nil {
+				t.Fatal(err)
+			}
+			expected, err := re.RenderToBytes([]byte(tt.in + tt.text))
+			if err != nil || d.Err() != nil {
+				t.Fatalf("expected no error got: '%v' '%v'", err, d.Err())
+			}
+			if string(expected) != string(d.Output()) {
+				t.Errorf("expected: %q got: %q", expected, d.Output())
+			}
+		})
+	}
+	for _, opts := range [][]Option{
+		nil,
+		{WithTrailingBackslash(TrailingBackslashJoin)},
+		{WithProfiles("a"), WithRegions()},
+		{WithRegion("a"), WithProfiles("a")},
+		{WithRegion("a"), WithPreformattedBlocks(), WithTrailingBackslash(TrailingBackslashJoin)},
+		{WithRegions(), WithTrailingBackslash(TrailingBackslashJoin), WithProfiles("a")},
+		{WithSourceLines(), WithLineNumbers(), WithProfiles("a")},
+	} {
+		re := NewRenderer(opts...)
+		rnd := rand.New(rand.NewSource(1))
+		for run := 0; run < 500; run++ {
+			d, err := re.NewDocument(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var edits []string
+			for i := 0; i < 12; i++ {
+				src := d.Source()
+				// Most edits add to the end, as typing does
+				start := len(src)
+				if rnd.Intn(3) == 0 {
+					start = rnd.Intn(len(src) + 1)
+				}
+				end := start + rnd.Intn(len(src)-start+1)
+				text := documentEditAlphabet[rnd.Intn(len(documentEditAlphabet))]
+				edits = append(edits, fmt.Sprintf("%d-%d %q", start, end, text))
+				if _, err := d.Edit(start, end, []byte(text)); err != nil {
+					t.Fatal(err)
+				}
+				expected := &strings.Builder{}
+				expectedErr := re.Render(bytes.NewReader(d.Source()), expected)
+				if fmt.Sprint(expectedErr) != fmt.Sprint(d.Err()) {
+					t.Fatalf("edits %v of %q: expected error: '%v' got: '%v'", edits, d.Source(), expectedErr, d.Err())
+				}
+				if expectedErr == nil && expected.String() != string(d.Output()) {
+					t.Fatalf("edits %v of %q: expected: %q got: %q", edits, d.Source(), expected.String(), d.Output())
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkDocumentEdit(b *testing.B) {
+	d, err := r.NewDocument([]byte(strings.Repeat(benchmarkDocument, 10)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := d.Edit(1000, 1000, []byte("a")); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := d.Edit(1000, 1001, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
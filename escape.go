@@ -0,0 +1,79 @@
+package rnzml
+
+import (
+	"strings"
+)
+
+// htmlEscapes are the replacements for bytes escaped in text and code, the
+// same as template.HTMLEscape
+var htmlEscapes = [...][]byte{
+	0:    []byte("\uFFFD"),
+	'"':  []byte("&#34;"),
+	'&':  []byte("&amp;"),
+	'\'': []byte("&#39;"),
+	'<':  []byte("&lt;"),
+	'>':  []byte("&gt;"),
+}
+
+// appendHTMLEscaped appends s to dst HTML escaped
+func appendHTMLEscaped(dst, s []byte) []byte {
+	written := 0
+	for i, c := range s {
+		if int(c) >= len(htmlEscapes) || htmlEscapes[c] == nil {
+			continue
+		}
+		dst = append(dst, s[written:i]...)
+		dst = append(dst, htmlEscapes[c]...)
+		written = i + 1
+	}
+	return append(dst, s[written:]...)
+}
+
+// Links used to be rendered with html/template, these functions escape link
+// URLs and labels the same way it escaped them in the href attribute and the
+// element content of <a href="{{.URL}}">{{.Label}}</a>.
+
+// failsafeURL replaces URLs with unsafe schemes, as html/template does
+const failsafeURL = "#ZgotmplZ"
+
+// isSafeURL reports whether rawURL has no scheme, or one of the schemes
+// html/template allows in a URL attribute
+func isSafeURL(rawURL string) bool {
+	i := strings.IndexByte(rawURL, ':')
+	if i == -1 {
+		return true
+	}
+	scheme := rawURL[:i]
+	if strings.IndexByte(scheme, '/') != -1 {
+		// The : is part of the path
+		return true
+	}
+	return strings.EqualFold(scheme, "http") || strings.EqualFold(scheme, "https") || strings.EqualFold(scheme, "mailto")
+}
+
+// templateEscapes are the replacements html/template uses when escaping both
+// element content and attribute values
+var templateEscapes = [...]string{
+	0:    "\uFFFD",
+	'"':  "&#34;",
+	'&':  "&amp;",
+	'\'': "&#39;",
+	'+':  "&#43;",
+	'<':  "&lt;",
+	'>':  "&gt;",
+}
+
+// appendTemplateEscaped appends s to dst escaped as html/template escapes
+// element content and attribute values
+func appendTemplateEscaped(dst, s []byte) []byte {
+	written := 0
+	for i, c := range s {
+		if int(c) >= len(templateEscapes) || templateEscapes[c] == "" {
+			continue
+		}
+		dst = append(dst, s[written:i]...)
+		dst = append(dst, templateEscapes[c]...)
+		written = i + 1
+	}
+	return append(dst, s[written:]...)
+}
@@ -0,0 +1,111 @@
+package rnzml
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+)
+
+var templateLinkTests = []string{
+	"https://res.nz",
+	"https://res.nz/a+b?q=1&r=2",
+	"http://res.nz/it's",
+	"mailto:a@res.nz",
+	"MAILTO:a@res.nz",
+	"HTTPS://Res.NZ/x",
+	"javascript:alert(1)",
+	"JavaScript:alert(1)",
+	"data:text/html,<b>",
+	"/path:with/colon",
+	"a/b:c",
+	"#anchor",
+	"//cdn.res.nz/a",
+	"https://res.nz/100%",
+	"https://münchen.de/café",
+}
+
+var templateLabelTests = []string{
+	"",
+	"label",
+	"a & b",
+	"1 + 1",
+	`"quoted" 'single'`,
+	"<b>html</b>",
+	"nul\x00byte",
+	"café 日本",
+	"&amp; already",
+}
+
+var templateLinkTemplate = template.Must(template.New("href").Parse(`<a href="{{.URL}}">{{.Label}}</a>`))
+
+func TestLinksMatchTemplate(t *testing.T) {
+	r := NewRenderer()
+	for _, u := range templateLinkTests {
+		for _, label := range templateLabelTests {
+			t.Run("Should match html/template for "+u+" "+label, func(t *testing.T) {
+				var expected bytes.Buffer
+				err := templateLinkTemplate.Execute(&expected, struct{ URL, Label string }{NormalizeURL(u), label})
+				if err != nil {
+					t.Fatal(err)
+				}
+				st := &renderState{link: []byte(u + " " + label)}
+				if label == "" {
+					st.link = []byte(u)
+					expected.Reset()
+					err = templateLinkTemplate.Execute(&expected, struct{ URL, Label string }{NormalizeURL(u), u})
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+				var got bytes.Buffer
+				href, rendered, err := r.link(st, 1, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := r.writeLink(st, href, rendered, &got); err != nil {
+					t.Fatal(err)
+				}
+				if expected.String() != got.String() {
+					t.Errorf("expected: '%s' got: '%s'", expected.String(), got.String())
+				}
+			})
+		}
+	}
+}
+
+var htmlescapetests = []string{
+	"",
+	"plain text",
+	`<script>alert("x" + 'y')</script>`,
+	"a & b &amp;",
+	"nul\x00byte\x00",
+	"café 日本",
+	"\xff invalid",
+}
+
+func TestAppendHTMLEscaped(t *testing.T) {
+	for _, tt := range htmlescapetests {
+		t.Run("Should match template.HTMLEscape for "+tt, func(t *testing.T) {
+			expected := template.HTMLEscapeString(tt)
+			got := string(appendHTMLEscaped(nil, []byte(tt)))
+			if expected != got {
+				t.Errorf("expected: '%s' got: '%s'", expected, got)
+			}
+		})
+	}
+	t.Run("Should append to dst", func(t *testing.T) {
+		got := string(appendHTMLEscaped([]byte("<p>"), []byte("<")))
+		if got != "<p>&lt;" {
+			t.Errorf("expected: '<p>&lt;' got: '%s'", got)
+		}
+	})
+}
+
+func BenchmarkAppendHTMLEscaped(b *testing.B) {
+	line := []byte(`Some <b>"quoted"</b> text & more text that is mostly plain`)
+	var dst []byte
+	b.SetBytes(int64(len(line)))
+	for i := 0; i < b.N; i++ {
+		dst = appendHTMLEscaped(dst[:0], line)
+	}
+}
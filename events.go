@@ -0,0 +1,271 @@
+package rnzml
+
+import (
+	"bytes"
+	"io"
+)
+
+// Handler is called by Parse with the structure of a document as it is read.
+// Byte slices passed to a Handler are only valid until the method returns.
+type Handler interface {
+	// StartParagraph and EndParagraph surround a text block starting on line
+	StartParagraph(line int)
+	EndParagraph()
+	// Text is text in a text block, in bold text or in code text, without
+	// escapes. A run of text may be passed in several calls.
+	Text(text []byte)
+	StartBold()
+	EndBold()
+	StartCode()
+	EndCode()
+	// Link is a link with its URL rewritten and normalized as it is rendered
+	Link(url string, label []byte)
+	// StartCodeBlock and EndCodeBlock surround a code block starting on line
+	StartCodeBlock(line int)
+	EndCodeBlock()
+	// CodeBlockLine is a line in a code block, tabs are expanded when
+	// WithTabWidth is used
+	CodeBlockLine(text []byte)
+	// Shortcode is a line calling the shortcode name registered with
+	// WithShortcode
+	Shortcode(line int, name string, args []string)
+}
+
+// Parse reads in and calls h with the structure of the document, instead of
+// rendering it, for consumers that want to convert documents to other formats
+// or inspect them. Parse returns the same errors and reports the same warnings
+// as Render, and stops at the first error.
+func (re *Renderer) Parse(in io.Reader, h Handler) error {
+	st := getRenderState()
+	defer putRenderState(st)
+	events := &eventInline{re: re, st: st, h: h}
+	return re.scanBlocks(in, st, func(b block) error {
+		startEvent(h, b)
+		if b.kind == blockShortcode {
+			if _, _, err := re.shortcode(b); err != nil {
+				return err
+			}
+		}
+		if b.kind == blockText {
+			line := b.content
+			if re.normalize != nil {
+				line = []byte(re.normalize(string(line)))
+			}
+			events.line = b.line
+			if err := re.scanInline(st, line, b.line, events); err != nil {
+				return lineError(b.line, err)
+			}
+		}
+		re.endEvent(h, b)
+		return nil
+	})
+}
+
+// startEvent calls h with the start of b
+func startEvent(h Handler, b block) {
+	switch b.kind {
+	case blockText:
+		h.StartParagraph(b.line)
+	case blockCodeStart:
+		h.StartCodeBlock(b.line)
+	}
+}
+
+// endEvent calls h with the end of b, the line of a code block or a shortcode
+func (re *Renderer) endEvent(h Handler, b block) {
+	switch b.kind {
+	case blockShortcode:
+		fields := shortcodeFields(b)
+		h.Shortcode(b.line, fields[0], fields[1:])
+	case blockText:
+		h.EndParagraph()
+	case blockCodeEnd:
+		h.EndCodeBlock()
+	case blockCodeLine:
+		h.CodeBlockLine(re.codeLine(b.content))
+	}
+}
+
+// inlineEvent calls h with an inline token other than a link
+func inlineEvent(h Handler, kind inlineKind, text []byte) {
+	switch kind {
+	case inlineText, inlineRune, inlineEscaped:
+		h.Text(text)
+	case inlineBoldStart:
+		h.StartBold()
+	case inlineBoldEnd:
+		h.EndBold()
+	case inlineCodeStart:
+		h.StartCode()
+	case inlineCodeEnd:
+		h.EndCode()
+	}
+}
+
+// eventInline passes inline tokens to a Handler
+type eventInline struct {
+	re   *Renderer
+	st   *renderState
+	h    Handler
+	line int
+}
+
+func (e *eventInline) inline(kind inlineKind, position int, text []byte) error {
+	if kind != inlineLink {
+		inlineEvent(e.h, kind, text)
+		return nil
+	}
+	href, label, err := e.re.link(e.st, e.line, position)
+	if err != nil {
+		return err
+	}
+	e.h.Link(href, label)
+	return nil
+}
+
+// MultiHandler returns a Handler that calls each of handlers in order, so one
+// call to Parse or RenderWith produces several outputs of a document
+func MultiHandler(handlers ...Handler) Handler {
+	return multiHandler(handlers)
+}
+
+type multiHandler []Handler
+
+func (m multiHandler) StartParagraph(line int) {
+	for _, h := range m {
+		h.StartParagraph(line)
+	}
+}
+
+func (m multiHandler) EndParagraph() {
+	for _, h := range m {
+		h.EndParagraph()
+	}
+}
+
+func (m multiHandler) Text(text []byte) {
+	for _, h := range m {
+		h.Text(text)
+	}
+}
+
+func (m multiHandler) StartBold() {
+	for _, h := range m {
+		h.StartBold()
+	}
+}
+
+func (m multiHandler) EndBold() {
+	for _, h := range m {
+		h.EndBold()
+	}
+}
+
+func (m multiHandler) StartCode() {
+	for _, h := range m {
+		h.StartCode()
+	}
+}
+
+func (m multiHandler) EndCode() {
+	for _, h := range m {
+		h.EndCode()
+	}
+}
+
+func (m multiHandler) Link(url string, label []byte) {
+	for _, h := range m {
+		h.Link(url, label)
+	}
+}
+
+func (m multiHandler) StartCodeBlock(line int) {
+	for _, h := range m {
+		h.StartCodeBlock(line)
+	}
+}
+
+func (m multiHandler) EndCodeBlock() {
+	for _, h := range m {
+		h.EndCodeBlock()
+	}
+}
+
+func (m multiHandler) CodeBlockLine(text []byte) {
+	for _, h := range m {
+		h.CodeBlockLine(text)
+	}
+}
+
+func (m multiHandler) Shortcode(line int, name string, args []string) {
+	for _, h := range m {
+		h.Shortcode(line, name, args)
+	}
+}
+
+// TextHandler is a Handler collecting the text of a document without markup,
+// for search indexes and plain text email. Each text block is a line with
+// links written as their label, code blocks are written line by line and
+// blocks are separated by a blank line.
+type TextHandler struct {
+	bytes.Buffer
+}
+
+func (t *TextHandler) StartParagraph(int) { t.separate() }
+func (t *TextHandler) EndParagraph()      { t.WriteByte('\n') }
+func (t *TextHandler) Text(text []byte)   { t.Write(text) }
+func (t *TextHandler) StartBold()         {}
+func (t *TextHandler) EndBold()           {}
+func (t *TextHandler) StartCode()         {}
+func (t *TextHandler) EndCode()           {}
+
+func (t *TextHandler) Link(url string, label []byte) { t.Write(label) }
+
+func (t *TextHandler) StartCodeBlock(int) { t.separate() }
+func (t *TextHandler) EndCodeBlock()      {}
+
+func (t *TextHandler) CodeBlockLine(text []byte) {
+	t.Write(text)
+	t.WriteByte('\n')
+}
+
+func (t *TextHandler) Shortcode(int, string, []string) {}
+
+// separate writes the blank line before a block that is not the first
+func (t *TextHandler) separate() {
+	if t.Len() > 0 {
+		t.WriteByte('\n')
+	}
+}
+
+// Link is a link of a document
+type Link struct {
+	// URL is rewritten and normalized as it is rendered
+	URL   string
+	Label string
+	// Line is the line of the text block the link is in
+	Line int
+}
+
+// LinkHandler is a Handler collecting the links of a document
+type LinkHandler struct {
+	Links []Link
+	line  int
+}
+
+func (l *LinkHandler) StartParagraph(line int) { l.line = line }
+func (l *LinkHandler) EndParagraph()           {}
+func (l *LinkHandler) Text([]byte)             {}
+func (l *LinkHandler) StartBold()              {}
+func (l *LinkHandler) EndBold()                {}
+func (l *LinkHandler) StartCode()              {}
+func (l *LinkHandler) EndCode()                {}
+func (l *LinkHandler) StartCodeBlock(int)      {}
+func (l *LinkHandler) EndCodeBlock()           {}
+func (l *LinkHandler) CodeBlockLine([]byte)    {}
+
+func (l *LinkHandler) Shortcode(int, string, []string) {}
+
+func (l *LinkHandler) Link(url string, label []byte) {
+	l.Links = append(l.Links, Link{URL: url, Label: string(label), Line: l.line})
+}
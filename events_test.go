@@ -0,0 +1,135 @@
+package rnzml
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// traceHandler records the calls made to it
+type traceHandler struct {
+	strings.Builder
+}
+
+func (h *traceHandler) StartParagraph(line int)   { fmt.Fprintf(h, "<p %d>", line) }
+func (h *traceHandler) EndParagraph()             { h.WriteString("</p>") }
+func (h *traceHandler) Text(text []byte)          { fmt.Fprintf(h, "%q", text) }
+func (h *traceHandler) StartBold()                { h.WriteString("<b>") }
+func (h *traceHandler) EndBold()                  { h.WriteString("</b>") }
+func (h *traceHandler) StartCode()                { h.WriteString("<c>") }
+func (h *traceHandler) EndCode()                  { h.WriteString("</c>") }
+func (h *traceHandler) Link(url string, l []byte) { fmt.Fprintf(h, "<a %s %q>", url, l) }
+func (h *traceHandler) StartCodeBlock(line int)   { fmt.Fprintf(h, "<pre %d>", line) }
+func (h *traceHandler) EndCodeBlock()             { h.WriteString("</pre>") }
+func (h *traceHandler) CodeBlockLine(text []byte) { fmt.Fprintf(h, "%q", text) }
+
+func (h *traceHandler) Shortcode(line int, name string, args []string) {
+	fmt.Fprintf(h, "<%s %d %q>", name, line, args)
+}
+
+var eventtests = []struct {
+	in    string
+	opts  []Option
+	trace string
+}{
+	{"", nil, ""},
+	{"a *b* `c`", nil, `<p 1>"a "<b>"b"</b>" "<c>"c"</c></p>`},
+	{"\\*a<", nil, `<p 1>"*""a""<"</p>`},
+	{"a\n\nb", nil, `<p 1>"a"</p><p 3>"b"</p>`},
+	{"[HTTPS://Res.NZ/a b c] d", nil, `<p 1><a https://res.nz/a "b c">" d"</p>`},
+	{"```\n\tx\n```", []Option{WithTabWidth(2)}, `<pre 1>"  x"</pre>`},
+	{"a\\\nb", []Option{WithTrailingBackslash(TrailingBackslashJoin)}, `<p 1>"a\nb"</p>`},
+	{"a\n{{< youtube x y >}}", []Option{WithShortcode("youtube", youtube)}, `<p 1>"a"</p><youtube 2 ["x" "y"]>`},
+}
+
+func TestParse(t *testing.T) {
+	for _, tt := range eventtests {
+		t.Run(tt.in, func(t *testing.T) {
+			h := &traceHandler{}
+			if err := NewRenderer(tt.opts...).Parse(strings.NewReader(tt.in), h); err != nil {
+				t.Fatal(err)
+			}
+			if tt.trace != h.String() {
+				t.Errorf("expected: %s got: %s", tt.trace, h.String())
+			}
+		})
+	}
+	t.Run("Should return the errors Render returns", func(t *testing.T) {
+		for _, in := range []string{"a\n*b", "```\na", "[ a]", "a\\"} {
+			renderErr := r.Render(strings.NewReader(in), &strings.Builder{})
+			parseErr := r.Parse(strings.NewReader(in), &traceHandler{})
+			if fmt.Sprint(renderErr) != fmt.Sprint(parseErr) {
+				t.Errorf("expected: '%v' got: '%v'", renderErr, parseErr)
+			}
+		}
+	})
+	t.Run("Should return errors for unknown shortcodes", func(t *testing.T) {
+		r := NewRenderer(WithShortcode("youtube", youtube))
+		err := r.Parse(strings.NewReader("a\n{{< nope x >}}\nb"), &traceHandler{})
+		if expected := "line 2: unknown shortcode"; fmt.Sprint(err) != expected {
+			t.Errorf("expected: '%s' got: '%v'", expected, err)
+		}
+	})
+	t.Run("Should report warnings", func(t *testing.T) {
+		var warnings []Warning
+		r := NewRenderer(WithWarnings(func(w Warning) { warnings = append(warnings, w) }))
+		if err := r.Parse(strings.NewReader("** [ftp://x y]"), &traceHandler{}); err != nil {
+			t.Fatal(err)
+		}
+		if len(warnings) != 2 {
+			t.Errorf("expected: 2 warnings got: %v", warnings)
+		}
+	})
+}
+
+func TestRenderWith(t *testing.T) {
+	in := "a *b* [/c c d]\n\n```\n\tx\n```\n[HTTPS://Res.NZ e]"
+	opts := []Option{WithTabWidth(2), WithParallelism(4)}
+	t.Run("Should render HTML and call the handler in one pass", func(t *testing.T) {
+		var html strings.Builder
+		trace, text, links := &traceHandler{}, &TextHandler{}, &LinkHandler{}
+		if err := NewRenderer(opts...).RenderWith(strings.NewReader(in), &html, MultiHandler(trace, text, links)); err != nil {
+			t.Fatal(err)
+		}
+		expected, err := NewRenderer(opts...).RenderToBytes([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if html.String() != string(expected) {
+			t.Errorf("expected: %q got: %q", expected, html.String())
+		}
+		parsed := &traceHandler{}
+		if err := NewRenderer(opts...).Parse(strings.NewReader(in), parsed); err != nil {
+			t.Fatal(err)
+		}
+		if trace.String() != parsed.String() {
+			t.Errorf("expected: %s got: %s", parsed.String(), trace.String())
+		}
+		if expected := "a b c d\n\n  x\n\ne\n"; text.String() != expected {
+			t.Errorf("expected: %q got: %q", expected, text.String())
+		}
+		expectedLinks := []Link{{URL: "/c", Label: "c d", Line: 1}, {URL: "https://res.nz", Label: "e", Line: 6}}
+		if fmt.Sprint(links.Links) != fmt.Sprint(expectedLinks) {
+			t.Errorf("expected: %v got: %v", expectedLinks, links.Links)
+		}
+	})
+	t.Run("Should call the handler up to the error", func(t *testing.T) {
+		trace := &traceHandler{}
+		err := NewRenderer().RenderWith(strings.NewReader("a\n*b"), &strings.Builder{}, trace)
+		if fmt.Sprint(err) != "line 2: unclosed bold text (*) at position: 0" {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if expected := `<p 1>"a"</p><p 2><b>"b"`; trace.String() != expected {
+			t.Errorf("expected: %s got: %s", expected, trace.String())
+		}
+	})
+	t.Run("Should render as Render does without a handler", func(t *testing.T) {
+		var html strings.Builder
+		if err := NewRenderer(opts...).RenderWith(strings.NewReader(in), &html, nil); err != nil {
+			t.Fatal(err)
+		}
+		if expected, _ := NewRenderer(opts...).RenderToBytes([]byte(in)); html.String() != string(expected) {
+			t.Errorf("expected: %q got: %q", expected, html.String())
+		}
+	})
+}
@@ -0,0 +1,213 @@
+package rnzml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// moreMarker is a line that ends the excerpt of a document
+var moreMarker = []byte("<!--more-->")
+
+// errExcerptEnd stops scanning once an excerpt is complete
+var errExcerptEnd = errors.New("end of excerpt")
+
+// Summary is an excerpt of a document rendered as HTML and as plain text
+type Summary struct {
+	HTML string
+	// Text is the text of the excerpt without markup, code blocks are
+	// included as written
+	Text string
+	// Truncated is true when the excerpt ends at a marker or does not include
+	// the whole document
+	Truncated bool
+}
+
+// Excerpt returns the excerpt of in rendered with a Renderer configured by
+// opts, for index pages and previews. A line containing only <!--more--> ends
+// the excerpt and everything before it is the excerpt, rendered as Render
+// renders it. Without a marker the excerpt is the first text block, cut after
+// words words when words is greater than 0, with any bold or code text closed.
+// The output before a marker is held until the marker or the end of in is
+// read, so the memory used by Excerpt grows with the input before a marker.
+func Excerpt(in io.Reader, words int, opts ...Option) (Summary, error) {
+	re := NewRenderer(opts...)
+	// The first text block is rendered twice, warnings are only reported once
+	quiet := *re
+	quiet.warnings = nil
+	if words <= 0 {
+		words = -1
+	}
+
+	st := getRenderState()
+	defer putRenderState(st)
+	marked := &excerpt{words: -1}
+	first := &excerpt{words: words}
+	found, seen, rest := false, false, false
+	err := re.scanBlocks(in, st, func(b block) error {
+		switch {
+		case b.kind == blockText && bytes.Equal(bytes.TrimSpace(b.content), moreMarker):
+			found = true
+			return errExcerptEnd
+		case b.kind == blockText && !seen:
+			seen = true
+			if err := quiet.renderExcerpt(st, b, first); err != nil {
+				return err
+			}
+		case b.kind != blockBlank:
+			rest = true
+		}
+		return re.renderExcerpt(st, b, marked)
+	})
+	if err != nil && err != errExcerptEnd {
+		return Summary{}, err
+	}
+	if found {
+		return marked.summary(true), nil
+	}
+	return first.summary(first.truncated || rest), nil
+}
+
+// excerpt collects the output of an excerpt, it is the inlineHandler of the
+// text blocks it renders
+type excerpt struct {
+	out  bytes.Buffer
+	text bytes.Buffer
+	html htmlInline
+	// words is the number of words left, or -1 when there is no limit
+	words  int
+	inWord bool
+	// space is whitespace at the end of the text written so far
+	space     []byte
+	bold      bool
+	code      bool
+	truncated bool
+}
+
+func (e *excerpt) summary(truncated bool) Summary {
+	return Summary{HTML: e.out.String(), Text: strings.TrimSpace(e.text.String()), Truncated: truncated}
+}
+
+// renderExcerpt renders b to e
+func (re *Renderer) renderExcerpt(st *renderState, b block, e *excerpt) error {
+	switch b.kind {
+	case blockText:
+	case blockCodeLine:
+		e.text.Write(re.codeLine(b.content))
+		e.text.WriteByte('\n')
+		return re.renderBlock(st, b, &e.out)
+	case blockBlank:
+		e.text.WriteByte('\n')
+		return re.renderBlock(st, b, &e.out)
+	default:
+		return re.renderBlock(st, b, &e.out)
+	}
+
+	line := b.content
+	if re.normalize != nil {
+		line = []byte(re.normalize(string(line)))
+	}
+	e.out.Write(re.appendBlockStart(nil, re.textBlockStart, b.line))
+	e.html = htmlInline{re: re, st: st, out: &e.out, line: b.line}
+	e.space = e.space[:0]
+	err := re.scanInline(st, line, b.line, e)
+	if err == nil {
+		err = e.writeSpace()
+	} else if err == errExcerptEnd {
+		// Close the text cut off by the word limit
+		err = nil
+		if e.code {
+			e.out.Write(re.codeTextEnd)
+		}
+		if e.bold {
+			e.out.Write(re.boldTextEnd)
+		}
+	}
+	if err != nil {
+		return lineError(b.line, err)
+	}
+	e.out.Write(re.textBlockEnd)
+	e.text.WriteByte('\n')
+	return nil
+}
+
+func (e *excerpt) inline(kind inlineKind, position int, text []byte) error {
+	switch kind {
+	case inlineText, inlineRune, inlineEscaped:
+		i := e.cut(text)
+		if i != -1 {
+			text = text[:i]
+		}
+		// Hold back trailing whitespace so text cut after it does not end in it
+		trimmed := bytes.TrimRightFunc(text, unicode.IsSpace)
+		if len(trimmed) > 0 {
+			if err := e.writeSpace(); err != nil {
+				return err
+			}
+			e.text.Write(trimmed)
+			if err := e.html.inline(kind, position, trimmed); err != nil {
+				return err
+			}
+		}
+		if i != -1 {
+			e.truncated = true
+			return errExcerptEnd
+		}
+		e.space = append(e.space, text[len(trimmed):]...)
+		return nil
+	case inlineLink:
+		// Links are not cut, they are left out when they start past the limit
+		_, label := splitLink(text)
+		if e.cut(label) == 0 {
+			e.truncated = true
+			return errExcerptEnd
+		}
+		if err := e.writeSpace(); err != nil {
+			return err
+		}
+		e.text.Write(label)
+	case inlineBoldStart, inlineBoldEnd:
+		e.bold = kind == inlineBoldStart
+	case inlineCodeStart, inlineCodeEnd:
+		e.code = kind == inlineCodeStart
+	}
+	if err := e.writeSpace(); err != nil {
+		return err
+	}
+	return e.html.inline(kind, position, text)
+}
+
+// writeSpace writes the whitespace held back from the last text
+func (e *excerpt) writeSpace() error {
+	if len(e.space) == 0 {
+		return nil
+	}
+	e.text.Write(e.space)
+	err := e.html.inline(inlineText, 0, e.space)
+	e.space = e.space[:0]
+	return err
+}
+
+// cut counts the words in text and returns the offset of the first word past
+// the limit, or -1 if text is within the limit
+func (e *excerpt) cut(text []byte) int {
+	if e.words < 0 {
+		return -1
+	}
+	for i, size := 0, 0; i < len(text); i += size {
+		var r rune
+		r, size = utf8.DecodeRune(text[i:])
+		space := unicode.IsSpace(r)
+		if !space && !e.inWord {
+			if e.words == 0 {
+				return i
+			}
+			e.words--
+		}
+		e.inWord = !space
+	}
+	return -1
+}
@@ -0,0 +1,74 @@
+package rnzml
+
+import (
+	"strings"
+	"testing"
+)
+
+var excerpttests = []struct {
+	name    string
+	in      string
+	words   int
+	summary Summary
+}{
+	{"empty", "", 0, Summary{}},
+	{"first text block", "\n```\nx\n```\na *b*\n\nc", 0, Summary{HTML: "<p>a <strong>b</strong></p>\n", Text: "a b", Truncated: true}},
+	{"whole document", "a [/x b]\n", 0, Summary{HTML: "<p>a <a href=\"/x\">b</a></p>\n", Text: "a b"}},
+	{"word limit", "one two  three", 2, Summary{HTML: "<p>one two</p>\n", Text: "one two", Truncated: true}},
+	{"word limit in bold", "one *two three* four", 2, Summary{HTML: "<p>one <strong>two</strong></p>\n", Text: "one two", Truncated: true}},
+	{"word limit in code", "*one `two three`*", 2, Summary{HTML: "<p><strong>one <code>two</code></strong></p>\n", Text: "one two", Truncated: true}},
+	{"word limit at a link", "one [/x two three]", 1, Summary{HTML: "<p>one</p>\n", Text: "one", Truncated: true}},
+	{"word limit in a link", "one [/x two three] four", 2, Summary{HTML: "<p>one <a href=\"/x\">two three</a></p>\n", Text: "one two three", Truncated: true}},
+	{"word limit not reached", "one two", 2, Summary{HTML: "<p>one two</p>\n", Text: "one two"}},
+	{"escapes", "a\\*<b", 0, Summary{HTML: "<p>a*&lt;b</p>\n", Text: "a*<b"}},
+	{"marker", "a\n\nb\n```\nc\n```\n<!--more-->\nd", 1, Summary{
+		HTML:      "<p>a</p>\n<p>b</p>\n<pre><code>c\n</code></pre>\n",
+		Text:      "a\n\nb\nc",
+		Truncated: true,
+	}},
+}
+
+func TestExcerpt(t *testing.T) {
+	for _, tt := range excerpttests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := Excerpt(strings.NewReader(tt.in), tt.words, WithCanonicalOutput())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.summary != summary {
+				t.Errorf("expected: %+v got: %+v", tt.summary, summary)
+			}
+		})
+	}
+	t.Run("Should render the input before a marker as Render renders it", func(t *testing.T) {
+		in := "a *b*\n\n```\nx\n```\n\n"
+		summary, err := Excerpt(strings.NewReader(in+"<!--more-->\n*unclosed"), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := &strings.Builder{}
+		if err := r.Render(strings.NewReader(in), out); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != summary.HTML {
+			t.Errorf("expected: %q got: %q", out.String(), summary.HTML)
+		}
+	})
+	t.Run("Should return the errors Render returns", func(t *testing.T) {
+		_, err := Excerpt(strings.NewReader("a\n*b"), 1)
+		expected := "line 2: unclosed bold text (*) at position: 0"
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected: '%s' got: '%v'", expected, err)
+		}
+	})
+	t.Run("Should report warnings once", func(t *testing.T) {
+		count := 0
+		_, err := Excerpt(strings.NewReader("a **"), 0, WithWarnings(func(Warning) { count++ }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("expected 1 warning got: %d", count)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package rnzml
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// fence is the line starting and ending a code block, a run of n of char, or
+// the zero fence outside of a block. start is the number of the first line of
+// the block given after the fence with WithLineNumbers, or 0.
+type fence struct {
+	char  byte
+	n     int
+	start int
+}
+
+// backtickFence returns the fence of line when it is three or more backticks,
+// or the zero fence
+func backtickFence(line []byte) fence {
+	if len(line) < len(codeFence) || !isRun(line, '`') {
+		return fence{}
+	}
+	return fence{char: '`', n: len(line)}
+}
+
+// openingFence returns the fence line starts a block with, or the zero fence
+// when it is not a fence. A fence is three or more backticks, or ~~~ with
+// WithPreformattedBlocks, followed by a number with WithLineNumbers.
+func (re *Renderer) openingFence(line []byte) fence {
+	start := 0
+	if re.lineNumbers {
+		line, start = fenceNumber(line)
+	}
+	f := backtickFence(line)
+	if re.preformatted && bytes.Equal(line, preFence) {
+		f = fence{char: '~', n: len(preFence)}
+	}
+	if f.n > 0 {
+		f.start = start
+	}
+	return f
+}
+
+// closedBy reports whether line ends the block started by f, which it does
+// when it is the same fence
+func (f fence) closedBy(line []byte) bool {
+	return f.n > 0 && len(line) == f.n && isRun(line, f.char)
+}
+
+// pre reports whether f starts a preformatted block
+func (f fence) pre() bool {
+	return f.char == '~'
+}
+
+// text returns the Text of the fence tokens of the block started by f, which
+// is empty for ```
+func (f fence) text() []byte {
+	if f.char == '`' && f.n == len(codeFence) {
+		return nil
+	}
+	return bytes.Repeat([]byte{f.char}, f.n)
+}
+
+// startText returns the Text of the token starting the block started by f,
+// its text followed by the number of its first line when it is given
+func (f fence) startText() []byte {
+	text := f.text()
+	if f.start == 0 {
+		return text
+	}
+	if text == nil {
+		text = []byte(codeFence)
+	}
+	return strconv.AppendInt(append(text, ' '), int64(f.start), 10)
+}
+
+// isRun reports whether every byte of line is c
+func isRun(line []byte, c byte) bool {
+	for _, b := range line {
+		if b != c {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package rnzml
+
+import (
+	"strings"
+	"testing"
+)
+
+var fencetests = []struct {
+	in  string
+	out string
+}{
+	{"````\n```\na\n```\n````", "<pre><code>```\na\n```\n</code></pre>\n"},
+	{"`````\n````\n`````\nb", "<pre><code>````\n</code></pre>\n<p>b</p>\n"},
+	{"```\n````\n```", "<pre><code>````\n</code></pre>\n"},
+	{"``\na", "<p><code></code></p>\n<p>a</p>\n"},
+	{"```` a", "<p><code></code><code></code> a</p>\n"},
+}
+
+func TestFences(t *testing.T) {
+	for _, tt := range fencetests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := NewRenderer(WithCanonicalOutput()).RenderToBytes([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != string(out) {
+				t.Errorf("expected: %q got: %q", tt.out, out)
+			}
+		})
+	}
+	t.Run("Should only close a block with its own fence", func(t *testing.T) {
+		_, err := NewRenderer().RenderToBytes([]byte("````\na\n```"))
+		if expected := "unclosed code block (```) on line: 1"; err == nil || err.Error() != expected {
+			t.Errorf("expected: '%s' got: '%v'", expected, err)
+		}
+	})
+	t.Run("Should lex fences with their text", func(t *testing.T) {
+		tokens, err := lexAll("````\n```\n````\n```\n```")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "CodeBlockStart@1:0\"````\" CodeBlockLine@2:0\"```\" CodeBlockEnd@3:0\"````\" CodeBlockStart@4:0\"\" CodeBlockEnd@5:0\"\""
+		if tokens != expected {
+			t.Errorf("expected: %s got: %s", expected, tokens)
+		}
+	})
+	t.Run("Should format fences as they are written", func(t *testing.T) {
+		in := "````\n```\n````\n\n```\n````\n```\n"
+		var out strings.Builder
+		if err := Format(strings.NewReader(in), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != in {
+			t.Errorf("expected: %q got: %q", in, out.String())
+		}
+	})
+	t.Run("Should keep whitespace after text that would be a fence", func(t *testing.T) {
+		for _, in := range []string{"````\t\n", "``````  \n"} {
+			var out strings.Builder
+			if err := Format(strings.NewReader(in), &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != in {
+				t.Errorf("expected: %q got: %q", in, out.String())
+			}
+		}
+	})
+	t.Run("Should not write a line containing only the fence of its block", func(t *testing.T) {
+		w := NewTokenWriter(&strings.Builder{})
+		w.Write(Token{Kind: TokenCodeBlockStart, Text: []byte("````")}) //nolint: errcheck
+		err := w.Write(Token{Kind: TokenCodeBlockLine, Line: 2, Text: []byte("````")})
+		if expected := "line 2: code block line contains only ````"; err == nil || err.Error() != expected {
+			t.Errorf("expected: '%s' got: '%v'", expected, err)
+		}
+		err = NewTokenWriter(&strings.Builder{}).Write(Token{Kind: TokenCodeBlockStart, Line: 1, Text: []byte("``")})
+		if err == nil {
+			t.Error("expected error for an invalid fence")
+		}
+	})
+	t.Run("Should highlight long fences", func(t *testing.T) {
+		var out strings.Builder
+		if err := Highlight(strings.NewReader("````\n```\n````"), &out); err != nil {
+			t.Fatal(err)
+		}
+		expected := ansiFence + "````" + ansiReset + "\n" + ansiCode + "```" + ansiReset + "\n" + ansiFence + "````" + ansiReset
+		if out.String() != expected {
+			t.Errorf("expected: %q got: %q", expected, out.String())
+		}
+	})
+}
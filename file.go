@@ -0,0 +1,20 @@
+package rnzml
+
+import (
+	"io"
+	"os"
+)
+
+// fileExtension is the extension of rnzml files
+const fileExtension = ".rnzml"
+
+// RenderFile renders the file at path to out with a Renderer configured by
+// opts, reading it line by line as Render reads from any io.Reader
+func RenderFile(path string, out io.Writer, opts ...Option) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return NewRenderer(opts...).Render(f, out)
+}
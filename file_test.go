@@ -0,0 +1,46 @@
+package rnzml
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var renderfiletests = []struct {
+	name string
+	in   string
+}{
+	{"empty", ""},
+	{"small", "a *b*\n```\nc\n```"},
+	{"large", largeDocument},
+	{"large error", largeDocument + "```\n"},
+}
+
+func TestRenderFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, tt := range renderfiletests {
+		t.Run("Should render the "+tt.name+" file the same as Render", func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, []byte(tt.in), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			expected := &strings.Builder{}
+			expectedErr := NewRenderer(WithCanonicalOutput()).Render(strings.NewReader(tt.in), expected)
+			out := &strings.Builder{}
+			err := RenderFile(path, out, WithCanonicalOutput())
+			if (expectedErr == nil) != (err == nil) || err != nil && expectedErr.Error() != err.Error() {
+				t.Errorf("expected error: '%v' got: '%v'", expectedErr, err)
+			}
+			if expected.String() != out.String() {
+				t.Errorf("expected %d bytes of output got: %d", expected.Len(), out.Len())
+			}
+		})
+	}
+	t.Run("Should return an error for a missing file", func(t *testing.T) {
+		err := RenderFile(filepath.Join(dir, "missing"), &strings.Builder{})
+		if !os.IsNotExist(err) {
+			t.Errorf("expected a not exist error got: '%v'", err)
+		}
+	})
+}
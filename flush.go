@@ -0,0 +1,45 @@
+package rnzml
+
+import (
+	"io"
+)
+
+// WithFlush writes output to out after each text block and each code block is
+// rendered, so long documents can be streamed progressively, for example in an
+// HTTP response. After output is written fn is called, when fn is nil out is
+// flushed instead if it has a Flush method, as an http.ResponseWriter
+// implementing http.Flusher or a *gzip.Writer do. With WithParallelism output
+// is flushed after each chunk of blocks instead.
+func WithFlush(fn func() error) Option {
+	return func(re *Renderer) {
+		re.flushBlocks = true
+		re.flush = fn
+	}
+}
+
+// flusher returns the function flushing out after a block, or nil when output
+// is not flushed
+func (re *Renderer) flusher(out io.Writer) func() error {
+	if !re.flushBlocks {
+		return nil
+	}
+	if re.flush != nil {
+		return re.flush
+	}
+	switch f := out.(type) {
+	case interface{ Flush() error }:
+		return f.Flush
+	case interface{ Flush() }:
+		return func() error {
+			f.Flush()
+			return nil
+		}
+	}
+	return nil
+}
+
+// endsBlock reports whether output can be flushed after b, code blocks are
+// flushed once they are closed
+func endsBlock(b block) bool {
+	return b.kind != blockCodeStart && b.kind != blockCodeLine
+}
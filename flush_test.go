@@ -0,0 +1,98 @@
+package rnzml
+
+import (
+	"bufio"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// flushRecorder records the output written before each call to Flush
+type flushRecorder struct {
+	strings.Builder
+	flushed []string
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushed = append(f.flushed, f.String())
+}
+
+var flushtests = []struct {
+	in      string
+	flushed []string
+}{
+	{"a", []string{"<p>a\n</p>\n"}},
+	{"a\nb", []string{"<p>a\n</p>\n", "<p>a\n</p>\n<p>b\n</p>\n"}},
+	{"```\na\nb\n```\nc", []string{
+		"<pre><code>a\nb\n</code></pre>\n",
+		"<pre><code>a\nb\n</code></pre>\n<p>c\n</p>\n",
+	}},
+	{"a\n*b", []string{"<p>a\n</p>\n"}},
+}
+
+func TestFlush(t *testing.T) {
+	for _, tt := range flushtests {
+		t.Run("Should flush after each block of "+tt.in, func(t *testing.T) {
+			r := NewRenderer(WithFlush(nil))
+			out := &flushRecorder{}
+			r.Render(strings.NewReader(tt.in), out) //nolint: errcheck
+			if strings.Join(out.flushed, "|") != strings.Join(tt.flushed, "|") {
+				t.Errorf("expected: %q got: %q", tt.flushed, out.flushed)
+			}
+		})
+	}
+	t.Run("Should call fn after writing output", func(t *testing.T) {
+		out := &strings.Builder{}
+		var flushed []string
+		r := NewRenderer(WithFlush(func() error {
+			flushed = append(flushed, out.String())
+			return nil
+		}))
+		if err := r.Render(strings.NewReader("a\nb"), out); err != nil {
+			t.Fatal(err)
+		}
+		if len(flushed) != 2 || flushed[0] != "<p>a\n</p>\n" {
+			t.Errorf("expected 2 flushes got: %q", flushed)
+		}
+	})
+	t.Run("Should return errors from fn", func(t *testing.T) {
+		flushErr := errors.New("flush failed")
+		r := NewRenderer(WithFlush(func() error { return flushErr }))
+		err := r.Render(strings.NewReader("a\nb"), &strings.Builder{})
+		if !errors.Is(err, flushErr) {
+			t.Errorf("expected: '%v' got: '%v'", flushErr, err)
+		}
+	})
+	t.Run("Should flush an http.ResponseWriter", func(t *testing.T) {
+		r := NewRenderer(WithFlush(nil))
+		w := httptest.NewRecorder()
+		if err := r.Render(strings.NewReader("a"), w); err != nil {
+			t.Fatal(err)
+		}
+		if !w.Flushed || w.Body.String() != "<p>a\n</p>\n" {
+			t.Errorf("expected a flushed response got: '%s'", w.Body.String())
+		}
+	})
+	t.Run("Should flush a bufio.Writer", func(t *testing.T) {
+		r := NewRenderer(WithFlush(nil))
+		out := &strings.Builder{}
+		if err := r.Render(strings.NewReader("a"), bufio.NewWriter(out)); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != "<p>a\n</p>\n" {
+			t.Errorf("expected: '<p>a\n</p>\n' got: '%s'", out.String())
+		}
+	})
+	t.Run("Should flush after each chunk when rendering in parallel", func(t *testing.T) {
+		r := NewRenderer(WithFlush(nil), WithParallelism(2))
+		out := &flushRecorder{}
+		in := strings.Repeat("a\n", chunkBlocks*3)
+		if err := r.Render(strings.NewReader(in), out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out.flushed) != 3 {
+			t.Errorf("expected: 3 flushes got: %d", len(out.flushed))
+		}
+	})
+}
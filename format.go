@@ -0,0 +1,226 @@
+package rnzml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Format reads the rnzml document in and writes it to out in canonical form,
+// for editors and hooks that normalize documents before they are saved. The
+// canonical form renders as the input does, except for whitespace: lines
+// containing only whitespace become blank lines as with
+// WithBlankWhitespaceLines rather than text blocks, and whitespace at the end
+// of text blocks is no longer rendered. Documents are written with these
+// changes:
+//
+//   - Lines end with \n and the document with a single newline
+//   - Runs of blank lines and lines containing only whitespace become one blank
+//     line, and the blank lines at the start and end are removed
+//   - Whitespace at the end of text blocks is removed, unless the block would
+//     then be a code fence
+//   - Escapes of runes that are not control characters are removed, escaped
+//     whitespace is kept
+//   - Links labelled with their URL are written as [url]
+//
+// Code blocks are written as they are. An invalid document returns the error
+// Render returns for it and nothing is written to out. in is read with opts,
+// such as WithPreformattedBlocks, as a Lexer reads it.
+func Format(in io.Reader, out io.Writer, opts ...Option) error {
+	l := NewLexer(in, opts...)
+	var b bytes.Buffer
+	w := NewTokenWriter(&b)
+	for {
+		t, err := l.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := w.Write(t); err != nil {
+			return err
+		}
+	}
+	_, err := out.Write(b.Bytes())
+	return err
+}
+
+// Errors for tokens a TokenWriter cannot write
+var (
+	errNewline = errors.New("token text contains a newline")
+	errFence   = errors.New("code block fence is not ``` or longer, or ~~~")
+)
+
+// TokenWriter writes tokens as rnzml source in the canonical form of Format, so
+// programs can edit the tokens of a Lexer and write them back. Writing the
+// tokens of a document in canonical form writes it byte for byte, and the same
+// tokens are always written the same way, so edits only change the lines they
+// touch. Text is escaped where it contains control characters, and only
+// there.
+//
+// A TokenError is written as the text it contains, so the tokens of a document
+// lexed WithErrorRecovery are written as a valid document. Tokens must be
+// written in the order a Lexer returns them. The position and
+// line of tokens are ignored, and a text block is written when its
+// TokenTextEnd is written.
+type TokenWriter struct {
+	out   io.Writer
+	block []byte
+	buf   []byte
+	// kept is the length of block that is not trailing whitespace to remove
+	kept int
+	// written is true once a line is written, blank is true when a blank line
+	// is written before the next line
+	written, blank bool
+	code           bool
+	// fence is the fence of the code block being written when codeBlock is
+	// true
+	fence     []byte
+	codeBlock bool
+	err       error
+}
+
+// NewTokenWriter returns a TokenWriter writing to out
+func NewTokenWriter(out io.Writer) *TokenWriter {
+	return &TokenWriter{out: out}
+}
+
+// Write writes t. Tokens that cannot be written as rnzml, such as text
+// containing a newline or a code block line containing only the fence of its
+// block, return an error. The fences of a code block are its TokenCodeBlockStart
+// text, or ``` when it is empty. Errors writing to out are returned by every
+// later call.
+func (w *TokenWriter) Write(t Token) error {
+	if w.err != nil {
+		return w.err
+	}
+	if bytes.ContainsAny(t.Text, "\r\n") {
+		return lineError(t.Line, errNewline)
+	}
+	switch t.Kind {
+	case TokenBlankLine:
+		w.blank = w.written
+	case TokenTextStart:
+		w.block, w.kept, w.code = w.block[:0], 0, false
+	case TokenText, TokenError:
+		special := "\\*`["
+		if w.code {
+			special = "\\`"
+		}
+		for _, c := range t.Text {
+			if strings.IndexByte(special, c) == -1 {
+				w.block = append(w.block, c)
+				continue
+			}
+			w.block = append(w.block, '\\', c)
+			w.kept = len(w.block)
+		}
+	case TokenEscaped:
+		w.block = appendFormattedEscape(w.block, t.Text, w.code)
+		w.kept = len(w.block)
+	case TokenBoldStart, TokenBoldEnd:
+		w.block = append(w.block, '*')
+		w.kept = len(w.block)
+	case TokenCodeStart, TokenCodeEnd:
+		w.code = t.Kind == TokenCodeStart
+		w.block = append(w.block, '`')
+		w.kept = len(w.block)
+	case TokenLink:
+		w.block = appendFormattedLink(w.block, t.Text)
+		w.kept = len(w.block)
+	case TokenTextEnd:
+		// A line of only backticks is a fence without its whitespace, so it
+		// keeps the whitespace to stay text
+		trimmed := bytes.TrimRightFunc(w.block[w.kept:], unicode.IsSpace)
+		if len(trimmed) > 0 || backtickFence(w.block[:w.kept]).n == 0 {
+			w.block = append(w.block[:w.kept], trimmed...)
+		}
+		if len(w.block) == 0 {
+			w.blank = w.written
+			return nil
+		}
+		return w.line(w.block)
+	case TokenCodeBlockStart:
+		text, start := fenceNumber(t.Text)
+		if len(t.Text) > 0 && backtickFence(text).n == 0 && !bytes.Equal(text, preFence) {
+			return lineError(t.Line, errFence)
+		}
+		w.fence, w.codeBlock = append(w.fence[:0], codeFence...), true
+		if len(text) > 0 {
+			w.fence = append(w.fence[:0], text...)
+		}
+		if start > 0 {
+			return w.line(t.Text)
+		}
+		return w.line(w.fence)
+	case TokenCodeBlockEnd:
+		if !w.codeBlock {
+			w.fence = append(w.fence[:0], codeFence...)
+		}
+		w.codeBlock = false
+		return w.line(w.fence)
+	case TokenCodeBlockLine:
+		fence := codeFence
+		if w.codeBlock {
+			fence = w.fence
+		}
+		if bytes.Equal(t.Text, fence) {
+			return lineError(t.Line, fmt.Errorf("code block line contains only %s", fence))
+		}
+		return w.line(t.Text)
+	case TokenShortcode:
+		return w.line(t.Text)
+	default:
+		return fmt.Errorf("unknown token kind: %v", t.Kind)
+	}
+	return nil
+}
+
+// line writes a line to out, after a blank line if one is pending
+func (w *TokenWriter) line(line []byte) error {
+	w.buf = w.buf[:0]
+	if w.blank {
+		w.buf = append(w.buf, '\n')
+		w.blank = false
+	}
+	w.buf = append(append(w.buf, line...), '\n')
+	if _, w.err = w.out.Write(w.buf); w.err != nil {
+		return w.err
+	}
+	w.written = true
+	return nil
+}
+
+// appendFormattedEscape appends the escaped rune r to block, with a \ if it is
+// whitespace or a control character in code text when code is true or in text
+func appendFormattedEscape(block, r []byte, code bool) []byte {
+	c, _ := utf8.DecodeRune(r)
+	switch {
+	case c == '\\' || c == '`' || unicode.IsSpace(c):
+	case !code && (c == '*' || c == '['):
+	default:
+		return append(block, r...)
+	}
+	return append(append(block, '\\'), r...)
+}
+
+// appendFormattedLink appends the link with content to block
+func appendFormattedLink(block, content []byte) []byte {
+	rawURL, label := splitLink(content)
+	if bytes.Equal(rawURL, label) {
+		content = rawURL
+	}
+	block = append(block, '[')
+	for _, c := range content {
+		if c == '\\' || c == ']' {
+			block = append(block, '\\')
+		}
+		block = append(block, c)
+	}
+	return append(block, ']')
+}
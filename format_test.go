@@ -0,0 +1,193 @@
+package rnzml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+var formattests = []struct {
+	in  string
+	out string
+}{
+	{"", ""},
+	{"a", "a\n"},
+	{"\ufeffa\r\nb\r\n", "a\nb\n"},
+	{"\n\na\n\n\n  \nb\n\n", "a\n\nb\n"},
+	{"a  \n*b* \t", "a\n*b*\n"},
+	{"  a", "  a\n"},
+	{"\\a \\* \\` \\\\ \\[ \\] \\<", "a \\* \\` \\\\ \\[ ] <\n"},
+	{"`\\a \\* \\` \\\\`", "`a * \\` \\\\`\n"},
+	{"a\\ ", "a\\ \n"},
+	{"\\ ", "\\ \n"},
+	{"[https://res.nz https://res.nz] [https://res.nz] [/a b\\]c] [/a ] [\\/a\\\\]", "[https://res.nz] [https://res.nz] [/a b\\]c] [/a ] [/a\\\\]\n"},
+	{"```\n  a  \n\n\n```\n\n\n```\n```", "```\n  a  \n\n\n```\n\n```\n```\n"},
+	{"!if profile=a\n{{< a >}}", "!if profile=a\n{{< a >}}\n"},
+}
+
+func TestFormat(t *testing.T) {
+	for _, tt := range formattests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := &strings.Builder{}
+			if err := Format(strings.NewReader(tt.in), out); err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != out.String() {
+				t.Errorf("expected: %q got: %q", tt.out, out.String())
+			}
+		})
+	}
+	t.Run("Should not write invalid documents", func(t *testing.T) {
+		out := &strings.Builder{}
+		err := Format(strings.NewReader("a\n*b"), out)
+		if expected := "line 2: unclosed bold text (*) at position: 0"; fmt.Sprint(err) != expected {
+			t.Errorf("expected: '%s' got: '%v'", expected, err)
+		}
+		if out.Len() > 0 {
+			t.Errorf("expected no output got: %q", out.String())
+		}
+	})
+	t.Run("Should return read errors", func(t *testing.T) {
+		fail := errors.New("fail")
+		if err := Format(&errorReader{err: fail}, &strings.Builder{}); !errors.Is(err, fail) {
+			t.Errorf("expected: '%v' got: '%v'", fail, err)
+		}
+	})
+}
+
+func TestFormatRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	re := NewRenderer(WithCanonicalOutput())
+	for i := 0; i < 5000; i++ {
+		in := ""
+		for n := rnd.Intn(20); n > 0; n-- {
+			in += documentAlphabet[rnd.Intn(len(documentAlphabet))]
+		}
+		expected := &strings.Builder{}
+		if err := re.Render(strings.NewReader(in), expected); err != nil {
+			continue
+		}
+		out := &bytes.Buffer{}
+		if err := Format(strings.NewReader(in), out); err != nil {
+			t.Fatalf("%q: %v", in, err)
+		}
+		formatted := out.String()
+		got := &strings.Builder{}
+		if err := re.Render(strings.NewReader(formatted), got); err != nil {
+			t.Fatalf("%q formatted to %q: %v", in, formatted, err)
+		}
+		if trimParagraphs(expected.String()) != trimParagraphs(got.String()) {
+			t.Fatalf("%q formatted to %q: expected: %q got: %q", in, formatted, expected.String(), got.String())
+		}
+		out.Reset()
+		if err := Format(strings.NewReader(formatted), out); err != nil || out.String() != formatted {
+			t.Fatalf("%q: expected formatting to be stable got: %q", formatted, out.String())
+		}
+	}
+}
+
+// trimParagraphs removes the whitespace Format removes from the paragraphs of
+// canonical output
+func trimParagraphs(s string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if strings.HasPrefix(line, "<p>") && strings.HasSuffix(line, "</p>\n") {
+			text := strings.TrimRight(strings.TrimSuffix(line, "</p>\n"), " \t")
+			if text == "<p>" {
+				continue
+			}
+			line = text + "</p>\n"
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+func TestTokenWriter(t *testing.T) {
+	t.Run("Should escape control characters in text", func(t *testing.T) {
+		out := &strings.Builder{}
+		w := NewTokenWriter(out)
+		for _, token := range []Token{
+			{Kind: TokenTextStart},
+			{Kind: TokenText, Text: []byte("a*b[`\\]")},
+			{Kind: TokenCodeStart},
+			{Kind: TokenText, Text: []byte("`*[")},
+			{Kind: TokenCodeEnd},
+			{Kind: TokenText, Text: []byte(" * ")},
+			{Kind: TokenTextEnd},
+		} {
+			if err := w.Write(token); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if expected := "a\\*b\\[\\`\\\\]`\\`*[` \\*\n"; expected != out.String() {
+			t.Errorf("expected: %q got: %q", expected, out.String())
+		}
+	})
+	t.Run("Should write error tokens as text", func(t *testing.T) {
+		out := &strings.Builder{}
+		w := NewTokenWriter(out)
+		l := NewLexer(strings.NewReader("*a [b\n```\nc"), WithErrorRecovery())
+		for {
+			token, err := l.Next()
+			if err != nil {
+				break
+			}
+			if err := w.Write(token); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if expected := "\\*a \\[b\n```\nc\n```\n"; expected != out.String() {
+			t.Errorf("expected: %q got: %q", expected, out.String())
+		}
+	})
+	t.Run("Should only change edited lines", func(t *testing.T) {
+		in := "a [/b c] \\<\n\n```\nd\n```\n*e* [/f g]\n"
+		out := &strings.Builder{}
+		w := NewTokenWriter(out)
+		l := NewLexer(strings.NewReader(in))
+		for {
+			token, err := l.Next()
+			if err != nil {
+				break
+			}
+			if token.Kind == TokenLink && token.Line == 6 {
+				token.Text = []byte("/h g")
+			}
+			if err := w.Write(token); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if expected := "a [/b c] <\n\n```\nd\n```\n*e* [/h g]\n"; expected != out.String() {
+			t.Errorf("expected: %q got: %q", expected, out.String())
+		}
+	})
+	var errortests = []struct {
+		token Token
+		err   string
+	}{
+		{Token{Kind: TokenText, Line: 2, Text: []byte("a\nb")}, "line 2: token text contains a newline"},
+		{Token{Kind: TokenCodeBlockLine, Line: 3, Text: []byte("```")}, "line 3: code block line contains only ```"},
+		{Token{Kind: TokenKind(100)}, "unknown token kind: TokenKind(100)"},
+	}
+	for _, tt := range errortests {
+		t.Run(fmt.Sprintf("Should return an error for %v", tt.token.Kind), func(t *testing.T) {
+			if err := NewTokenWriter(&strings.Builder{}).Write(tt.token); fmt.Sprint(err) != tt.err {
+				t.Errorf("expected: '%s' got: '%v'", tt.err, err)
+			}
+		})
+	}
+	t.Run("Should return write errors", func(t *testing.T) {
+		fail := errors.New("fail")
+		w := NewTokenWriter(&failingWriter{err: fail})
+		if err := w.Write(Token{Kind: TokenCodeBlockStart}); err != fail {
+			t.Errorf("expected: '%v' got: '%v'", fail, err)
+		}
+		if err := w.Write(Token{Kind: TokenBlankLine}); err != fail {
+			t.Errorf("expected: '%v' got: '%v'", fail, err)
+		}
+	})
+}
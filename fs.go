@@ -0,0 +1,109 @@
+package rnzml
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// FSRenderer renders the rnzml files of a file system, such as an embed.FS of
+// documentation shipped in a binary. Each file is rendered the first time it
+// is requested and its output is kept, so it is safe for concurrent use and
+// only renders a file once. The files are expected not to change, errors
+// rendering a file are kept as its output is. Files that cannot be read, such
+// as files that do not exist, are not kept.
+type FSRenderer struct {
+	fsys  fs.FS
+	re    *Renderer
+	mu    sync.Mutex
+	pages map[string]*fsPage
+}
+
+// fsPage is the output of a file rendered by an FSRenderer
+type fsPage struct {
+	once sync.Once
+	html []byte
+	err  error
+}
+
+// NewFSRenderer returns an FSRenderer rendering the files of fsys with a
+// Renderer configured by opts
+func NewFSRenderer(fsys fs.FS, opts ...Option) *FSRenderer {
+	return &FSRenderer{fsys: fsys, re: NewRenderer(opts...), pages: map[string]*fsPage{}}
+}
+
+// Render returns the output of the file name, a path in the file system such
+// as "guide/intro.rnzml". The output is shared and must not be modified.
+func (f *FSRenderer) Render(name string) ([]byte, error) {
+	f.mu.Lock()
+	page, ok := f.pages[name]
+	if !ok {
+		page = &fsPage{}
+		f.pages[name] = page
+	}
+	f.mu.Unlock()
+	page.once.Do(func() {
+		in, err := fs.ReadFile(f.fsys, name)
+		if err != nil {
+			// Only files that exist are kept, so requests for random names
+			// do not grow pages
+			f.mu.Lock()
+			if f.pages[name] == page {
+				delete(f.pages, name)
+			}
+			f.mu.Unlock()
+			page.err = err
+			return
+		}
+		page.html, page.err = f.re.RenderToBytes(in)
+	})
+	return page.html, page.err
+}
+
+// Prewarm renders every .rnzml file of the file system, so requests do not
+// wait for rendering and documents that cannot be rendered are found at
+// startup. It returns the first error, after rendering the other files.
+func (f *FSRenderer) Prewarm() error {
+	var first error
+	err := fs.WalkDir(f.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(name) != fileExtension {
+			return nil
+		}
+		if _, err := f.Render(name); err != nil && first == nil {
+			first = &fs.PathError{Op: "render", Path: name, Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return first
+}
+
+// ServeHTTP serves the output of the file named by the path of the request
+// with the .rnzml extension added, /guide/intro is guide/intro.rnzml and
+// /guide/ is guide/index.rnzml. Files that do not exist are not found and
+// files that cannot be rendered are an internal server error.
+func (f *FSRenderer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if strings.HasSuffix(r.URL.Path, "/") || name == "" {
+		name = path.Join(name, "index")
+	}
+	html, err := f.Render(name + fileExtension)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(html) //nolint: errcheck
+}
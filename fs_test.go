@@ -0,0 +1,97 @@
+package rnzml
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+var fstests = []struct {
+	path   string
+	status int
+	body   string
+}{
+	{"/", 200, "<p>index\n</p>\n"},
+	{"/guide/intro", 200, "<p><strong>intro</strong>\n</p>\n"},
+	{"/guide/", 200, "<p>guide\n</p>\n"},
+	{"/guide/../guide/intro", 200, "<p><strong>intro</strong>\n</p>\n"},
+	{"/guide/intro.rnzml", 404, "404 page not found\n"},
+	{"/missing", 404, "404 page not found\n"},
+	{"/broken", 500, "Internal Server Error\n"},
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.rnzml":       {Data: []byte("index")},
+		"guide/index.rnzml": {Data: []byte("guide")},
+		"guide/intro.rnzml": {Data: []byte("*intro*")},
+		"broken.rnzml":      {Data: []byte("*a")},
+		"image.png":         {Data: []byte("*")},
+	}
+}
+
+func TestFSRenderer(t *testing.T) {
+	f := NewFSRenderer(testFS())
+	for _, tt := range fstests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.status {
+				t.Errorf("expected status: %d got: %d", tt.status, w.Code)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("expected: %q got: %q", tt.body, w.Body.String())
+			}
+		})
+	}
+	t.Run("Should render each file once", func(t *testing.T) {
+		fsys := testFS()
+		f := NewFSRenderer(fsys)
+		first, err := f.Render("index.rnzml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsys["index.rnzml"].Data = []byte("changed")
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if html, err := f.Render("index.rnzml"); err != nil || string(html) != string(first) {
+					t.Errorf("expected: %q got: %q %v", first, html, err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	t.Run("Should prewarm every file and return the first error", func(t *testing.T) {
+		f := NewFSRenderer(testFS())
+		err := f.Prewarm()
+		var syntaxErr *SyntaxError
+		var pathErr *fs.PathError
+		if !errors.As(err, &pathErr) || pathErr.Path != "broken.rnzml" || !errors.As(err, &syntaxErr) {
+			t.Fatalf("expected render error for broken.rnzml got: '%v'", err)
+		}
+		if len(f.pages) != 4 {
+			t.Errorf("expected 4 rendered files got: %d", len(f.pages))
+		}
+	})
+	t.Run("Should return errors opening files", func(t *testing.T) {
+		if _, err := NewFSRenderer(testFS()).Render("missing.rnzml"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected: '%v' got: '%v'", fs.ErrNotExist, err)
+		}
+	})
+	t.Run("Should not keep files that do not exist", func(t *testing.T) {
+		f := NewFSRenderer(testFS())
+		for _, path := range []string{"/a", "/b", "/c/"} {
+			f.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+		if len(f.pages) != 0 {
+			t.Errorf("expected no kept files got: %d", len(f.pages))
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package rnzml
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WriteFS is a file system RenderFS writes a tree to
+type WriteFS interface {
+	// Create creates or truncates the file name, a path as in fs.FS, and the
+	// directories it is in
+	Create(name string) (io.WriteCloser, error)
+}
+
+// DirWriteFS returns a WriteFS writing to the tree of files rooted at the
+// directory dir
+func DirWriteFS(dir string) WriteFS {
+	return dirWriteFS(dir)
+}
+
+// dirWriteFS is a WriteFS writing to the directory it names
+type dirWriteFS string
+
+// Create creates the file name in the directory
+func (dir dirWriteFS) Create(name string) (io.WriteCloser, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrInvalid}
+	}
+	file := filepath.Join(string(dir), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0o777); err != nil {
+		return nil, err
+	}
+	return os.Create(file)
+}
+
+// FileResult is the result of a file of a tree written by RenderFS
+type FileResult struct {
+	// Source is the name of the file in the source file system
+	Source string
+	// Output is the name of the file written, .rnzml files are written to a
+	// .html file of the same name
+	Output string
+	// Rendered is true for rnzml files and false for files copied
+	Rendered bool
+	// Warnings are the warnings reported rendering the file
+	Warnings []Warning
+	// Err is the error rendering, reading or writing the file, the output
+	// of a file that cannot be rendered is not written
+	Err error
+}
+
+// RenderFS walks src, such as an os.DirFS, an embed.FS or a zip.Reader, and
+// writes its tree to dst, with each .rnzml file rendered with a Renderer
+// configured by opts to a .html file of the same name. Other files are copied.
+// A file that cannot be rendered or copied does not stop the walk, the result
+// of each file is returned in the order of the walk with the first error of a
+// file. Warnings are returned with the result of their file and are also
+// reported to a function set with WithWarnings.
+func RenderFS(src fs.FS, dst WriteFS, opts ...Option) ([]FileResult, error) {
+	re := NewRenderer(opts...)
+	var current *FileResult
+	warnings := re.warnings
+	re.warnings = func(w Warning) {
+		current.Warnings = append(current.Warnings, w)
+		if warnings != nil {
+			warnings(w)
+		}
+	}
+	var results []FileResult
+	var first error
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		result := FileResult{Source: name}
+		result.Output, result.Rendered = htmlName(name)
+		current = &result
+		if err := writeFSFile(re, src, dst, &result); err != nil {
+			result.Err = &fs.PathError{Op: "render", Path: name, Err: err}
+			if first == nil {
+				first = result.Err
+			}
+		}
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return results, err
+	}
+	return results, first
+}
+
+// writeFSFile writes the file of result to dst, rendered when it is an rnzml
+// file
+func writeFSFile(re *Renderer, src fs.FS, dst WriteFS, result *FileResult) error {
+	if result.Rendered {
+		in, err := fs.ReadFile(src, result.Source)
+		if err != nil {
+			return err
+		}
+		html, err := re.RenderToBytes(in)
+		if err != nil {
+			return err
+		}
+		return writeFile(dst, result.Output, func(w io.Writer) error {
+			_, err := w.Write(html)
+			return err
+		})
+	}
+	in, err := src.Open(result.Source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return writeFile(dst, result.Output, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+}
+
+// writeFile creates the file name of dst and writes it with write
+func writeFile(dst WriteFS, name string, write func(io.Writer) error) error {
+	w, err := dst.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(w); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,94 @@
+package rnzml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// memWriteFS is a WriteFS keeping the files written in memory
+type memWriteFS map[string]*bytes.Buffer
+
+func (m memWriteFS) Create(name string) (io.WriteCloser, error) {
+	b := &bytes.Buffer{}
+	m[name] = b
+	return nopWriteCloser{b}, nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestRenderFS(t *testing.T) {
+	t.Run("Should render rnzml files and copy other files", func(t *testing.T) {
+		dst := memWriteFS{}
+		results, err := RenderFS(testFS(), dst)
+		var syntaxErr *SyntaxError
+		var pathErr *fs.PathError
+		if !errors.As(err, &pathErr) || pathErr.Path != "broken.rnzml" || !errors.As(err, &syntaxErr) {
+			t.Fatalf("expected render error for broken.rnzml got: '%v'", err)
+		}
+		expected := map[string]string{
+			"index.html":       "<p>index\n</p>\n",
+			"guide/index.html": "<p>guide\n</p>\n",
+			"guide/intro.html": "<p><strong>intro</strong>\n</p>\n",
+			"image.png":        "*",
+		}
+		if len(dst) != len(expected) {
+			t.Errorf("expected %d files got: %d", len(expected), len(dst))
+		}
+		for name, content := range expected {
+			if b, ok := dst[name]; !ok || b.String() != content {
+				t.Errorf("expected %s: %q got: %q", name, content, b)
+			}
+		}
+		if len(results) != 5 {
+			t.Fatalf("expected 5 results got: %d", len(results))
+		}
+		for _, r := range results {
+			if (r.Err != nil) != (r.Source == "broken.rnzml") {
+				t.Errorf("unexpected error for %s: %v", r.Source, r.Err)
+			}
+			if r.Rendered != (filepath.Ext(r.Source) == ".rnzml") {
+				t.Errorf("expected %s rendered: %t", r.Source, !r.Rendered)
+			}
+		}
+	})
+	t.Run("Should return the warnings of each file", func(t *testing.T) {
+		src := fstest.MapFS{
+			"a.rnzml": {Data: []byte("[ftp://a a]")},
+			"b.rnzml": {Data: []byte("b")},
+		}
+		var reported int
+		results, err := RenderFS(src, memWriteFS{}, WithWarnings(func(Warning) { reported++ }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results[0].Warnings) != 1 || len(results[1].Warnings) != 0 {
+			t.Errorf("expected one warning for a.rnzml got: %v %v", results[0].Warnings, results[1].Warnings)
+		}
+		if reported != 1 {
+			t.Errorf("expected the warning reported got: %d", reported)
+		}
+	})
+	t.Run("Should write to a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := RenderFS(fstest.MapFS{"a/b.rnzml": {Data: []byte("b")}}, DirWriteFS(dir)); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, "a", "b.html"))
+		if err != nil || string(b) != "<p>b\n</p>\n" {
+			t.Errorf("expected: %q got: %q %v", "<p>b\n</p>\n", b, err)
+		}
+	})
+	t.Run("Should not create files outside the directory", func(t *testing.T) {
+		if _, err := DirWriteFS(t.TempDir()).Create("../a"); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("expected invalid path error got: %v", err)
+		}
+	})
+}
@@ -1,3 +1,5 @@
 module github.com/Resonance1584/rnzml
 
-go 1.15
+go 1.18
+
+require golang.org/x/net v0.23.0
@@ -0,0 +1,121 @@
+package rnzml
+
+import (
+	"bufio"
+	"io"
+	"unicode/utf8"
+)
+
+// ANSI escape codes Highlight colors source with
+const (
+	ansiReset   = "\x1b[0m"
+	ansiControl = "\x1b[33m"   // yellow
+	ansiEscape  = "\x1b[35m"   // magenta
+	ansiCode    = "\x1b[32m"   // green
+	ansiFence   = "\x1b[1;36m" // bold cyan
+	ansiURL     = "\x1b[4;34m" // underlined blue
+)
+
+// Highlight writes the rnzml source read from in to out colored with ANSI
+// escape codes, for showing documents and the context of errors in a
+// terminal. Control characters, escapes, code, code fences and link URLs each
+// have a color. Invalid source is highlighted as far as it can be, unclosed
+// bold, code or links are colored to the end of their line.
+func Highlight(in io.Reader, out io.Writer) error {
+	r := bufio.NewReader(in)
+	w := bufio.NewWriter(out)
+	// open is the fence of the open code block, or the zero fence
+	var open fence
+	var b []byte
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			content := trimLineEnding(line)
+			switch {
+			case open.n == 0 && backtickFence(content).n > 0, open.closedBy(content):
+				if open.n == 0 {
+					open = backtickFence(content)
+				} else {
+					open = fence{}
+				}
+				b = append(append(append(b[:0], ansiFence...), content...), ansiReset...)
+			case open.n > 0:
+				b = append(b[:0], ansiCode...)
+				b = append(append(b, content...), ansiReset...)
+				if len(content) == 0 {
+					b = b[:0]
+				}
+			default:
+				b = appendHighlightedLine(b[:0], content)
+			}
+			w.Write(b)
+			w.Write(line[len(content):])
+		}
+		if err == io.EOF {
+			return w.Flush()
+		}
+		if err != nil {
+			w.Flush()
+			return err
+		}
+	}
+}
+
+// HighlightLine returns a line of a text block colored as Highlight colors it
+func HighlightLine(line string) string {
+	return string(appendHighlightedLine(nil, []byte(line)))
+}
+
+// appendHighlightedLine appends the text block line highlighted to b
+func appendHighlightedLine(b, line []byte) []byte {
+	code, link, label := false, false, false
+	// color is the color of the text being written, restored after escapes
+	// and control characters
+	color := ""
+	set := func(c string) {
+		if c == color {
+			return
+		}
+		if color != "" {
+			b = append(b, ansiReset...)
+		}
+		b = append(b, c...)
+		color = c
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\':
+			set(ansiEscape)
+			_, size := utf8.DecodeRune(line[i+1:])
+			b = append(b, line[i:i+1+size]...)
+			i += size
+			continue
+		case link && c == ']':
+			link, label = false, false
+			set(ansiControl)
+		case link && c == ' ' && !label:
+			label = true
+			set("")
+		case link && !label:
+			set(ansiURL)
+		case link:
+			set("")
+		case c == '`':
+			code = !code
+			set(ansiControl)
+		case code:
+			set(ansiCode)
+		case c == '*':
+			set(ansiControl)
+		case c == '[':
+			link = true
+			set(ansiControl)
+		default:
+			set("")
+		}
+		b = append(b, c)
+	}
+	set("")
+	return b
+}
@@ -0,0 +1,57 @@
+package rnzml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Short names for the escape codes in expected output
+const (
+	hr = ansiReset
+	hc = ansiControl
+	he = ansiEscape
+	hk = ansiCode
+	hf = ansiFence
+	hu = ansiURL
+)
+
+var highlighttests = []struct {
+	in  string
+	out string
+}{
+	{"a b", "a b"},
+	{"a *b*", "a " + hc + "*" + hr + "b" + hc + "*" + hr},
+	{"`*a\\``", hc + "`" + hr + hk + "*a" + hr + he + "\\`" + hr + hc + "`" + hr},
+	{"[https://res.nz a b] c", hc + "[" + hr + hu + "https://res.nz" + hr + " a b" + hc + "]" + hr + " c"},
+	{"[/a\\]b c\\] d]", hc + "[" + hr + hu + "/a" + hr + he + "\\]" + hr + hu + "b" + hr + " c" + he + "\\]" + hr + " d" + hc + "]" + hr},
+	{"\\ā*", he + "\\ā" + hr + hc + "*" + hr},
+	{"*unclosed `code", hc + "*" + hr + "unclosed " + hc + "`" + hr + hk + "code" + hr},
+	{"a\\", "a" + he + "\\" + hr},
+	{"```\r\n*a*\n\n```\nb", hf + "```" + hr + "\r\n" + hk + "*a*" + hr + "\n\n" + hf + "```" + hr + "\nb"},
+}
+
+func TestHighlight(t *testing.T) {
+	for _, tt := range highlighttests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := &strings.Builder{}
+			if err := Highlight(strings.NewReader(tt.in), out); err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != out.String() {
+				t.Errorf("expected: %q got: %q", tt.out, out.String())
+			}
+		})
+	}
+	t.Run("Should highlight a line", func(t *testing.T) {
+		if expected := "a " + hc + "*" + hr + "b"; HighlightLine("a *b") != expected {
+			t.Errorf("expected: %q got: %q", expected, HighlightLine("a *b"))
+		}
+	})
+	t.Run("Should return read errors", func(t *testing.T) {
+		fail := errors.New("fail")
+		if err := Highlight(&errorReader{err: fail}, &strings.Builder{}); err != fail {
+			t.Errorf("expected: '%v' got: '%v'", fail, err)
+		}
+	})
+}
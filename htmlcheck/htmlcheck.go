@@ -0,0 +1,137 @@
+// Package htmlcheck checks that HTML fragments, such as rnzml output, are well
+// formed.
+//
+// The fragment is tokenized with golang.org/x/net/html rather than parsed, as
+// the parser silently repairs the problems this package reports: tags that are
+// not closed or closed out of order, block elements inside inline elements or
+// paragraphs, and links inside links.
+package htmlcheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Error describes a problem found in a fragment
+type Error struct {
+	// Offset is the byte offset of the tag the problem was found at
+	Offset  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at offset: %d", e.Message, e.Offset)
+}
+
+// voidElements never have a closing tag
+var voidElements = map[atom.Atom]bool{
+	atom.Area: true, atom.Base: true, atom.Br: true, atom.Col: true,
+	atom.Embed: true, atom.Hr: true, atom.Img: true, atom.Input: true,
+	atom.Link: true, atom.Meta: true, atom.Source: true, atom.Track: true,
+	atom.Wbr: true,
+}
+
+// IsVoidElement reports whether the element name, such as br, never has a
+// closing tag
+func IsVoidElement(name string) bool {
+	return voidElements[atom.Lookup([]byte(name))]
+}
+
+// blockElements may not be placed inside a paragraph or an inline element
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true,
+	atom.Blockquote: true, atom.Details: true, atom.Div: true, atom.Dl: true,
+	atom.Fieldset: true, atom.Figure: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true,
+	atom.H6: true, atom.Header: true, atom.Hr: true, atom.Li: true,
+	atom.Main: true, atom.Nav: true, atom.Ol: true, atom.P: true,
+	atom.Pre: true, atom.Section: true, atom.Table: true, atom.Ul: true,
+}
+
+// inlineElements only contain text and other inline elements
+var inlineElements = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.B: true, atom.Cite: true,
+	atom.Code: true, atom.Del: true, atom.Em: true, atom.I: true,
+	atom.Ins: true, atom.Kbd: true, atom.Mark: true, atom.Q: true,
+	atom.S: true, atom.Samp: true, atom.Small: true, atom.Span: true,
+	atom.Strong: true, atom.Sub: true, atom.Sup: true, atom.U: true,
+	atom.Var: true,
+}
+
+type element struct {
+	name   string
+	atom   atom.Atom
+	offset int
+}
+
+// Check reads an HTML fragment from r and returns an *Error describing the
+// first problem found, or an error from r
+func Check(r io.Reader) error {
+	z := html.NewTokenizer(r)
+	var open []element
+	offset := 0
+	for {
+		tt := z.Next()
+		raw := len(z.Raw())
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return err
+			}
+			if len(open) > 0 {
+				last := open[len(open)-1]
+				return &Error{Offset: last.offset, Message: fmt.Sprintf("unclosed <%s>", last.name)}
+			}
+			return nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			el := element{name: string(name), atom: atom.Lookup(name), offset: offset}
+			if err := checkNesting(open, el); err != nil {
+				return err
+			}
+			if tt == html.StartTagToken && !voidElements[el.atom] {
+				open = append(open, el)
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if len(open) == 0 {
+				return &Error{Offset: offset, Message: fmt.Sprintf("</%s> was never opened", name)}
+			}
+			if last := open[len(open)-1]; last.name != string(name) {
+				return &Error{Offset: offset, Message: fmt.Sprintf("</%s> closes <%s>", name, last.name)}
+			}
+			open = open[:len(open)-1]
+		}
+		offset += raw
+	}
+}
+
+// CheckBytes checks the HTML fragment in b
+func CheckBytes(b []byte) error {
+	return Check(bytes.NewReader(b))
+}
+
+// checkNesting returns an *Error if el cannot be placed inside the open
+// elements
+func checkNesting(open []element, el element) error {
+	if len(open) == 0 {
+		return nil
+	}
+	parent := open[len(open)-1]
+	if blockElements[el.atom] && (parent.atom == atom.P || inlineElements[parent.atom]) {
+		return &Error{Offset: el.offset, Message: fmt.Sprintf("<%s> inside <%s>", el.name, parent.name)}
+	}
+	if el.atom == atom.A {
+		for _, o := range open {
+			if o.atom == atom.A {
+				return &Error{Offset: el.offset, Message: "<a> inside <a>"}
+			}
+		}
+	}
+	return nil
+}
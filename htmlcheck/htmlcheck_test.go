@@ -0,0 +1,40 @@
+package htmlcheck
+
+import (
+	"testing"
+)
+
+var checktests = []struct {
+	in  string
+	err string
+}{
+	{"", ""},
+	{"<p>a <strong>b</strong></p>\n", ""},
+	{`<p><a href="/a" target="_blank">a &lt; b</a></p>`, ""},
+	{"<pre><code>a\n</code></pre>\n", ""},
+	{"<p>a<br>b</p>", ""},
+	{"<section><ol><li>a</li></ol></section>", ""},
+	{"<p>a", "unclosed <p> at offset: 0"},
+	{"a</p>", "</p> was never opened at offset: 1"},
+	{"<p><strong>a</p></strong>", "</p> closes <strong> at offset: 12"},
+	{"<p><pre></pre></p>", "<pre> inside <p> at offset: 3"},
+	{"<strong><div></div></strong>", "<div> inside <strong> at offset: 8"},
+	{`<a href="a"><span><a href="b">b</a></span></a>`, "<a> inside <a> at offset: 18"},
+}
+
+func TestCheck(t *testing.T) {
+	for _, tt := range checktests {
+		t.Run(tt.in, func(t *testing.T) {
+			err := CheckBytes([]byte(tt.in))
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("error: %s", err.Error())
+				}
+			} else if err == nil {
+				t.Errorf("expected error")
+			} else if tt.err != err.Error() {
+				t.Errorf("expected: '%s' got: '%s'", tt.err, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package rnzml
+
+// BlockKind is the kind of a block in an IndexEntry
+type BlockKind int
+
+const (
+	// TextBlock is a text block, spanning several lines when continued with a
+	// trailing \
+	TextBlock BlockKind = iota
+	// CodeBlock is a code block including its fences
+	CodeBlock
+	// ShortcodeBlock is a line calling a shortcode
+	ShortcodeBlock
+)
+
+// IndexEntry maps a block to the lines of the input it was rendered from and
+// the range of the output it was rendered to
+type IndexEntry struct {
+	Kind BlockKind
+	// StartLine and EndLine are the first and last line of the block
+	StartLine int
+	EndLine   int
+	// OutputStart and OutputEnd are the byte offsets of the output of the
+	// block, the output of the block is output[OutputStart:OutputEnd]
+	OutputStart int
+	OutputEnd   int
+}
+
+// WithBlockIndex calls fn with an IndexEntry for each text block, code block
+// and shortcode once it is rendered, in the order they appear in the input.
+// Collecting the entries builds an index of the output for paginating it or
+// jumping to a block. Blank lines are not indexed, and a block that fails to
+// render is not indexed.
+func WithBlockIndex(fn func(IndexEntry)) Option {
+	return func(re *Renderer) {
+		re.blockIndex = fn
+	}
+}
+
+// indexer reports IndexEntries for rendered blocks
+type indexer struct {
+	fn func(IndexEntry)
+	// code is the entry of the code block being rendered
+	code IndexEntry
+}
+
+// add indexes b, which was rendered to the output from start to end. ix may be
+// nil.
+func (ix *indexer) add(b block, start, end int) {
+	if ix == nil {
+		return
+	}
+	switch b.kind {
+	case blockText:
+		ix.fn(IndexEntry{Kind: TextBlock, StartLine: b.line, EndLine: b.lastLine, OutputStart: start, OutputEnd: end})
+	case blockShortcode:
+		ix.fn(IndexEntry{Kind: ShortcodeBlock, StartLine: b.line, EndLine: b.line, OutputStart: start, OutputEnd: end})
+	case blockCodeStart:
+		ix.code = IndexEntry{Kind: CodeBlock, StartLine: b.line, OutputStart: start}
+	case blockCodeEnd:
+		ix.code.EndLine = b.line
+		ix.code.OutputEnd = end
+		ix.fn(ix.code)
+	}
+}
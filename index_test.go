@@ -0,0 +1,66 @@
+package rnzml
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var indextests = []struct {
+	in      string
+	opts    []Option
+	entries []IndexEntry
+}{
+	{"", nil, nil},
+	{"a\n\nb", nil, []IndexEntry{
+		{Kind: TextBlock, StartLine: 1, EndLine: 1, OutputStart: 0, OutputEnd: 10},
+		{Kind: TextBlock, StartLine: 3, EndLine: 3, OutputStart: 11, OutputEnd: 21},
+	}},
+	{"```\na\n```\nb", nil, []IndexEntry{
+		{Kind: CodeBlock, StartLine: 1, EndLine: 3, OutputStart: 0, OutputEnd: 27},
+		{Kind: TextBlock, StartLine: 4, EndLine: 4, OutputStart: 27, OutputEnd: 37},
+	}},
+	{"a\\\nb\nc", []Option{WithTrailingBackslash(TrailingBackslashJoin)}, []IndexEntry{
+		{Kind: TextBlock, StartLine: 1, EndLine: 2, OutputStart: 0, OutputEnd: 12},
+		{Kind: TextBlock, StartLine: 3, EndLine: 3, OutputStart: 12, OutputEnd: 22},
+	}},
+	{"a\n*b", nil, []IndexEntry{
+		{Kind: TextBlock, StartLine: 1, EndLine: 1, OutputStart: 0, OutputEnd: 10},
+	}},
+}
+
+func TestBlockIndex(t *testing.T) {
+	for _, tt := range indextests {
+		t.Run(tt.in, func(t *testing.T) {
+			var entries []IndexEntry
+			opts := append([]Option{WithBlockIndex(func(e IndexEntry) {
+				entries = append(entries, e)
+			})}, tt.opts...)
+			NewRenderer(opts...).Render(strings.NewReader(tt.in), &strings.Builder{}) //nolint: errcheck
+			if fmt.Sprint(tt.entries) != fmt.Sprint(entries) {
+				t.Errorf("expected: %+v got: %+v", tt.entries, entries)
+			}
+		})
+	}
+	t.Run("Should index the same blocks when rendering in parallel", func(t *testing.T) {
+		var expected, got []IndexEntry
+		out := &strings.Builder{}
+		NewRenderer(WithBlockIndex(func(e IndexEntry) {
+			expected = append(expected, e)
+		})).Render(strings.NewReader(largeDocument), out) //nolint: errcheck
+		NewRenderer(WithParallelism(4), WithBlockIndex(func(e IndexEntry) {
+			got = append(got, e)
+		})).Render(strings.NewReader(largeDocument), &strings.Builder{}) //nolint: errcheck
+		if fmt.Sprint(expected) != fmt.Sprint(got) {
+			t.Errorf("expected %d entries got: %d", len(expected), len(got))
+		}
+		last := expected[len(expected)-1]
+		// The document ends with a blank line
+		if last.OutputEnd != out.Len()-1 {
+			t.Errorf("expected the last block to end at: %d got: %d", out.Len()-1, last.OutputEnd)
+		}
+		if output := out.String()[last.OutputStart:last.OutputEnd]; !strings.HasPrefix(output, "<pre><code>") {
+			t.Errorf("expected the last block to be a code block got: '%s'", output)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package rnzml
+
+import (
+	"unicode/utf8"
+)
+
+// inlineKind is the kind of an inline token in a text block
+type inlineKind int
+
+const (
+	// inlineText is text as written in the input, it is valid UTF-8 and
+	// contains no bytes that are escaped in HTML
+	inlineText inlineKind = iota
+	// inlineRune is a single rune of text, which may be escaped in HTML.
+	// Invalid bytes are passed as utf8.RuneError.
+	inlineRune
+	// inlineEscaped is a rune escaped with a \ in the input, its position is
+	// the position of the \
+	inlineEscaped
+	inlineBoldStart
+	inlineBoldEnd
+	inlineCodeStart
+	inlineCodeEnd
+	// inlineLink is a link, the text of the token is the content between the
+	// [ and ] with escapes removed
+	inlineLink
+)
+
+// inlineHandler is called with the inline tokens of a line. The text of a
+// token is only valid until inline returns.
+type inlineHandler interface {
+	inline(kind inlineKind, position int, text []byte) error
+}
+
+// Bytes that end a plain span in a text block, in code text and in a link.
+// Text and code text spans are written without escaping, so they also end at
+// bytes in htmlEscapes.
+var (
+	textSpecial = specialBytes("\\*`[<>&'\"\x00")
+	codeSpecial = specialBytes("\\`<>&'\"\x00")
+	linkSpecial = specialBytes("\\]")
+)
+
+func specialBytes(chars string) (special [utf8.RuneSelf]bool) {
+	for i := 0; i < len(chars); i++ {
+		special[chars[i]] = true
+	}
+	return special
+}
+
+// plainSpan returns the length of the prefix of line that contains no special
+// bytes and no invalid UTF-8, which are decoded to utf8.RuneError instead
+func plainSpan(line []byte, special *[utf8.RuneSelf]bool) int {
+	n := 0
+	for n < len(line) {
+		if c := line[n]; c < utf8.RuneSelf {
+			if special[c] {
+				return n
+			}
+			n++
+			continue
+		}
+		r, size := utf8.DecodeRune(line[n:])
+		if r == utf8.RuneError && size == 1 {
+			return n
+		}
+		n += size
+	}
+	return n
+}
+
+// scanInline splits line into inline tokens, calling h with each token and its
+// position in line. lineNumber is used to report warnings.
+func (re *Renderer) scanInline(st *renderState, line []byte, lineNumber int, h inlineHandler) error {
+	// Track position of last control characters for error reporting.
+	// When a control character occurs again reset the value.
+	lastEscape := -1
+	lastBold := -1
+	lastCode := -1
+	lastLink := -1
+
+	// When a link is started runes are written to st.link, when finished
+	// the link is passed to h and st.link is reset.
+	st.link = st.link[:0]
+
+	for n, size := 0, 0; n < len(line); n += size {
+		if lastEscape == -1 {
+			// Pass on the span of bytes up to the next byte that is a control
+			// character or needs escaping as one token
+			special := &textSpecial
+			if lastLink > -1 {
+				special = &linkSpecial
+			} else if lastCode > -1 {
+				special = &codeSpecial
+			}
+			if size = plainSpan(line[n:], special); size > 0 {
+				if lastLink > -1 {
+					st.link = append(st.link, line[n:n+size]...)
+				} else if err := h.inline(inlineText, n, line[n:n+size]); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		r := rune(line[n])
+		size = 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRune(line[n:])
+		}
+		if lastEscape > -1 {
+			// Always check for escape first
+			if lastLink > -1 {
+				st.appendLinkRune(r)
+			} else if err := h.inline(inlineEscaped, lastEscape, st.encodeRune(r)); err != nil {
+				return err
+			}
+			lastEscape = -1
+		} else if lastLink > -1 {
+			if r == '\\' { // Escapes still work on ] in links
+				lastEscape = n
+			} else if r == ']' { // End link is the only control character in a link
+				if err := h.inline(inlineLink, lastLink, st.link); err != nil {
+					return err
+				}
+				lastLink = -1
+				st.link = st.link[:0]
+			} else {
+				// Write current rune to current link
+				st.appendLinkRune(r)
+			}
+		} else if lastCode > -1 {
+			if r == '\\' { // Escapes still work on `
+				lastEscape = n
+			} else if r == '`' { // End code is the only control character in code
+				if lastCode == n-1 {
+					re.warn(st, lineNumber, lastCode, "empty code text (`)")
+				}
+				if err := h.inline(inlineCodeEnd, n, nil); err != nil {
+					return err
+				}
+				lastCode = -1
+			} else if err := h.inline(inlineRune, n, st.encodeRune(r)); err != nil {
+				return err
+			}
+		} else {
+			var err error
+			switch r {
+			case '\\':
+				lastEscape = n
+			case '*':
+				if lastBold < 0 {
+					err = h.inline(inlineBoldStart, n, nil)
+					lastBold = n
+				} else {
+					if lastBold == n-1 {
+						re.warn(st, lineNumber, lastBold, "empty bold text (*)")
+					}
+					err = h.inline(inlineBoldEnd, n, nil)
+					lastBold = -1
+				}
+			case '`':
+				err = h.inline(inlineCodeStart, n, nil)
+				lastCode = n
+			case '[':
+				lastLink = n
+			default:
+				err = h.inline(inlineRune, n, st.encodeRune(r))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Check for any unclosed control characters and if so return an error
+	if lastBold > -1 {
+		return &SyntaxError{Problem: UnclosedBold, Position: lastBold}
+	}
+	if lastCode > -1 {
+		return &SyntaxError{Problem: UnclosedCode, Position: lastCode}
+	}
+	if lastLink > -1 {
+		return &SyntaxError{Problem: UnclosedLink, Position: lastLink}
+	}
+	if lastEscape > -1 {
+		if re.trailingBackslash != TrailingBackslashLiteral {
+			return &SyntaxError{Problem: UnclosedEscape, Position: lastEscape}
+		}
+		return h.inline(inlineRune, lastEscape, st.encodeRune('\\'))
+	}
+	return nil
+}
+
+// encodeRune returns r encoded as UTF-8 in st.runeBuffer
+func (st *renderState) encodeRune(r rune) []byte {
+	return st.runeBuffer[:utf8.EncodeRune(st.runeBuffer[:], r)]
+}
+
+// appendLinkRune adds r to the content of the current link
+func (st *renderState) appendLinkRune(r rune) {
+	st.link = append(st.link, st.encodeRune(r)...)
+}
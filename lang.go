@@ -0,0 +1,46 @@
+package rnzml
+
+// Direction is the direction of the text in a text block
+type Direction string
+
+const (
+	// DirectionAuto lets the browser choose the direction of each text block
+	// from its first strong character, for content mixing languages
+	DirectionAuto Direction = "auto"
+	DirectionLTR  Direction = "ltr"
+	DirectionRTL  Direction = "rtl"
+)
+
+// WithLanguage sets the lang attribute of text blocks to lang, a BCP 47
+// language tag such as he or en-NZ
+func WithLanguage(lang string) Option {
+	return func(re *Renderer) {
+		re.lang = lang
+		re.setTextBlockStart()
+	}
+}
+
+// WithDirection sets the dir attribute of text blocks to dir. Code blocks are
+// rendered left to right whichever direction is set.
+func WithDirection(dir Direction) Option {
+	return func(re *Renderer) {
+		re.dir = dir
+		re.setTextBlockStart()
+	}
+}
+
+// setTextBlockStart sets the tags starting text and code blocks to include the
+// language and direction of re
+func (re *Renderer) setTextBlockStart() {
+	start := []byte("<p")
+	if re.lang != "" {
+		start = append(appendHTMLEscaped(append(start, ` lang="`...), []byte(re.lang)), '"')
+	}
+	if re.dir != "" {
+		start = append(appendHTMLEscaped(append(start, ` dir="`...), []byte(re.dir)), '"')
+		re.codeBlockStart = []byte(`<pre dir="ltr"><code>`)
+	} else {
+		re.codeBlockStart = []byte(codeBlockStartString)
+	}
+	re.textBlockStart = append(start, '>')
+}
@@ -0,0 +1,37 @@
+package rnzml
+
+import (
+	"strings"
+	"testing"
+)
+
+var langtests = []struct {
+	name string
+	opts []Option
+	out  string
+}{
+	{"default", nil, "<p>a</p>\n<pre><code>b\n</code></pre>\n"},
+	{"language", []Option{WithLanguage("he")}, "<p lang=\"he\">a</p>\n<pre><code>b\n</code></pre>\n"},
+	{"direction", []Option{WithDirection(DirectionRTL)}, "<p dir=\"rtl\">a</p>\n<pre dir=\"ltr\"><code>b\n</code></pre>\n"},
+	{
+		"language and direction",
+		[]Option{WithDirection(DirectionAuto), WithLanguage("en-NZ")},
+		"<p lang=\"en-NZ\" dir=\"auto\">a</p>\n<pre dir=\"ltr\"><code>b\n</code></pre>\n",
+	},
+	{"escaped language", []Option{WithLanguage(`"><script>`)}, "<p lang=\"&#34;&gt;&lt;script&gt;\">a</p>\n<pre><code>b\n</code></pre>\n"},
+}
+
+func TestLanguage(t *testing.T) {
+	for _, tt := range langtests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithCanonicalOutput()}, tt.opts...)
+			out := &strings.Builder{}
+			if err := NewRenderer(opts...).Render(strings.NewReader("a\n```\nb\n```"), out); err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != out.String() {
+				t.Errorf("expected: %q got: %q", tt.out, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package rnzml
+
+import (
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// TokenKind is the kind of a Token
+type TokenKind int
+
+const (
+	// TokenBlankLine is a blank line outside of a code block
+	TokenBlankLine TokenKind = iota + 1
+	// TokenTextStart and TokenTextEnd surround the tokens of a text block
+	TokenTextStart
+	TokenTextEnd
+	// TokenText is text as written in the input, with invalid UTF-8 replaced
+	// by utf8.RuneError. A run of text may be split into several tokens.
+	TokenText
+	// TokenEscaped is a rune escaped with a \, its position is the position
+	// of the \ and its text is the rune
+	TokenEscaped
+	TokenBoldStart
+	TokenBoldEnd
+	TokenCodeStart
+	TokenCodeEnd
+	// TokenLink is a link, its position is the position of the [ and its text
+	// is the content between the [ and ] with escapes removed. The URL is the
+	// text up to the first space and the label is the rest, or the URL when
+	// there is no space.
+	TokenLink
+	// TokenCodeBlockStart and TokenCodeBlockEnd are the fences of a code
+	// block, their text is the fence when it is not ```, such as ````` or
+	// the ~~~ of a block of WithPreformattedBlocks
+	TokenCodeBlockStart
+	TokenCodeBlockEnd
+	// TokenCodeBlockLine is a line in a code block as written in the input
+	TokenCodeBlockLine
+	// TokenShortcode is a line calling a shortcode registered with
+	// WithShortcode, its text is the line
+	TokenShortcode
+	// TokenError is a malformed construct, returned by a Lexer created
+	// WithErrorRecovery. Its text is the character starting a malformed
+	// inline construct, which is lexed as text, and is empty for a malformed
+	// block.
+	TokenError
+)
+
+var tokenKindNames = [...]string{
+	TokenBlankLine:      "BlankLine",
+	TokenTextStart:      "TextStart",
+	TokenTextEnd:        "TextEnd",
+	TokenText:           "Text",
+	TokenEscaped:        "Escaped",
+	TokenBoldStart:      "BoldStart",
+	TokenBoldEnd:        "BoldEnd",
+	TokenCodeStart:      "CodeStart",
+	TokenCodeEnd:        "CodeEnd",
+	TokenLink:           "Link",
+	TokenCodeBlockStart: "CodeBlockStart",
+	TokenCodeBlockEnd:   "CodeBlockEnd",
+	TokenCodeBlockLine:  "CodeBlockLine",
+	TokenShortcode:      "Shortcode",
+	TokenError:          "Error",
+}
+
+func (k TokenKind) String() string {
+	if k > 0 && int(k) < len(tokenKindNames) {
+		return tokenKindNames[k]
+	}
+	return "TokenKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Token is a lexical token of rnzml input
+type Token struct {
+	Kind TokenKind
+	// Line is the line number of the block the token is in. Text blocks
+	// joined with TrailingBackslashJoin are one block starting on the line of
+	// their first line.
+	Line int
+	// Position is the byte offset of an inline token in its text block, where
+	// joined lines are separated by a newline, or a space with
+	// LineBreakSpace
+	Position int
+	Text     []byte
+	// Err is the problem of a TokenError
+	Err *SyntaxError
+}
+
+// Lexer splits rnzml input into tokens using the same rules as Render. Options
+// that change how input is read, such as WithTrailingBackslash,
+// WithBlankWhitespaceLines, WithNormalizer and the limits on the input, apply
+// to a Lexer as they do to a Renderer.
+type Lexer struct {
+	re     *Renderer
+	st     *renderState
+	blocks blockScanner
+	tokens []Token
+	// spans are the ranges of the text of tokens in data
+	spans [][2]int
+	data  []byte
+	next  int
+	err   error
+	// inline lexes the inline tokens of the current text block
+	inline lexerInline
+}
+
+// WithErrorRecovery makes a Lexer return a TokenError for each malformed
+// construct instead of failing, and lex the rest of the input as usual, so
+// linters and editors get the tokens of documents with mistakes. The
+// character starting an unclosed bold, code, link or escape, or the [ of a
+// link that is invalid, is a TokenError and the rest of its text block is lexed
+// after it. A code block that is not closed ends with a TokenCodeBlockEnd
+// after the last line. Errors reading the input and limit errors still end
+// lexing. Render is not affected.
+func WithErrorRecovery() Option {
+	return func(re *Renderer) {
+		re.recover = true
+	}
+}
+
+// NewLexer returns a Lexer reading from in
+func NewLexer(in io.Reader, opts ...Option) *Lexer {
+	re := NewRenderer(opts...)
+	st := getRenderState()
+	return &Lexer{re: re, st: st, blocks: re.newBlockScanner(in, st)}
+}
+
+// Next returns the next token. It returns io.EOF at the end of the input, or
+// the error Render returns for the input after the tokens before the error.
+// The text of a token is only valid until the next call to Next.
+func (l *Lexer) Next() (Token, error) {
+	for l.next == len(l.tokens) {
+		if l.err != nil {
+			return Token{}, l.err
+		}
+		l.lex()
+	}
+	t := l.tokens[l.next]
+	l.next++
+	return t, nil
+}
+
+// lex reads the tokens of the next block
+func (l *Lexer) lex() {
+	l.tokens, l.spans, l.data, l.next = l.tokens[:0], l.spans[:0], l.data[:0], 0
+	b, ok, err := l.blocks.next()
+	if err != nil && l.recoverBlock(err) {
+		l.setText()
+		return
+	}
+	if err != nil || !ok {
+		if err == nil {
+			err = io.EOF
+		}
+		l.fail(err)
+		return
+	}
+	switch b.kind {
+	case blockBlank:
+		l.add(TokenBlankLine, b.line, 0, nil)
+	case blockCodeStart, blockCodeEnd:
+		if b.kind == blockCodeEnd {
+			l.add(TokenCodeBlockEnd, b.line, 0, l.re.openingFence(b.content).text())
+		} else {
+			l.add(TokenCodeBlockStart, b.line, 0, l.re.openingFence(b.content).startText())
+		}
+	case blockCodeLine:
+		l.add(TokenCodeBlockLine, b.line, 0, b.content)
+	case blockShortcode:
+		if _, _, err := l.re.shortcode(b); err != nil {
+			if !l.recoverBlock(err) {
+				l.fail(err)
+			}
+			break
+		}
+		l.add(TokenShortcode, b.line, 0, b.content)
+	case blockText:
+		line := b.content
+		if l.re.normalize != nil {
+			line = []byte(l.re.normalize(string(line)))
+		}
+		l.add(TokenTextStart, b.line, 0, nil)
+		if err := l.lexText(line, b.line); err != nil {
+			l.fail(err)
+			break
+		}
+		l.add(TokenTextEnd, b.line, len(line), nil)
+	}
+	l.setText()
+}
+
+// setText sets the text of the tokens added to their span of data
+func (l *Lexer) setText() {
+	for i, span := range l.spans {
+		if span[1] > span[0] {
+			l.tokens[i].Text = l.data[span[0]:span[1]]
+		}
+	}
+}
+
+// lexText adds the inline tokens of the text block line. With error recovery
+// a malformed construct becomes a TokenError and the text after the character
+// starting it is lexed again.
+func (l *Lexer) lexText(line []byte, lineNumber int) error {
+	for offset := 0; ; {
+		start := len(l.tokens)
+		l.inline = lexerInline{l: l, line: lineNumber, offset: offset}
+		err := l.re.scanInline(l.st, line[offset:], lineNumber, &l.inline)
+		if err == nil {
+			return nil
+		}
+		syntaxErr, ok := err.(*SyntaxError)
+		if !ok || !l.re.recover {
+			return lineError(lineNumber, err)
+		}
+		// scanInline reports unclosed constructs at positions in the text it
+		// scans, lexerInline reports links at positions in the text block
+		position := syntaxErr.Position
+		if syntaxErr.Problem != MissingLinkURL && syntaxErr.Problem != VagueLinkLabel {
+			position += offset
+		}
+		// Tokens after the start of the construct were lexed inside it
+		for start < len(l.tokens) && l.tokens[start].Position < position {
+			start++
+		}
+		l.tokens, l.spans = l.tokens[:start], l.spans[:start]
+		syntaxErr.Line, syntaxErr.Position = lineNumber, position
+		_, size := utf8.DecodeRune(line[position:])
+		l.add(TokenError, lineNumber, position, line[position:position+size])
+		l.tokens[len(l.tokens)-1].Err = syntaxErr
+		if offset = position + size; offset == len(line) {
+			return nil
+		}
+	}
+}
+
+// recoverBlock adds a TokenError for err, an error reading a block, and
+// reports whether lexing continues
+func (l *Lexer) recoverBlock(err error) bool {
+	syntaxErr, ok := err.(*SyntaxError)
+	if !ok || !l.re.recover {
+		return false
+	}
+	l.add(TokenError, syntaxErr.Line, 0, nil)
+	l.tokens[len(l.tokens)-1].Err = syntaxErr
+	if !l.blocks.eof {
+		return true
+	}
+	// Close what is left open so the next problem is found
+	s := &l.blocks
+	switch syntaxErr.Problem {
+	case UnclosedCodeBlock, UnclosedPreformattedBlock:
+		text := s.fence.text()
+		s.codeBlockStartLine, s.fence = -1, fence{}
+		l.add(TokenCodeBlockEnd, s.lineCount+1, 0, text)
+	case UnclosedCondition:
+		s.conditions = s.conditions[:len(s.conditions)-1]
+	case UnclosedRegion:
+		s.regions = s.regions[:len(s.regions)-1]
+	}
+	return true
+}
+
+// fail ends lexing with err, which is returned after the tokens read so far
+func (l *Lexer) fail(err error) {
+	l.err = err
+	putRenderState(l.st)
+	l.st = nil
+}
+
+// add adds a token with a copy of text
+func (l *Lexer) add(kind TokenKind, line, position int, text []byte) {
+	start := len(l.data)
+	l.data = append(l.data, text...)
+	l.tokens = append(l.tokens, Token{Kind: kind, Line: line, Position: position})
+	l.spans = append(l.spans, [2]int{start, len(l.data)})
+}
+
+// lexerInline adds the inline tokens of a text block to a Lexer
+type lexerInline struct {
+	l    *Lexer
+	line int
+	// offset is the position in the text block of the text being lexed
+	offset int
+}
+
+var inlineTokenKinds = [...]TokenKind{
+	inlineText:      TokenText,
+	inlineRune:      TokenText,
+	inlineEscaped:   TokenEscaped,
+	inlineBoldStart: TokenBoldStart,
+	inlineBoldEnd:   TokenBoldEnd,
+	inlineCodeStart: TokenCodeStart,
+	inlineCodeEnd:   TokenCodeEnd,
+	inlineLink:      TokenLink,
+}
+
+func (h *lexerInline) inline(kind inlineKind, position int, text []byte) error {
+	position += h.offset
+	if kind == inlineLink {
+		rawURL, label := splitLink(text)
+		if len(rawURL) == 0 {
+			return &SyntaxError{Problem: MissingLinkURL, Position: position, Link: string(text)}
+		}
+		h.l.re.warnLink(h.l.st, h.line, position, string(rawURL), label)
+		if err := h.l.re.checkLinkLabel(h.l.st, h.line, position, rawURL, label); err != nil {
+			return err
+		}
+	}
+	h.l.add(inlineTokenKinds[kind], h.line, position, text)
+	return nil
+}
@@ -0,0 +1,150 @@
+package rnzml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// lexAll returns the tokens of in formatted as Kind@Line:Position"Text"
+func lexAll(in string, opts ...Option) (string, error) {
+	var tokens []string
+	l := NewLexer(strings.NewReader(in), opts...)
+	for {
+		t, err := l.Next()
+		if err == io.EOF {
+			return strings.Join(tokens, " "), nil
+		}
+		if err != nil {
+			return strings.Join(tokens, " "), err
+		}
+		tokens = append(tokens, fmt.Sprintf("%v@%d:%d%q", t.Kind, t.Line, t.Position, t.Text))
+	}
+}
+
+var lexertests = []struct {
+	in     string
+	opts   []Option
+	tokens string
+}{
+	{"", nil, ""},
+	{"a *b*", nil, `TextStart@1:0"" Text@1:0"a " BoldStart@1:2"" Text@1:3"b" BoldEnd@1:4"" TextEnd@1:5""`},
+	{"`a\\``", nil, `TextStart@1:0"" CodeStart@1:0"" Text@1:1"a" Escaped@1:2"` + "`" + `" CodeEnd@1:4"" TextEnd@1:5""`},
+	{"a<\n\n[/x a\\]b]", nil, `TextStart@1:0"" Text@1:0"a" Text@1:1"<" TextEnd@1:2"" BlankLine@2:0"" TextStart@3:0"" Link@3:0"/x a]b" TextEnd@3:9""`},
+	{"```\n\tx\n```", []Option{WithTabWidth(2)}, `CodeBlockStart@1:0"" CodeBlockLine@2:0"\tx" CodeBlockEnd@3:0""`},
+	{"a\\\nb", []Option{WithTrailingBackslash(TrailingBackslashJoin)}, `TextStart@1:0"" Text@1:0"a\nb" TextEnd@1:3""`},
+	{"{{< youtube a >}}", []Option{WithShortcode("youtube", youtube)}, `Shortcode@1:0"{{< youtube a >}}"`},
+	{"a\\", []Option{WithTrailingBackslash(TrailingBackslashLiteral)}, `TextStart@1:0"" Text@1:0"a" Text@1:1"\\" TextEnd@1:2""`},
+}
+
+func TestLexer(t *testing.T) {
+	for _, tt := range lexertests {
+		t.Run(tt.in, func(t *testing.T) {
+			tokens, err := lexAll(tt.in, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.tokens != tokens {
+				t.Errorf("expected: %s got: %s", tt.tokens, tokens)
+			}
+		})
+	}
+	t.Run("Should return the errors Render returns", func(t *testing.T) {
+		for _, in := range []string{"a\n*b", "```\na", "[ a]", "a\\", "a\n\n\n", "{{< b >}}"} {
+			opts := []Option{WithMaxLines(2), WithShortcode("youtube", youtube)}
+			renderErr := NewRenderer(opts...).Render(strings.NewReader(in), io.Discard)
+			_, lexErr := lexAll(in, opts...)
+			if fmt.Sprint(renderErr) != fmt.Sprint(lexErr) {
+				t.Errorf("expected: '%v' got: '%v'", renderErr, lexErr)
+			}
+		}
+	})
+	t.Run("Should return the tokens before an error", func(t *testing.T) {
+		tokens, err := lexAll("a\n`b *c")
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || syntaxErr.Problem != UnclosedCode {
+			t.Fatalf("expected *SyntaxError got: '%v'", err)
+		}
+		expected := `TextStart@1:0"" Text@1:0"a" TextEnd@1:1"" TextStart@2:0"" CodeStart@2:0"" Text@2:1"b *c"`
+		if expected != tokens {
+			t.Errorf("expected: %s got: %s", expected, tokens)
+		}
+	})
+	t.Run("Should return an error after the last token", func(t *testing.T) {
+		l := NewLexer(strings.NewReader("a"))
+		for i := 0; i < 3; i++ {
+			l.Next()
+		}
+		if _, err := l.Next(); err != io.EOF {
+			t.Errorf("expected: '%v' got: '%v'", io.EOF, err)
+		}
+	})
+}
+
+var recoverytests = []struct {
+	in     string
+	opts   []Option
+	tokens string
+}{
+	{"a *b", nil, `TextStart@1:0"" Text@1:0"a " Error@1:2"*" Text@1:3"b" TextEnd@1:4""`},
+	{"*a `b* c", nil, `TextStart@1:0"" Error@1:0"*" Text@1:1"a " Error@1:3"` + "`" + `" Text@1:4"b" Error@1:5"*" Text@1:6" c" TextEnd@1:8""`},
+	{"[ a] *b*\\", nil, `TextStart@1:0"" Error@1:0"[" Text@1:1" a] " BoldStart@1:5"" Text@1:6"b" BoldEnd@1:7"" Error@1:8"\\" TextEnd@1:9""`},
+	{"[a\n\nb", nil, `TextStart@1:0"" Error@1:0"[" Text@1:1"a" TextEnd@1:2"" BlankLine@2:0"" TextStart@3:0"" Text@3:0"b" TextEnd@3:1""`},
+	{"```\na", nil, `CodeBlockStart@1:0"" CodeBlockLine@2:0"a" Error@1:0"" CodeBlockEnd@3:0""`},
+	{"{{< b >}}\na", []Option{WithShortcode("youtube", youtube)}, `Error@1:0"" TextStart@2:0"" Text@2:0"a" TextEnd@2:1""`},
+	{"!endif\n!if profile=a\na", []Option{WithProfiles("a")}, `Error@1:0"" TextStart@3:0"" Text@3:0"a" TextEnd@3:1"" Error@2:0""`},
+	{"a [/a here]", []Option{WithAccessibilityChecks(AccessibilityError)}, `TextStart@1:0"" Text@1:0"a " Error@1:2"[" Text@1:3"/a here]" TextEnd@1:11""`},
+}
+
+func TestErrorRecovery(t *testing.T) {
+	for _, tt := range recoverytests {
+		t.Run(tt.in, func(t *testing.T) {
+			tokens, err := lexAll(tt.in, append(tt.opts, WithErrorRecovery())...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.tokens != tokens {
+				t.Errorf("expected: %s got: %s", tt.tokens, tokens)
+			}
+		})
+	}
+	t.Run("Should set the error of error tokens", func(t *testing.T) {
+		l := NewLexer(strings.NewReader("a\nb `c"), WithErrorRecovery())
+		var errs []string
+		for {
+			tok, err := l.Next()
+			if err != nil {
+				break
+			}
+			if tok.Kind == TokenError {
+				errs = append(errs, tok.Err.Error())
+			}
+		}
+		expected := "line 2: unclosed code text (`) at position: 2"
+		if strings.Join(errs, ", ") != expected {
+			t.Errorf("expected: %s got: %s", expected, strings.Join(errs, ", "))
+		}
+	})
+	t.Run("Should return limit errors", func(t *testing.T) {
+		_, err := lexAll("a\nb\nc", WithErrorRecovery(), WithMaxLines(2))
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) {
+			t.Errorf("expected *LimitError got: '%v'", err)
+		}
+	})
+}
+
+func BenchmarkLexer(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(largeDocument)))
+	for i := 0; i < b.N; i++ {
+		l := NewLexer(strings.NewReader(largeDocument))
+		for {
+			if _, err := l.Next(); err != nil {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package rnzml
+
+import (
+	"fmt"
+	"io"
+)
+
+// Limit names a limit that can be configured on a Renderer
+type Limit string
+
+const (
+	// LimitInputBytes is the maximum number of bytes read from the input
+	LimitInputBytes Limit = "input bytes"
+	// LimitLines is the maximum number of lines read from the input
+	LimitLines Limit = "lines"
+	// LimitOutputBytes is the maximum number of bytes written to the output
+	LimitOutputBytes Limit = "output bytes"
+)
+
+// LimitError is returned when rendering exceeds a limit configured on the
+// Renderer
+type LimitError struct {
+	Limit Limit
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("exceeded limit of %d %s", e.Max, e.Limit)
+}
+
+// WithMaxInputBytes fails rendering with a *LimitError once more than n bytes
+// have been read from the input
+func WithMaxInputBytes(n int) Option {
+	return func(re *Renderer) {
+		re.maxInputBytes = n
+	}
+}
+
+// WithMaxLines fails rendering with a *LimitError once more than n lines have
+// been read from the input
+func WithMaxLines(n int) Option {
+	return func(re *Renderer) {
+		re.maxLines = n
+	}
+}
+
+// WithMaxOutputBytes fails rendering with a *LimitError instead of writing
+// more than n bytes to the output
+func WithMaxOutputBytes(n int) Option {
+	return func(re *Renderer) {
+		re.maxOutputBytes = n
+	}
+}
+
+// limitedReader reads from r until more than max bytes have been read, then
+// returns a *LimitError
+type limitedReader struct {
+	r         io.Reader
+	remaining int
+	max       int
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	// Read one byte past the limit to tell an input of exactly max bytes
+	// apart from a longer one
+	if len(p) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if n > l.remaining {
+		n = l.remaining
+		l.remaining = 0
+		return n, &LimitError{Limit: LimitInputBytes, Max: l.max}
+	}
+	l.remaining -= n
+	return n, err
+}
+
+// limitedWriter writes to w until max bytes have been written. Once a write
+// would exceed max, the bytes that fit are written and it and every later
+// write return a *LimitError.
+type limitedWriter struct {
+	w         io.Writer
+	remaining int
+	max       int
+	err       error
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	if len(p) <= l.remaining {
+		n, err := l.w.Write(p)
+		l.remaining -= n
+		return n, err
+	}
+	n, err := l.w.Write(p[:l.remaining])
+	l.remaining -= n
+	if err != nil {
+		return n, err
+	}
+	l.err = &LimitError{Limit: LimitOutputBytes, Max: l.max}
+	return n, l.err
+}
@@ -0,0 +1,70 @@
+package rnzml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var limittests = []struct {
+	in    string
+	opt   Option
+	limit Limit
+}{
+	{"abc", WithMaxInputBytes(3), ""},
+	{"abcd", WithMaxInputBytes(3), LimitInputBytes},
+	{"a\nb\nc\n", WithMaxInputBytes(5), LimitInputBytes},
+	{"a\nb\nc\n", WithMaxInputBytes(6), ""},
+	{"a\nb\nc", WithMaxLines(3), ""},
+	{"a\nb\nc\nd", WithMaxLines(3), LimitLines},
+	{"a\n\n\n\n", WithMaxLines(3), LimitLines},
+	{"a", WithMaxOutputBytes(10), ""},
+	{"ab", WithMaxOutputBytes(10), LimitOutputBytes},
+	{"```\na\n```", WithMaxOutputBytes(27), ""},
+	{"```\na\n```", WithMaxOutputBytes(26), LimitOutputBytes},
+}
+
+func TestLimits(t *testing.T) {
+	for _, tt := range limittests {
+		t.Run(tt.in, func(t *testing.T) {
+			r := NewRenderer(tt.opt)
+			out := &strings.Builder{}
+			err := r.Render(strings.NewReader(tt.in), out)
+			var limitErr *LimitError
+			if tt.limit == "" {
+				if err != nil {
+					t.Errorf("error: %s", err.Error())
+				}
+			} else if !errors.As(err, &limitErr) {
+				t.Errorf("expected *LimitError got: '%v'", err)
+			} else if limitErr.Limit != tt.limit {
+				t.Errorf("expected: '%s' got: '%s'", tt.limit, limitErr.Limit)
+			}
+		})
+	}
+	t.Run("Should describe the exceeded limit", func(t *testing.T) {
+		r := NewRenderer(WithMaxLines(1))
+		out := &strings.Builder{}
+		expected := "exceeded limit of 1 lines"
+		err := r.Render(strings.NewReader("a\nb"), out)
+		if err == nil {
+			t.Error("expected error")
+		} else if expected != err.Error() {
+			t.Errorf("expected: '%s' got: '%s'", expected, err.Error())
+		}
+	})
+}
+
+func TestMaxOutputBytes(t *testing.T) {
+	t.Run("Should not write past the limit", func(t *testing.T) {
+		r := NewRenderer(WithMaxOutputBytes(5))
+		out := &strings.Builder{}
+		expected := "<p>ab"
+		err := r.Render(strings.NewReader("abcdef\nghi"), out)
+		if err == nil {
+			t.Error("expected error")
+		} else if expected != out.String() {
+			t.Errorf("expected: '%s' got: '%s'", expected, out.String())
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package rnzml
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Tags around a line of a code block with WithLineNumbers
+const (
+	lineNumberStart = `<span data-line-number="`
+	lineNumberEnd   = "</span>"
+)
+
+// WithLineNumbers renders each line of a code block or preformatted block in
+// a <span> with its number in a data-line-number attribute, for stylesheets
+// to show in a gutter and tutorials to refer to. Lines are numbered from 1,
+// or from the number following the fence starting the block after a space:
+// lines after ``` 12 are numbered from 12. Without WithLineNumbers a fence
+// followed by a number is text.
+func WithLineNumbers() Option {
+	return func(re *Renderer) {
+		re.lineNumbers = true
+	}
+}
+
+// fenceNumber splits a line that may start a block into the fence and the
+// number following it, which is 0 when there is none
+func fenceNumber(line []byte) ([]byte, int) {
+	i := bytes.IndexByte(line, ' ')
+	if i == -1 || i == len(line)-1 || line[i+1] == '0' {
+		return line, 0
+	}
+	for _, c := range line[i+1:] {
+		if c < '0' || c > '9' {
+			return line, 0
+		}
+	}
+	n, err := strconv.Atoi(string(line[i+1:]))
+	if err != nil {
+		return line, 0
+	}
+	return line[:i], n
+}
+
+// appendLineNumber appends the start tag of line n of a code block to b
+func appendLineNumber(b []byte, n int) []byte {
+	b = strconv.AppendInt(append(b, lineNumberStart...), int64(n), 10)
+	return append(b, `">`...)
+}
@@ -0,0 +1,70 @@
+package rnzml
+
+import (
+	"strings"
+	"testing"
+)
+
+var linenumbertests = []struct {
+	in   string
+	opts []Option
+	out  string
+}{
+	{"```\na\n\n<b>\n```", nil, "<pre><code><span data-line-number=\"1\">a</span>\n<span data-line-number=\"2\"></span>\n<span data-line-number=\"3\">&lt;b&gt;</span>\n</code></pre>\n"},
+	{"``` 12\na\nb\n```", nil, "<pre><code><span data-line-number=\"12\">a</span>\n<span data-line-number=\"13\">b</span>\n</code></pre>\n"},
+	{"```` 3\n``` 4\n````\n```\nc\n```", nil, "<pre><code><span data-line-number=\"3\">``` 4</span>\n</code></pre>\n<pre><code><span data-line-number=\"1\">c</span>\n</code></pre>\n"},
+	{"~~~ 5\na\n~~~", []Option{WithPreformattedBlocks()}, "<pre><span data-line-number=\"5\">a</span>\n</pre>\n"},
+	{"a\n``` 2\n```", nil, "<p>a\n</p>\n<pre><code></code></pre>\n"},
+	{"~~~ 0\n\n~~~ a\n\n~~~1", []Option{WithPreformattedBlocks()}, "<p>~~~ 0\n</p>\n\n<p>~~~ a\n</p>\n\n<p>~~~1\n</p>\n"},
+}
+
+func TestLineNumbers(t *testing.T) {
+	for _, tt := range linenumbertests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := NewRenderer(append([]Option{WithLineNumbers()}, tt.opts...)...).RenderToBytes([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != string(out) {
+				t.Errorf("expected: %q got: %q", tt.out, out)
+			}
+		})
+	}
+	t.Run("Should render a fence followed by a number as text without the option", func(t *testing.T) {
+		out, err := NewRenderer(WithPreformattedBlocks()).RenderToBytes([]byte("~~~ 12"))
+		if err != nil || string(out) != "<p>~~~ 12\n</p>\n" {
+			t.Errorf("expected text got: %q %v", out, err)
+		}
+	})
+	t.Run("Should lex the number with the fence", func(t *testing.T) {
+		tokens, err := lexAll("``` 12\na\n```", WithLineNumbers())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "CodeBlockStart@1:0\"``` 12\" CodeBlockLine@2:0\"a\" CodeBlockEnd@3:0\"\""; tokens != expected {
+			t.Errorf("expected: %s got: %s", expected, tokens)
+		}
+	})
+	t.Run("Should write the number with a TokenWriter", func(t *testing.T) {
+		in := "``` 12\n```\n```` 3\n````\n"
+		l := NewLexer(strings.NewReader(in), WithLineNumbers())
+		var out strings.Builder
+		w := NewTokenWriter(&out)
+		for {
+			tok, err := l.Next()
+			if err != nil {
+				break
+			}
+			if err := w.Write(tok); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if out.String() != in {
+			t.Errorf("expected: %q got: %q", in, out.String())
+		}
+		w = NewTokenWriter(&out)
+		if err := w.Write(Token{Kind: TokenCodeBlockStart, Line: 1, Text: []byte(" 12")}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
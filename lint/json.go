@@ -0,0 +1,75 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Diagnostic is an Issue of a file in the form written as JSON for build
+// systems and review bots
+type Diagnostic struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// Column is the byte position of the issue counted from 1, or 0 for
+	// issues of a whole document
+	Column   int      `json:"column"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Diagnostics returns the diagnostics of the issues found in file
+func Diagnostics(file string, issues []Issue) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0, len(issues))
+	for _, issue := range issues {
+		d := Diagnostic{File: file, Line: issue.Line, Rule: issue.Rule, Severity: issue.Severity, Message: issue.Message}
+		if issue.Line > 0 {
+			d.Column = issue.Position + 1
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// WriteJSON writes diagnostics to w as a JSON array, one diagnostic per line
+func WriteJSON(w io.Writer, diagnostics []Diagnostic) error {
+	if len(diagnostics) == 0 {
+		_, err := io.WriteString(w, "[]\n")
+		return err
+	}
+	for i, d := range diagnostics {
+		b, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		sep := ",\n"
+		if i == 0 {
+			sep = "[\n"
+		}
+		if _, err := fmt.Fprintf(w, "%s  %s", sep, b); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "\n]\n")
+	return err
+}
+
+// MarshalText returns the name of s, such as "warning"
+func (s Severity) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(severityNames) {
+		return nil, fmt.Errorf("lint: invalid severity %d", int(s))
+	}
+	return []byte(severityNames[s]), nil
+}
+
+// UnmarshalText sets s to the severity named by text
+func (s *Severity) UnmarshalText(text []byte) error {
+	for i, name := range severityNames {
+		if name == string(text) {
+			*s = Severity(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("lint: unknown severity %q", text)
+}
@@ -0,0 +1,53 @@
+package lint
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	t.Run("Should write the diagnostics of issues", func(t *testing.T) {
+		issues, err := New().Lint(strings.NewReader("a \n*b"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		if err := WriteJSON(&b, Diagnostics("doc.rnzml", issues)); err != nil {
+			t.Fatal(err)
+		}
+		expected := `[
+  {"file":"doc.rnzml","line":1,"column":2,"rule":"trailing-whitespace","severity":"warning","message":"trailing whitespace"},
+  {"file":"doc.rnzml","line":2,"column":1,"rule":"syntax","severity":"error","message":"line 2: unclosed bold text (*) at position: 0"}
+]
+`
+		if b.String() != expected {
+			t.Errorf("expected: %s got: %s", expected, b.String())
+		}
+		var decoded []Diagnostic
+		if err := json.Unmarshal([]byte(b.String()), &decoded); err != nil || len(decoded) != 2 || decoded[1].Severity != Error {
+			t.Errorf("expected diagnostics to decode got: %v %v", decoded, err)
+		}
+	})
+	t.Run("Should write an empty array", func(t *testing.T) {
+		var b strings.Builder
+		if err := WriteJSON(&b, Diagnostics("doc.rnzml", nil)); err != nil || b.String() != "[]\n" {
+			t.Errorf("expected: %q got: %q %v", "[]\n", b.String(), err)
+		}
+	})
+	t.Run("Should not report a column for issues of a whole document", func(t *testing.T) {
+		d := Diagnostics("doc.rnzml", []Issue{{Rule: SyntaxRule, Severity: Error, Message: "limit"}})
+		if d[0].Column != 0 {
+			t.Errorf("expected column: 0 got: %d", d[0].Column)
+		}
+	})
+	t.Run("Should reject unknown severities", func(t *testing.T) {
+		var s Severity
+		if err := s.UnmarshalText([]byte("fatal")); err == nil {
+			t.Error("expected error")
+		}
+		if _, err := Severity(9).MarshalText(); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
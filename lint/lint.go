@@ -0,0 +1,194 @@
+// Package lint checks rnzml documents for problems that are not syntax errors,
+// such as links that do not describe where they go, for the command line and
+// editor integrations.
+//
+// A Linter runs a set of Rules over the tokens and lines of a document. Each
+// Rule has a name and reports Issues, which are given the Severity configured
+// for the rule.
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+// Severity is how serious an Issue is
+type Severity int
+
+const (
+	// Off disables a rule
+	Off Severity = iota
+	Info
+	Warning
+	Error
+)
+
+var severityNames = [...]string{Off: "off", Info: "info", Warning: "warning", Error: "error"}
+
+func (s Severity) String() string {
+	if s >= 0 && int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// SyntaxRule is the name of the Issue reported for a document rnzml cannot
+// render, it is always an Error
+const SyntaxRule = "syntax"
+
+// Issue is a problem found by a Rule
+type Issue struct {
+	Rule     string
+	Severity Severity
+	// Line and Position are the line and byte position the problem starts at
+	Line     int
+	Position int
+	Message  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", i.Line, i.Position, i.Severity, i.Message, i.Rule)
+}
+
+// Document is a document being linted
+type Document struct {
+	// Lines are the lines of the source without line endings
+	Lines [][]byte
+	// Tokens are the tokens of the document, the tokens before the error of
+	// a document that cannot be rendered
+	Tokens []rnzml.Token
+}
+
+// Rule checks a Document, calling report with each problem it finds
+type Rule interface {
+	Name() string
+	Check(doc *Document, report func(line, position int, message string))
+}
+
+// Option configures a Linter
+type Option func(*Linter)
+
+// Linter checks documents with a set of rules
+type Linter struct {
+	rules      []Rule
+	severities map[string]Severity
+	opts       []rnzml.Option
+}
+
+// WithRule adds rule with severity, replacing a rule with the same name
+func WithRule(rule Rule, severity Severity) Option {
+	return func(l *Linter) {
+		for i, r := range l.rules {
+			if r.Name() == rule.Name() {
+				l.rules = append(l.rules[:i], l.rules[i+1:]...)
+				break
+			}
+		}
+		l.rules = append(l.rules, rule)
+		l.severities[rule.Name()] = severity
+	}
+}
+
+// WithSeverity sets the severity of the rule called name, Off disables it
+func WithSeverity(name string, severity Severity) Option {
+	return func(l *Linter) {
+		l.severities[name] = severity
+	}
+}
+
+// WithRenderOptions sets the options documents are read with, such as
+// rnzml.WithTrailingBackslash
+func WithRenderOptions(opts ...rnzml.Option) Option {
+	return func(l *Linter) {
+		l.opts = opts
+	}
+}
+
+// RenderOptions returns the options documents are read with
+func (l *Linter) RenderOptions() []rnzml.Option {
+	return l.opts
+}
+
+// New returns a Linter with the built-in rules configured by opts. The rules
+// and their severities are:
+//
+//   - link-label (Warning): link labels that do not describe the link
+//   - line-length (Info): lines longer than 120 characters
+//   - trailing-whitespace (Warning): whitespace at the end of text lines
+func New(opts ...Option) *Linter {
+	l := &Linter{severities: map[string]Severity{}}
+	WithRule(LinkLabel(), Warning)(l)
+	WithRule(LineLength(120), Info)(l)
+	WithRule(TrailingWhitespace(), Warning)(l)
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Lint reads the document in and returns the issues found in it in order. The
+// document is lexed with rnzml.WithErrorRecovery, each syntax error is reported
+// as an Issue of SyntaxRule and the rules check the rest of the document.
+// Other errors lexing, such as limit errors, end the check at the error and
+// are reported the same way. Errors reading in are returned.
+func (l *Linter) Lint(in io.Reader) ([]Issue, error) {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{Lines: bytes.Split(src, []byte("\n"))}
+	for i, line := range doc.Lines {
+		doc.Lines[i] = bytes.TrimSuffix(line, []byte("\r"))
+	}
+	if len(src) > 0 && src[len(src)-1] == '\n' || len(src) == 0 {
+		doc.Lines = doc.Lines[:len(doc.Lines)-1]
+	}
+
+	var issues []Issue
+	opts := append(append([]rnzml.Option(nil), l.opts...), rnzml.WithErrorRecovery())
+	lexer := rnzml.NewLexer(bytes.NewReader(src), opts...)
+	for {
+		t, err := lexer.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			issues = append(issues, syntaxIssue(err))
+			break
+		}
+		if t.Kind == rnzml.TokenError {
+			issues = append(issues, syntaxIssue(t.Err))
+		}
+		t.Text = append([]byte(nil), t.Text...)
+		doc.Tokens = append(doc.Tokens, t)
+	}
+	for _, rule := range l.rules {
+		severity := l.severities[rule.Name()]
+		if severity == Off {
+			continue
+		}
+		rule.Check(doc, func(line, position int, message string) {
+			issues = append(issues, Issue{Rule: rule.Name(), Severity: severity, Line: line, Position: position, Message: message})
+		})
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Position < b.Position
+	})
+	return issues, nil
+}
+
+// syntaxIssue returns the Issue for an error rendering a document
+func syntaxIssue(err error) Issue {
+	issue := Issue{Rule: SyntaxRule, Severity: Error, Message: err.Error()}
+	var syntaxErr *rnzml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		issue.Line, issue.Position = syntaxErr.Line, syntaxErr.Position
+	}
+	return issue
+}
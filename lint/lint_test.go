@@ -0,0 +1,76 @@
+package lint
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+var linttests = []struct {
+	in     string
+	opts   []Option
+	issues []string
+}{
+	{"a [https://res.nz The res.nz website]\n", nil, nil},
+	{"a \n[/a here]\r\n", nil, []string{
+		"1:1: warning: trailing whitespace (trailing-whitespace)",
+		`2:0: warning: link label "here" does not describe the link (link-label)`,
+	}},
+	{strings.Repeat("a", 121), nil, []string{"1:0: info: line is 121 characters long, more than 120 (line-length)"}},
+	{"a \n[/a here]", []Option{WithSeverity("trailing-whitespace", Off), WithSeverity("link-label", Error)}, []string{
+		`2:0: error: link label "here" does not describe the link (link-label)`,
+	}},
+	{"abc", []Option{WithRule(LineLength(2), Error)}, []string{"1:0: error: line is 3 characters long, more than 2 (line-length)"}},
+	{"[/a here] \na *b", nil, []string{
+		`1:0: warning: link label "here" does not describe the link (link-label)`,
+		"1:9: warning: trailing whitespace (trailing-whitespace)",
+		"2:2: error: line 2: unclosed bold text (*) at position: 2 (syntax)",
+	}},
+	{"*a\n`b [/c here]\n```", nil, []string{
+		"1:0: error: line 1: unclosed bold text (*) at position: 0 (syntax)",
+		"2:0: error: line 2: unclosed code text (`) at position: 0 (syntax)",
+		`2:3: warning: link label "here" does not describe the link (link-label)`,
+		"3:0: error: unclosed code block (```) on line: 3 (syntax)",
+	}},
+	{"a\nb", []Option{WithRenderOptions(rnzml.WithMaxLines(1))}, []string{"0:0: error: exceeded limit of 1 lines (syntax)"}},
+	{"a\\", []Option{WithRenderOptions(rnzml.WithTrailingBackslash(rnzml.TrailingBackslashLiteral))}, nil},
+}
+
+func TestLint(t *testing.T) {
+	for _, tt := range linttests {
+		t.Run(tt.in, func(t *testing.T) {
+			issues, err := New(tt.opts...).Lint(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.String())
+			}
+			if strings.Join(tt.issues, "\n") != strings.Join(got, "\n") {
+				t.Errorf("expected: '%v' got: '%v'", tt.issues, got)
+			}
+		})
+	}
+	t.Run("Should return read errors", func(t *testing.T) {
+		fail := errors.New("fail")
+		if _, err := New().Lint(failingReader{fail}); err != fail {
+			t.Errorf("expected: '%v' got: '%v'", fail, err)
+		}
+	})
+	t.Run("Should name severities", func(t *testing.T) {
+		if s := Severity(7).String(); s != "Severity(7)" {
+			t.Errorf("expected: 'Severity(7)' got: '%s'", s)
+		}
+	})
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,83 @@
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+// rule is a Rule implemented by a func
+type rule struct {
+	name  string
+	check func(doc *Document, report func(line, position int, message string))
+}
+
+func (r rule) Name() string {
+	return r.name
+}
+
+func (r rule) Check(doc *Document, report func(line, position int, message string)) {
+	r.check(doc, report)
+}
+
+// LinkLabel returns the link-label rule, which reports links labelled with
+// their URL, with no label or with a label such as "click here" that does not
+// describe where the link goes, as rnzml.DescribesLink checks
+func LinkLabel() Rule {
+	return rule{name: "link-label", check: func(doc *Document, report func(line, position int, message string)) {
+		for _, t := range doc.Tokens {
+			if t.Kind != rnzml.TokenLink {
+				continue
+			}
+			rawURL, label := splitLink(t.Text)
+			if !rnzml.DescribesLink(string(rawURL), string(label)) {
+				report(t.Line, t.Position, fmt.Sprintf("link label %q does not describe the link", label))
+			}
+		}
+	}}
+}
+
+// splitLink splits the text of a link token into its URL and label
+func splitLink(text []byte) (rawURL, label []byte) {
+	if i := bytes.IndexByte(text, ' '); i != -1 {
+		return text[:i], text[i+1:]
+	}
+	return text, text
+}
+
+// LineLength returns the line-length rule, which reports lines longer than max
+// characters
+func LineLength(max int) Rule {
+	return rule{name: "line-length", check: func(doc *Document, report func(line, position int, message string)) {
+		for i, line := range doc.Lines {
+			if n := utf8.RuneCount(line); n > max {
+				report(i+1, 0, fmt.Sprintf("line is %d characters long, more than %d", n, max))
+			}
+		}
+	}}
+}
+
+// TrailingWhitespace returns the trailing-whitespace rule, which reports
+// spaces and tabs at the end of lines outside of code blocks
+func TrailingWhitespace() Rule {
+	return rule{name: "trailing-whitespace", check: func(doc *Document, report func(line, position int, message string)) {
+		code := map[int]bool{}
+		for _, t := range doc.Tokens {
+			if t.Kind == rnzml.TokenCodeBlockLine {
+				code[t.Line] = true
+			}
+		}
+		for i, line := range doc.Lines {
+			start := len(bytes.TrimRight(line, " \t"))
+			if backslashes := start - len(bytes.TrimRight(line[:start], "\\")); backslashes%2 == 1 {
+				// An escaped space is kept
+				start++
+			}
+			if start < len(line) && !code[i+1] {
+				report(i+1, start, "trailing whitespace")
+			}
+		}
+	}}
+}
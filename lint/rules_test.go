@@ -0,0 +1,50 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+var ruletests = []struct {
+	rule   Rule
+	in     string
+	issues []string
+}{
+	{LinkLabel(), "[https://res.nz The res.nz website]", nil},
+	{LinkLabel(), "a [https://res.nz click here]\n[https://res.nz]\n[/a ]", []string{
+		`1:2: warning: link label "click here" does not describe the link (link-label)`,
+		`2:0: warning: link label "https://res.nz" does not describe the link (link-label)`,
+		`3:0: warning: link label "" does not describe the link (link-label)`,
+	}},
+	{LineLength(5), "abcde\nabcdef\nāāāāā\n```\nabcdefg\n```", []string{
+		"2:0: warning: line is 6 characters long, more than 5 (line-length)",
+		"5:0: warning: line is 7 characters long, more than 5 (line-length)",
+	}},
+	{TrailingWhitespace(), "a \nb\t\n  \nc\\ \nd\\  \ne\\\\ \n```\nf \n```", []string{
+		"1:1: warning: trailing whitespace (trailing-whitespace)",
+		"2:1: warning: trailing whitespace (trailing-whitespace)",
+		"3:0: warning: trailing whitespace (trailing-whitespace)",
+		"5:3: warning: trailing whitespace (trailing-whitespace)",
+		"6:3: warning: trailing whitespace (trailing-whitespace)",
+	}},
+}
+
+func TestRules(t *testing.T) {
+	for _, tt := range ruletests {
+		t.Run(tt.rule.Name()+" "+tt.in, func(t *testing.T) {
+			l := &Linter{severities: map[string]Severity{}}
+			WithRule(tt.rule, Warning)(l)
+			issues, err := l.Lint(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.String())
+			}
+			if strings.Join(tt.issues, "\n") != strings.Join(got, "\n") {
+				t.Errorf("expected: '%v' got: '%v'", tt.issues, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,425 @@
+// Package lsp is a Language Server Protocol server for rnzml documents, so
+// editors get diagnostics, link hovers and formatting from the same parser and
+// linter as the renderer.
+//
+// The server reads JSON-RPC messages framed with Content-Length headers, as
+// editors send them to a language server over stdin, and keeps the full text
+// of each open document. Diagnostics are published when a document is opened
+// or changed, and are the issues the linter finds, including syntax errors.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/Resonance1584/rnzml"
+	"github.com/Resonance1584/rnzml/lint"
+)
+
+// JSON-RPC error codes
+const (
+	parseError     = -32700
+	invalidRequest = -32600
+	methodNotFound = -32601
+	invalidParams  = -32602
+)
+
+// maxContentLength is the largest message the server reads
+const maxContentLength = 64 << 20
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithLinter sets the linter diagnostics are found with, lint.New() by default
+func WithLinter(l *lint.Linter) Option {
+	return func(s *Server) {
+		s.linter = l
+	}
+}
+
+// Server is a language server for rnzml documents
+type Server struct {
+	linter    *lint.Linter
+	documents map[string]string
+	out       io.Writer
+	// err is the first error writing to out
+	err      error
+	shutdown bool
+}
+
+// NewServer returns a Server configured with opts
+func NewServer(opts ...Option) *Server {
+	s := &Server{linter: lint.New(), documents: map[string]string{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// message is a JSON-RPC request, notification or response
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve handles the messages read from in, writing responses and
+// notifications to out, until the exit notification or the end of in
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	s.out = out
+	r := textproto.NewReader(bufio.NewReader(in))
+	for {
+		header, err := r.ReadMIMEHeader()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil {
+			return fmt.Errorf("invalid Content-Length: %w", err)
+		}
+		if length < 0 || length > maxContentLength {
+			return fmt.Errorf("invalid Content-Length: %d", length)
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r.R, body); err != nil {
+			return err
+		}
+		var m message
+		if err := json.Unmarshal(body, &m); err != nil {
+			if err := s.write(message{Error: &responseError{Code: parseError, Message: err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if m.Method == "exit" {
+			return nil
+		}
+		result, rerr := s.handle(m.Method, m.Params)
+		if s.err != nil {
+			return s.err
+		}
+		if m.ID == nil {
+			// Notifications have no response
+			continue
+		}
+		response := message{ID: m.ID, Result: result, Error: rerr}
+		if result == nil && rerr == nil {
+			response.Result = json.RawMessage("null")
+		}
+		if err := s.write(response); err != nil {
+			return err
+		}
+	}
+}
+
+// write writes a message to the client
+func (s *Server) write(m message) error {
+	m.JSONRPC = "2.0"
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil && s.err == nil {
+		s.err = err
+	}
+	return err
+}
+
+type textDocument struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type textRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type positionParams struct {
+	TextDocument textDocument `json:"textDocument"`
+	Position     position     `json:"position"`
+}
+
+type changeParams struct {
+	TextDocument   textDocument `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type diagnostic struct {
+	Range    textRange `json:"range"`
+	Severity int       `json:"severity"`
+	Code     string    `json:"code"`
+	Source   string    `json:"source"`
+	Message  string    `json:"message"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    textRange     `json:"range"`
+}
+
+type textEdit struct {
+	Range   textRange `json:"range"`
+	NewText string    `json:"newText"`
+}
+
+// handle handles a request or notification, returning its result
+func (s *Server) handle(method string, params json.RawMessage) (interface{}, *responseError) {
+	if s.shutdown && method != "shutdown" {
+		return nil, &responseError{Code: invalidRequest, Message: "server is shut down"}
+	}
+	var p struct {
+		TextDocument textDocument `json:"textDocument"`
+	}
+	switch method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				// Documents are sent in full on every change
+				"textDocumentSync":           1,
+				"hoverProvider":              true,
+				"documentFormattingProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "rnzml"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &responseError{Code: invalidParams, Message: err.Error()}
+		}
+		s.documents[p.TextDocument.URI] = p.TextDocument.Text
+		return nil, s.publish(p.TextDocument.URI)
+	case "textDocument/didChange":
+		var change changeParams
+		if err := json.Unmarshal(params, &change); err != nil {
+			return nil, &responseError{Code: invalidParams, Message: err.Error()}
+		}
+		if n := len(change.ContentChanges); n > 0 {
+			s.documents[change.TextDocument.URI] = change.ContentChanges[n-1].Text
+		}
+		return nil, s.publish(change.TextDocument.URI)
+	case "textDocument/didClose":
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &responseError{Code: invalidParams, Message: err.Error()}
+		}
+		delete(s.documents, p.TextDocument.URI)
+		return nil, s.publish(p.TextDocument.URI)
+	case "textDocument/hover":
+		var hp positionParams
+		if err := json.Unmarshal(params, &hp); err != nil {
+			return nil, &responseError{Code: invalidParams, Message: err.Error()}
+		}
+		if h := s.hover(hp); h != nil {
+			return h, nil
+		}
+		return nil, nil
+	case "textDocument/formatting":
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &responseError{Code: invalidParams, Message: err.Error()}
+		}
+		if edits := s.format(p.TextDocument.URI); edits != nil {
+			return edits, nil
+		}
+		return nil, nil
+	case "initialized", "$/cancelRequest", "$/setTrace", "workspace/didChangeConfiguration":
+		return nil, nil
+	}
+	return nil, &responseError{Code: methodNotFound, Message: "method not found: " + method}
+}
+
+// severities are the LSP severities of lint severities
+var severities = [...]int{lint.Error: 1, lint.Warning: 2, lint.Info: 3}
+
+// lspSeverity returns the LSP severity of s, severities above lint.Error are
+// errors and those below lint.Info are information
+func lspSeverity(s lint.Severity) int {
+	if s > lint.Error {
+		s = lint.Error
+	} else if s < lint.Info {
+		s = lint.Info
+	}
+	return severities[s]
+}
+
+// publish sends the diagnostics of the document at uri, an empty list once it
+// is closed
+func (s *Server) publish(uri string) *responseError {
+	diagnostics := []diagnostic{}
+	if text, ok := s.documents[uri]; ok {
+		issues, err := s.linter.Lint(strings.NewReader(text))
+		if err != nil {
+			return &responseError{Code: invalidParams, Message: err.Error()}
+		}
+		lines := splitLines(text)
+		for _, issue := range issues {
+			line := issue.Line - 1
+			if line < 0 {
+				line = 0
+			}
+			start := lspPosition(lines, line, issue.Position)
+			end := lspPosition(lines, line, -1)
+			diagnostics = append(diagnostics, diagnostic{
+				Range:    textRange{Start: start, End: end},
+				Severity: lspSeverity(issue.Severity),
+				Code:     issue.Rule,
+				Source:   "rnzml",
+				Message:  issue.Message,
+			})
+		}
+	}
+	params, err := json.Marshal(map[string]interface{}{"uri": uri, "diagnostics": diagnostics})
+	if err != nil {
+		return &responseError{Code: invalidParams, Message: err.Error()}
+	}
+	// Errors writing are returned by Serve
+	s.write(message{Method: "textDocument/publishDiagnostics", Params: params})
+	return nil
+}
+
+// hover returns the URL of the link at the position of p, or nil
+func (s *Server) hover(p positionParams) *hover {
+	text, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	lines := splitLines(text)
+	if p.Position.Line < 0 || p.Position.Line >= len(lines) {
+		return nil
+	}
+	line := lines[p.Position.Line]
+	offset := byteOffset(line, p.Position.Character)
+	// Links after a syntax error are found as the linter reports them
+	opts := append([]rnzml.Option{rnzml.WithErrorRecovery()}, s.linter.RenderOptions()...)
+	l := rnzml.NewLexer(strings.NewReader(text), opts...)
+	for {
+		t, err := l.Next()
+		if err != nil || t.Line > p.Position.Line+1 {
+			return nil
+		}
+		if t.Kind != rnzml.TokenLink || t.Line != p.Position.Line+1 || t.Position > offset {
+			continue
+		}
+		end := linkEnd(line, t.Position)
+		if offset > end {
+			continue
+		}
+		rawURL := string(t.Text)
+		if i := strings.IndexByte(rawURL, ' '); i != -1 {
+			rawURL = rawURL[:i]
+		}
+		return &hover{
+			Contents: markupContent{Kind: "plaintext", Value: rawURL},
+			Range: textRange{
+				Start: lspPosition(lines, p.Position.Line, t.Position),
+				End:   lspPosition(lines, p.Position.Line, end+1),
+			},
+		}
+	}
+}
+
+// format returns an edit replacing the document at uri with its canonical
+// form, or nil if it is not open or cannot be formatted
+func (s *Server) format(uri string) []textEdit {
+	text, ok := s.documents[uri]
+	if !ok {
+		return nil
+	}
+	var b strings.Builder
+	if err := rnzml.Format(strings.NewReader(text), &b, s.linter.RenderOptions()...); err != nil {
+		return nil
+	}
+	if b.String() == text {
+		return []textEdit{}
+	}
+	lines := splitLines(text)
+	end := lspPosition(lines, len(lines)-1, -1)
+	return []textEdit{{Range: textRange{End: end}, NewText: b.String()}}
+}
+
+// linkEnd returns the offset of the ] ending the link with the [ at start
+func linkEnd(line string, start int) int {
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case ']':
+			return i
+		}
+	}
+	return len(line)
+}
+
+// splitLines splits text into lines without line endings
+func splitLines(text string) []string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// lspPosition returns the LSP position of offset bytes into the line at index
+// line, counted in UTF-16 code units. An offset of -1 is the end of the line.
+func lspPosition(lines []string, line, offset int) position {
+	if line >= len(lines) {
+		return position{Line: line}
+	}
+	text := lines[line]
+	if offset == -1 || offset > len(text) {
+		offset = len(text)
+	}
+	character := 0
+	for _, r := range text[:offset] {
+		character += utf16Len(r)
+	}
+	return position{Line: line, Character: character}
+}
+
+// byteOffset returns the byte offset in line of character UTF-16 code units
+func byteOffset(line string, character int) int {
+	for i, r := range line {
+		if character <= 0 {
+			return i
+		}
+		character -= utf16Len(r)
+	}
+	return len(line)
+}
+
+// utf16Len returns the number of UTF-16 code units encoding r
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,205 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Resonance1584/rnzml"
+	"github.com/Resonance1584/rnzml/lint"
+)
+
+// frame returns messages framed as a client sends them
+func frame(messages ...string) string {
+	var b strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n%s", len(m), m)
+	}
+	return b.String()
+}
+
+// serve runs a Server on messages and returns the messages it writes
+func serve(t *testing.T, messages ...string) []string {
+	t.Helper()
+	var out strings.Builder
+	if err := NewServer().Serve(strings.NewReader(frame(messages...)), &out); err != nil {
+		t.Fatal(err)
+	}
+	var written []string
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(out.String())))
+	for {
+		header, err := r.ReadMIMEHeader()
+		if err == io.EOF {
+			return written
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r.R, body); err != nil {
+			t.Fatal(err)
+		}
+		written = append(written, string(body))
+	}
+}
+
+const open = `{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///a.rnz","text":"ā [https://res.nz here]  \n*b"}}}`
+
+func TestServer(t *testing.T) {
+	t.Run("Should initialize and shut down", func(t *testing.T) {
+		written := serve(t,
+			`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+			`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+			`{"jsonrpc":"2.0","id":2,"method":"shutdown"}`,
+			`{"jsonrpc":"2.0","id":3,"method":"textDocument/hover","params":{}}`,
+			`{"jsonrpc":"2.0","method":"exit"}`,
+			`{"jsonrpc":"2.0","id":4,"method":"shutdown"}`,
+		)
+		expected := []string{
+			`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{"documentFormattingProvider":true,"hoverProvider":true,"textDocumentSync":1},"serverInfo":{"name":"rnzml"}}}`,
+			`{"jsonrpc":"2.0","id":2,"result":null}`,
+			`{"jsonrpc":"2.0","id":3,"error":{"code":-32600,"message":"server is shut down"}}`,
+		}
+		if strings.Join(expected, "\n") != strings.Join(written, "\n") {
+			t.Errorf("expected: %v got: %v", expected, written)
+		}
+	})
+	t.Run("Should publish diagnostics", func(t *testing.T) {
+		written := serve(t, open,
+			`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///a.rnz"},"contentChanges":[{"text":"a"}]}}`,
+			`{"jsonrpc":"2.0","method":"textDocument/didClose","params":{"textDocument":{"uri":"file:///a.rnz"}}}`,
+		)
+		expected := []string{
+			`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"diagnostics":[` +
+				`{"range":{"start":{"line":0,"character":2},"end":{"line":0,"character":25}},"severity":2,"code":"link-label","source":"rnzml","message":"link label \"here\" does not describe the link"},` +
+				`{"range":{"start":{"line":0,"character":23},"end":{"line":0,"character":25}},"severity":2,"code":"trailing-whitespace","source":"rnzml","message":"trailing whitespace"},` +
+				`{"range":{"start":{"line":1,"character":0},"end":{"line":1,"character":2}},"severity":1,"code":"syntax","source":"rnzml","message":"line 2: unclosed bold text (*) at position: 0"}` +
+				`],"uri":"file:///a.rnz"}}`,
+			`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"diagnostics":[],"uri":"file:///a.rnz"}}`,
+			`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"diagnostics":[],"uri":"file:///a.rnz"}}`,
+		}
+		if strings.Join(expected, "\n") != strings.Join(written, "\n") {
+			t.Errorf("expected: %v got: %v", expected, written)
+		}
+	})
+	var hovertests = []struct {
+		character int
+		result    string
+	}{
+		{1, `null`},
+		{2, `{"contents":{"kind":"plaintext","value":"https://res.nz"},"range":{"start":{"line":0,"character":2},"end":{"line":0,"character":23}}}`},
+		{22, `{"contents":{"kind":"plaintext","value":"https://res.nz"},"range":{"start":{"line":0,"character":2},"end":{"line":0,"character":23}}}`},
+		{23, `null`},
+	}
+	for _, tt := range hovertests {
+		t.Run(fmt.Sprintf("Should hover at character %d", tt.character), func(t *testing.T) {
+			written := serve(t, open, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.rnz"},"position":{"line":0,"character":%d}}}`, tt.character))
+			if expected := `{"jsonrpc":"2.0","id":1,"result":` + tt.result + `}`; written[len(written)-1] != expected {
+				t.Errorf("expected: %s got: %s", expected, written[len(written)-1])
+			}
+		})
+	}
+	t.Run("Should format documents", func(t *testing.T) {
+		written := serve(t,
+			`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"a","text":"a  \r\n\n\nb"}}}`,
+			`{"jsonrpc":"2.0","id":1,"method":"textDocument/formatting","params":{"textDocument":{"uri":"a"},"options":{}}}`,
+			`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"a"},"contentChanges":[{"text":"a\n"}]}}`,
+			`{"jsonrpc":"2.0","id":2,"method":"textDocument/formatting","params":{"textDocument":{"uri":"a"},"options":{}}}`,
+			`{"jsonrpc":"2.0","id":3,"method":"textDocument/formatting","params":{"textDocument":{"uri":"b"},"options":{}}}`,
+		)
+		expected := []string{
+			`{"jsonrpc":"2.0","id":1,"result":[{"range":{"start":{"line":0,"character":0},"end":{"line":3,"character":1}},"newText":"a\n\nb\n"}]}`,
+			`{"jsonrpc":"2.0","id":2,"result":[]}`,
+			`{"jsonrpc":"2.0","id":3,"result":null}`,
+		}
+		var responses []string
+		for _, m := range written {
+			if strings.Contains(m, `"id"`) {
+				responses = append(responses, m)
+			}
+		}
+		if strings.Join(expected, "\n") != strings.Join(responses, "\n") {
+			t.Errorf("expected: %v got: %v", expected, responses)
+		}
+	})
+	t.Run("Should return errors for bad messages", func(t *testing.T) {
+		written := serve(t, `{`, `{"jsonrpc":"2.0","id":1,"method":"a"}`, `{"jsonrpc":"2.0","id":2,"method":"textDocument/didOpen","params":1}`)
+		for i, code := range []int{-32700, -32601, -32602} {
+			var m struct {
+				Error struct{ Code int }
+			}
+			if err := json.Unmarshal([]byte(written[i]), &m); err != nil || m.Error.Code != code {
+				t.Errorf("expected error code %d got: %s", code, written[i])
+			}
+		}
+	})
+	t.Run("Should hover after syntax errors", func(t *testing.T) {
+		written := serve(t,
+			`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"a","text":"*unclosed\n[https://res.nz here]"}}}`,
+			`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"textDocument":{"uri":"a"},"position":{"line":1,"character":1}}}`,
+		)
+		expected := `{"jsonrpc":"2.0","id":1,"result":{"contents":{"kind":"plaintext","value":"https://res.nz"},"range":{"start":{"line":1,"character":0},"end":{"line":1,"character":21}}}}`
+		if written[len(written)-1] != expected {
+			t.Errorf("expected: %s got: %s", expected, written[len(written)-1])
+		}
+	})
+	t.Run("Should format with the render options of the linter", func(t *testing.T) {
+		var out strings.Builder
+		s := NewServer(WithLinter(lint.New(lint.WithRenderOptions(rnzml.WithPreformattedBlocks()))))
+		in := frame(
+			`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"a","text":"~~~\n*\n~~~\n"}}}`,
+			`{"jsonrpc":"2.0","id":1,"method":"textDocument/formatting","params":{"textDocument":{"uri":"a"},"options":{}}}`,
+		)
+		if err := s.Serve(strings.NewReader(in), &out); err != nil {
+			t.Fatal(err)
+		}
+		if expected := `{"jsonrpc":"2.0","id":1,"result":[]}`; !strings.HasSuffix(out.String(), expected) {
+			t.Errorf("expected: %s got: %s", expected, out.String())
+		}
+	})
+	t.Run("Should not hover at negative lines", func(t *testing.T) {
+		written := serve(t, open, `{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.rnz"},"position":{"line":-1,"character":0}}}`)
+		if expected := `{"jsonrpc":"2.0","id":1,"result":null}`; written[len(written)-1] != expected {
+			t.Errorf("expected: %s got: %s", expected, written[len(written)-1])
+		}
+	})
+	t.Run("Should publish severities above error as errors", func(t *testing.T) {
+		var out strings.Builder
+		s := NewServer(WithLinter(lint.New(lint.WithRule(lint.LineLength(1), lint.Error+1))))
+		if err := s.Serve(strings.NewReader(frame(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"a","text":"abc"}}}`)), &out); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out.String(), `"severity":1,"code":"line-length"`) {
+			t.Errorf("expected an error diagnostic got: %s", out.String())
+		}
+	})
+	t.Run("Should reject invalid lengths", func(t *testing.T) {
+		for _, length := range []string{"-1", "1099511627776"} {
+			in := "Content-Length: " + length + "\r\n\r\n{}"
+			if err := NewServer().Serve(strings.NewReader(in), io.Discard); err == nil || err.Error() != "invalid Content-Length: "+length {
+				t.Errorf("expected invalid Content-Length error got: '%v'", err)
+			}
+		}
+	})
+	t.Run("Should return write errors", func(t *testing.T) {
+		fail := errors.New("fail")
+		if err := NewServer().Serve(strings.NewReader(frame(open)), failingWriter{fail}); err != fail {
+			t.Errorf("expected: '%v' got: '%v'", fail, err)
+		}
+	})
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
@@ -0,0 +1,852 @@
+package rnzml
+
+import (
+	"bufio"
+	"bytes"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	markdownReference = regexp.MustCompile(`^ {0,3}\[((?:[^\\\[\]]|\\.)+)\]:[ \t]*(<[^<>\n]*>|\S+)(?:[ \t]+("[^"]*"|'[^']*'|\([^)]*\)))?[ \t]*$`)
+	markdownHeading   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))??(?:[ \t]+#+)?[ \t]*$`)
+	markdownFence     = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^ \t]*)")
+	markdownBreak     = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	markdownSetext    = regexp.MustCompile(`^ {0,3}(?:=+|-+)[ \t]*$`)
+	markdownItem      = regexp.MustCompile(`^( {0,3})([-+*]|[0-9]{1,9}[.)])(?:( {1,4})|[ \t]*$)`)
+	markdownQuote     = regexp.MustCompile(`^ {0,3}> ?`)
+	markdownAutolink  = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^<>\x00-\x20]*)>`)
+	markdownEmail     = regexp.MustCompile(`^<([A-Za-z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*)>`)
+	markdownEntity    = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+)
+
+// ConvertMarkdown converts the CommonMark document in to rnzml and writes it to
+// out, for migrating content. Markdown has constructs rnzml does not, each one
+// that cannot be converted without losing formatting is returned as a Warning
+// with its line and position in in:
+//
+//   - Headings become bold text
+//   - Italic text becomes plain text
+//   - List items and block quotes become text, with list markers kept
+//   - Thematic breaks, link titles and code block info strings are removed
+//   - Images become links to the image
+//   - HTML is written as text
+//
+// Paragraphs become one line, as every line of rnzml is a text block, and hard
+// line breaks start a new line. Link reference definitions are resolved, so
+// the whole of in is read before anything is written.
+func ConvertMarkdown(in io.Reader, out io.Writer) ([]Warning, error) {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	c := &markdownConverter{out: bufio.NewWriter(out)}
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	for i := range lines {
+		lines[i] = trimLineEnding(lines[i])
+	}
+	c.references(lines)
+	for i, line := range lines {
+		c.line(line, i+1)
+	}
+	c.flushParagraph()
+	if c.fence != nil || c.indented {
+		c.write("```")
+	}
+	// Warnings in inline content are found after the paragraph is read
+	sort.SliceStable(c.warnings, func(i, j int) bool {
+		a, b := c.warnings[i], c.warnings[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Position < b.Position
+	})
+	return c.warnings, c.out.Flush()
+}
+
+// markdownLink is the destination of a link reference definition
+type markdownLink struct {
+	url   string
+	title bool
+}
+
+// markdownSegment is where a line of a paragraph starts in its text
+type markdownSegment struct {
+	start, line, column int
+}
+
+// markdownParagraph is a paragraph, heading or list item being read
+type markdownParagraph struct {
+	text     []byte
+	segments []markdownSegment
+	// prefix is written before the text, the marker of a list item
+	prefix    string
+	heading   bool
+	hardBreak bool
+	// backslash is true when the hard break is a \ at the end of the text,
+	// which is only a break when another line follows it
+	backslash bool
+}
+
+// markdownConverter converts the lines of a Markdown document
+type markdownConverter struct {
+	out      *bufio.Writer
+	warnings []Warning
+	refs     map[string]markdownLink
+	// definitions are the line numbers of link reference definitions
+	definitions map[int]bool
+	para        *markdownParagraph
+	// started is true once a block is written, blank is true when a blank
+	// line is written before the next block
+	started, blank bool
+	// fence is the fence of the open fenced code block and fenceIndent its
+	// indentation, indented is true in an indented code block
+	fence       []byte
+	fenceIndent int
+	indented    bool
+	codeBlanks  int
+	// listIndent is the column of the content of the current list item, or
+	// 0 outside of a list
+	listIndent int
+	quoted     bool
+}
+
+func (c *markdownConverter) warn(line, position int, message string) {
+	c.warnings = append(c.warnings, Warning{Line: line, Position: position, Message: message})
+}
+
+func (c *markdownConverter) write(line string) {
+	c.out.WriteString(line)
+	c.out.WriteByte('\n')
+}
+
+// startBlock writes the blank line before a block
+func (c *markdownConverter) startBlock() {
+	if c.blank && c.started {
+		c.write("")
+	}
+	c.blank = false
+	c.started = true
+}
+
+// references collects the link reference definitions in lines
+func (c *markdownConverter) references(lines [][]byte) {
+	c.refs = map[string]markdownLink{}
+	c.definitions = map[int]bool{}
+	var fence []byte
+	paragraph := false
+	for i, line := range lines {
+		if fence != nil {
+			if isMarkdownFenceEnd(line, fence) {
+				fence = nil
+			}
+			continue
+		}
+		if m := markdownFenceStart(line); m != nil {
+			fence = m[2]
+			paragraph = false
+			continue
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			paragraph = false
+			continue
+		}
+		m := markdownReference.FindSubmatch(line)
+		if paragraph || m == nil {
+			paragraph = true
+			continue
+		}
+		label := normalizeMarkdownLabel(string(m[1]))
+		if _, ok := c.refs[label]; !ok {
+			// The first definition of a label is used
+			c.refs[label] = markdownLink{url: markdownDestination(string(m[2])), title: len(m[3]) > 0}
+		}
+		c.definitions[i+1] = true
+	}
+}
+
+// line converts a line of the document
+func (c *markdownConverter) line(line []byte, lineNumber int) {
+	if c.fence != nil {
+		c.fencedCode(line, lineNumber)
+		return
+	}
+	if c.indented {
+		if indent, n := markdownIndent(line, 4); indent >= 4 {
+			for ; c.codeBlanks > 0; c.codeBlanks-- {
+				c.write("")
+			}
+			c.codeLine(line[n:], lineNumber)
+			return
+		} else if len(bytes.TrimSpace(line)) == 0 {
+			c.codeBlanks++
+			return
+		}
+		c.write("```")
+		c.indented = false
+		c.blank = c.codeBlanks > 0
+	}
+	if len(bytes.TrimSpace(line)) == 0 {
+		c.flushParagraph()
+		c.blank = true
+		c.quoted = false
+		return
+	}
+
+	column := 0
+	if m := markdownQuote.Find(line); m != nil {
+		if !c.quoted {
+			c.warn(lineNumber, 0, "block quote converted to text")
+		}
+		c.quoted = true
+		line = line[len(m):]
+		column += len(m)
+	} else {
+		c.quoted = false
+	}
+	listed := 0
+	if c.listIndent > 0 {
+		if indent, n := markdownIndent(line, c.listIndent); indent >= c.listIndent {
+			line = line[n:]
+			column += n
+			listed = c.listIndent
+		} else if c.blank && !markdownItem.Match(line) {
+			c.listIndent = 0
+		}
+	}
+
+	indent, n := markdownIndent(line, 4)
+	switch {
+	case indent >= 4 && c.para == nil:
+		c.startBlock()
+		c.write("```")
+		c.indented = true
+		c.codeBlanks = 0
+		c.codeLine(line[n:], lineNumber)
+	case markdownFenceStart(line) != nil:
+		m := markdownFenceStart(line)
+		c.flushParagraph()
+		c.startBlock()
+		c.write("```")
+		c.fence = m[2]
+		c.fenceIndent = len(m[1])
+		if len(m[3]) > 0 {
+			c.warn(lineNumber, column+len(m[1])+len(m[2]), "code block info string removed")
+		}
+	case c.para != nil && c.para.prefix == "" && markdownSetext.Match(line):
+		c.warn(c.para.segments[0].line, c.para.segments[0].column, "heading converted to bold text")
+		c.para.heading = true
+		c.flushParagraph()
+	case markdownBreak.Match(line):
+		c.flushParagraph()
+		c.warn(lineNumber, column, "thematic break removed")
+	case markdownHeading.Match(line):
+		m := markdownHeading.FindSubmatchIndex(line)
+		c.flushParagraph()
+		c.warn(lineNumber, column, "heading converted to bold text")
+		if m[4] != -1 {
+			c.text(line[m[4]:m[5]], lineNumber, column+m[4])
+			c.para.heading = true
+			c.flushParagraph()
+		}
+	case markdownItem.Match(line):
+		m := markdownItem.FindSubmatchIndex(line)
+		c.flushParagraph()
+		if c.listIndent == 0 {
+			c.warn(lineNumber, column, "list converted to text")
+		}
+		marker := string(line[m[4]:m[5]])
+		if marker == "*" || marker == "+" {
+			marker = "-"
+		}
+		c.listIndent = listed + m[1]
+		if m[6] == -1 || m[7]-m[6] > 1 && m[1] < len(line) && line[m[1]] == ' ' {
+			// Content indented by 5 spaces or more is code after one space
+			c.listIndent = listed + m[5] + 1
+		}
+		c.text(line[m[1]:], lineNumber, column+m[1])
+		c.para.prefix = strings.Repeat(" ", listed+m[3]-m[2]) + marker + " "
+	case c.para == nil && c.definitions[lineNumber]:
+	default:
+		c.text(line, lineNumber, column)
+	}
+}
+
+// markdownIndent returns the indentation of line, with tabs to multiples of 4,
+// and the number of bytes up to max columns of it
+func markdownIndent(line []byte, max int) (indent, n int) {
+	for n < len(line) && indent < max {
+		switch line[n] {
+		case ' ':
+			indent++
+		case '\t':
+			indent += 4 - indent%4
+		default:
+			return indent, n
+		}
+		n++
+	}
+	for i := n; i < len(line) && (line[i] == ' ' || line[i] == '\t'); i++ {
+		indent++
+	}
+	return indent, n
+}
+
+// markdownFenceStart returns the indentation, fence and info string of a line
+// opening a fenced code block, or nil
+func markdownFenceStart(line []byte) [][]byte {
+	m := markdownFence.FindSubmatch(line)
+	if m == nil || m[2][0] == '`' && bytes.IndexByte(line[len(m[1])+len(m[2]):], '`') != -1 {
+		return nil
+	}
+	return m
+}
+
+// isMarkdownFenceEnd reports whether line closes a code block opened by fence
+func isMarkdownFenceEnd(line, fence []byte) bool {
+	line = bytes.TrimRight(line, " \t")
+	trimmed := bytes.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < len(fence) {
+		return false
+	}
+	return len(bytes.Trim(trimmed, string(fence[:1]))) == 0
+}
+
+// fencedCode converts a line inside a fenced code block
+func (c *markdownConverter) fencedCode(line []byte, lineNumber int) {
+	if isMarkdownFenceEnd(line, c.fence) {
+		c.write("```")
+		c.fence = nil
+		return
+	}
+	_, n := markdownIndent(line, c.fenceIndent)
+	c.codeLine(line[n:], lineNumber)
+}
+
+// codeLine writes a line of a code block. A line containing only ``` would end
+// the code block, so a space is added to it.
+func (c *markdownConverter) codeLine(line []byte, lineNumber int) {
+	if bytes.Equal(line, codeFence) {
+		c.warn(lineNumber, 0, "code block line ``` written with a trailing space")
+		c.write("``` ")
+		return
+	}
+	c.write(string(line))
+}
+
+// text adds a line of text to the current paragraph
+func (c *markdownConverter) text(line []byte, lineNumber, column int) {
+	start := len(line) - len(bytes.TrimLeft(line, " \t"))
+	line = line[start:]
+	if c.para == nil {
+		c.para = &markdownParagraph{}
+	} else if c.para.hardBreak {
+		if c.para.backslash {
+			c.para.text = c.para.text[:len(c.para.text)-1]
+		}
+		c.para.text = append(c.para.text, '\n')
+	} else {
+		c.para.text = append(c.para.text, ' ')
+	}
+	trimmed := bytes.TrimRight(line, " \t")
+	c.para.hardBreak = len(line)-len(trimmed) >= 2 && bytes.HasSuffix(line, []byte("  "))
+	backslashes := len(trimmed) - len(bytes.TrimRight(trimmed, "\\"))
+	c.para.backslash = len(trimmed) == len(line) && backslashes%2 == 1
+	if c.para.backslash {
+		c.para.hardBreak = true
+	}
+	c.para.segments = append(c.para.segments, markdownSegment{start: len(c.para.text), line: lineNumber, column: column + start})
+	c.para.text = append(c.para.text, trimmed...)
+}
+
+// flushParagraph writes the current paragraph
+func (c *markdownConverter) flushParagraph() {
+	p := c.para
+	if p == nil {
+		return
+	}
+	c.para = nil
+	c.startBlock()
+	in := &markdownInline{c: c, p: p}
+	if p.heading {
+		in.depth = 1
+	}
+	text := in.convert(p.text)
+	switch {
+	case p.heading && text != "":
+		c.write(p.prefix + "*" + text + "*")
+	case text == "":
+		c.write(strings.TrimSuffix(p.prefix, " "))
+	default:
+		c.write(p.prefix + text)
+	}
+}
+
+// position returns the line and position in the document of the byte at i of
+// the text of p
+func (p *markdownParagraph) position(i int) (line, position int) {
+	s := p.segments[0]
+	for _, segment := range p.segments[1:] {
+		if segment.start > i {
+			break
+		}
+		s = segment
+	}
+	return s.line, s.column + i - s.start
+}
+
+// markdownNode is text converted to rnzml or a run of * or _ delimiters
+type markdownNode struct {
+	text     string
+	position int
+	// delim is the delimiter of a run of count delimiters
+	delim            byte
+	count            int
+	canOpen          bool
+	canClose         bool
+	opens, closes    []int
+	hardBreak        bool
+	emphasisReported bool
+}
+
+// markdownInline converts the inline content of a paragraph
+type markdownInline struct {
+	c *markdownConverter
+	p *markdownParagraph
+	// plain converts to text without markup, for link labels, formatted is
+	// set when markup is removed
+	plain     bool
+	formatted bool
+	// base is the position of the text in the paragraph
+	base  int
+	nodes []markdownNode
+	// depth is the number of strong spans open when writing
+	depth int
+}
+
+func (in *markdownInline) warn(i int, message string) {
+	if in.plain {
+		return
+	}
+	line, position := in.p.position(in.base + i)
+	in.c.warn(line, position, message)
+}
+
+func (in *markdownInline) add(text string, position int) {
+	if in.plain {
+		in.nodes = append(in.nodes, markdownNode{text: text, position: position})
+		return
+	}
+	in.nodes = append(in.nodes, markdownNode{text: escapeRnzml(text, "\\*`[]"), position: position})
+}
+
+// escapeRnzml escapes the bytes of text in special with a \
+func escapeRnzml(text, special string) string {
+	if !strings.ContainsAny(text, special) {
+		return text
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if strings.IndexByte(special, text[i]) != -1 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
+
+// convert returns the inline content s converted to rnzml
+func (in *markdownInline) convert(s []byte) string {
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && isMarkdownEscapable(s[i+1]):
+			in.add(string(s[i+1]), i)
+			i += 2
+		case c == '\n':
+			in.nodes = append(in.nodes, markdownNode{hardBreak: true, position: i})
+			i++
+		case c == '`':
+			i = in.code(s, i)
+		case c == '*' || c == '_':
+			i = in.delimiters(s, i)
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if n := in.link(s, i+1, true); n > 0 {
+				i = n
+			} else {
+				in.add("!", i)
+				i++
+			}
+		case c == '[':
+			if n := in.link(s, i, false); n > 0 {
+				i = n
+			} else {
+				in.add("[", i)
+				i++
+			}
+		case c == '<':
+			i = in.angle(s, i)
+		case c == '&':
+			if m := markdownEntity.Find(s[i:]); m != nil {
+				in.add(html.UnescapeString(string(m)), i)
+				i += len(m)
+			} else {
+				in.add("&", i)
+				i++
+			}
+		default:
+			n := i + 1
+			for n < len(s) && strings.IndexByte("\\\n`*_![<&", s[n]) == -1 {
+				n++
+			}
+			in.add(string(s[i:n]), i)
+			i = n
+		}
+	}
+	in.emphasis()
+	return in.string()
+}
+
+// code converts the code span starting with the backticks at i
+func (in *markdownInline) code(s []byte, i int) int {
+	n := i
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	run := n - i
+	for end := n; end < len(s); {
+		if s[end] != '`' {
+			end++
+			continue
+		}
+		closing := end
+		for end < len(s) && s[end] == '`' {
+			end++
+		}
+		if end-closing != run {
+			continue
+		}
+		code := bytes.ReplaceAll(s[n:closing], []byte("\n"), []byte(" "))
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && len(bytes.TrimSpace(code)) > 0 {
+			code = code[1 : len(code)-1]
+		}
+		if in.plain {
+			in.formatted = true
+			in.add(string(code), i)
+		} else {
+			in.nodes = append(in.nodes, markdownNode{text: "`" + escapeRnzml(string(code), "\\`") + "`", position: i})
+		}
+		return end
+	}
+	in.add(string(s[i:n]), i)
+	return n
+}
+
+// delimiters adds the run of * or _ at i
+func (in *markdownInline) delimiters(s []byte, i int) int {
+	delim := s[i]
+	n := i
+	for n < len(s) && s[n] == delim {
+		n++
+	}
+	before, after := ' ', ' '
+	if i > 0 {
+		before, _ = utf8.DecodeLastRune(s[:i])
+	}
+	if n < len(s) {
+		after, _ = utf8.DecodeRune(s[n:])
+	}
+	left := !unicode.IsSpace(after) && (!isMarkdownPunct(after) || unicode.IsSpace(before) || isMarkdownPunct(before))
+	right := !unicode.IsSpace(before) && (!isMarkdownPunct(before) || unicode.IsSpace(after) || isMarkdownPunct(after))
+	node := markdownNode{delim: delim, count: n - i, position: i, canOpen: left, canClose: right}
+	if delim == '_' {
+		node.canOpen = left && (!right || isMarkdownPunct(before))
+		node.canClose = right && (!left || isMarkdownPunct(after))
+	}
+	in.nodes = append(in.nodes, node)
+	return n
+}
+
+// isMarkdownEscapable reports whether c can be escaped with a \, which is true
+// of ASCII punctuation
+func isMarkdownEscapable(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) != -1
+}
+
+func isMarkdownPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// emphasis matches the delimiter runs of the nodes
+func (in *markdownInline) emphasis() {
+	for i := range in.nodes {
+		closer := &in.nodes[i]
+		for closer.delim != 0 && closer.canClose && closer.count > 0 {
+			j := i - 1
+			for ; j >= 0; j-- {
+				if opener := &in.nodes[j]; opener.delim == closer.delim && opener.canOpen && opener.count > 0 {
+					break
+				}
+			}
+			if j < 0 {
+				break
+			}
+			opener := &in.nodes[j]
+			use := 1
+			if opener.count >= 2 && closer.count >= 2 {
+				use = 2
+			}
+			opener.count -= use
+			closer.count -= use
+			opener.opens = append(opener.opens, use)
+			closer.closes = append(closer.closes, use)
+			if use == 1 {
+				if in.plain {
+					in.formatted = true
+				} else if !opener.emphasisReported {
+					opener.emphasisReported = true
+					in.warn(opener.position, "italic text converted to plain text")
+				}
+			} else if in.plain {
+				in.formatted = true
+			}
+			for k := j + 1; k < i; k++ {
+				in.nodes[k].canOpen = false
+				in.nodes[k].canClose = false
+			}
+		}
+	}
+}
+
+// string writes the nodes as rnzml
+func (in *markdownInline) string() string {
+	var b strings.Builder
+	for _, node := range in.nodes {
+		switch {
+		case node.hardBreak && in.plain:
+			b.WriteByte(' ')
+		case node.hardBreak && in.depth > 0:
+			b.WriteString("*\n*")
+		case node.hardBreak:
+			b.WriteByte('\n')
+		case node.delim != 0:
+			for _, use := range node.closes {
+				if use == 2 {
+					if in.depth--; in.depth == 0 && !in.plain {
+						b.WriteByte('*')
+					}
+				}
+			}
+			literal := strings.Repeat(string(node.delim), node.count)
+			if !in.plain {
+				literal = escapeRnzml(literal, "*")
+			}
+			b.WriteString(literal)
+			for k := len(node.opens) - 1; k >= 0; k-- {
+				if node.opens[k] == 2 {
+					if in.depth++; in.depth == 1 && !in.plain {
+						b.WriteByte('*')
+					}
+				}
+			}
+		default:
+			b.WriteString(node.text)
+		}
+	}
+	return b.String()
+}
+
+// angle converts an autolink or HTML starting with the < at i
+func (in *markdownInline) angle(s []byte, i int) int {
+	if m := markdownAutolink.FindSubmatch(s[i:]); m != nil {
+		in.writeLink(string(m[1]), "", i)
+		return i + len(m[0])
+	}
+	if m := markdownEmail.FindSubmatch(s[i:]); m != nil {
+		in.writeLink("mailto:"+string(m[1]), string(m[1]), i)
+		return i + len(m[0])
+	}
+	if i+1 < len(s) && (s[i+1] == '/' || s[i+1] == '!' || s[i+1] == '?' || s[i+1] < utf8.RuneSelf && unicode.IsLetter(rune(s[i+1]))) {
+		in.warn(i, "HTML converted to text")
+	}
+	in.add("<", i)
+	return i + 1
+}
+
+// link converts the link or image with the [ at i, returning the position after
+// it or 0 if there is no link at i
+func (in *markdownInline) link(s []byte, i int, image bool) int {
+	end := markdownBracket(s, i)
+	if end == -1 {
+		return 0
+	}
+	label := s[i+1 : end]
+	n := end + 1
+	var dest markdownLink
+	found := false
+	if n < len(s) && s[n] == '(' {
+		if d, after, ok := markdownInlineDestination(s, n+1); ok {
+			dest, n, found = d, after, true
+		}
+	}
+	if !found {
+		ref := label
+		if n+1 < len(s) && s[n] == '[' {
+			if refEnd := markdownBracket(s, n); refEnd != -1 {
+				if refEnd > n+1 {
+					ref = s[n+1 : refEnd]
+				}
+				n = refEnd + 1
+			}
+		}
+		dest, found = in.c.refs[normalizeMarkdownLabel(string(ref))]
+		if !found {
+			return 0
+		}
+	}
+
+	position := i
+	if image {
+		position--
+	}
+	labelInline := &markdownInline{c: in.c, p: in.p, plain: true}
+	text := strings.TrimSpace(labelInline.convert(label))
+	if in.plain {
+		in.formatted = true
+		in.add(text, position)
+		return n
+	}
+	switch {
+	case image:
+		in.warn(position, "image converted to link")
+	case labelInline.formatted:
+		in.warn(position, "formatting in link label removed")
+	}
+	if dest.title {
+		in.warn(position, "link title removed")
+	}
+	if dest.url == "" {
+		in.warn(position, "link without URL converted to text")
+		in.add(text, position)
+		return n
+	}
+	in.writeLink(dest.url, text, position)
+	return n
+}
+
+// writeLink adds a link to url labelled with label
+func (in *markdownInline) writeLink(url, label string, position int) {
+	if in.plain {
+		in.formatted = true
+		if label == "" {
+			label = url
+		}
+		in.add(label, position)
+		return
+	}
+	url = strings.ReplaceAll(url, " ", "%20")
+	link := "[" + escapeRnzml(url, "\\]")
+	if label != "" && label != url {
+		link += " " + escapeRnzml(label, "\\]")
+	}
+	in.nodes = append(in.nodes, markdownNode{text: link + "]", position: position})
+}
+
+// markdownBracket returns the position of the ] matching the [ at i, or -1
+func markdownBracket(s []byte, i int) int {
+	depth := 0
+	for n := i; n < len(s); n++ {
+		switch s[n] {
+		case '\\':
+			n++
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				return n
+			}
+		}
+	}
+	return -1
+}
+
+// markdownInlineDestination reads the destination and title of an inline link
+// starting at i, after the (
+func markdownInlineDestination(s []byte, i int) (markdownLink, int, bool) {
+	var link markdownLink
+	skip := func() {
+		for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n') {
+			i++
+		}
+	}
+	skip()
+	start := i
+	if i < len(s) && s[i] == '<' {
+		end := bytes.IndexAny(s[i+1:], "<>\n")
+		if end == -1 || s[i+1+end] != '>' {
+			return link, 0, false
+		}
+		i += end + 2
+	} else {
+		depth := 0
+		for ; i < len(s) && s[i] > ' '; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			} else if s[i] == '(' {
+				depth++
+			} else if s[i] == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+		}
+	}
+	link.url = markdownDestination(string(s[start:i]))
+	skip()
+	if i < len(s) && (s[i] == '"' || s[i] == '\'' || s[i] == '(') {
+		closing := s[i]
+		if closing == '(' {
+			closing = ')'
+		}
+		end := bytes.IndexByte(s[i+1:], closing)
+		if end == -1 {
+			return link, 0, false
+		}
+		link.title = true
+		i += end + 2
+		skip()
+	}
+	if i >= len(s) || s[i] != ')' {
+		return link, 0, false
+	}
+	return link, i + 1, true
+}
+
+// markdownDestination removes the brackets, escapes and entities of a link
+// destination
+func markdownDestination(dest string) string {
+	if strings.HasPrefix(dest, "<") && strings.HasSuffix(dest, ">") {
+		dest = dest[1 : len(dest)-1]
+	}
+	if strings.IndexByte(dest, '\\') != -1 {
+		var b strings.Builder
+		for i := 0; i < len(dest); i++ {
+			if dest[i] == '\\' && i+1 < len(dest) && isMarkdownEscapable(dest[i+1]) {
+				i++
+			}
+			b.WriteByte(dest[i])
+		}
+		dest = b.String()
+	}
+	return html.UnescapeString(dest)
+}
+
+// normalizeMarkdownLabel returns the label used to match a link reference
+func normalizeMarkdownLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
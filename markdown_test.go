@@ -0,0 +1,120 @@
+package rnzml
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+var markdowntests = []struct {
+	in       string
+	out      string
+	warnings []string
+}{
+	{"a\nb\n\nc", "a b\n\nc\n", nil},
+	{"a  \nb\\\nc", "a\nb\nc\n", nil},
+	{"a\\", "a\\\\\n", nil},
+	{"x \\\n\ny\\", "x \\\\\n\ny\\\\\n", nil},
+	{"**a** __b__ *c* _d_", "*a* *b* c d\n", []string{
+		"line 1: italic text converted to plain text at position: 12",
+		"line 1: italic text converted to plain text at position: 16",
+	}},
+	{"**a *b* c**", "*a b c*\n", []string{"line 1: italic text converted to plain text at position: 4"}},
+	{"**a **b** c**", "*a b c*\n", nil},
+	{"**a\nb**", "*a b*\n", nil},
+	{"**a  \nb**", "*a*\n*b*\n", nil},
+	{"snake_case_name 2 * 3 [x] \\[y\\] a\\b", "snake_case_name 2 \\* 3 \\[x\\] \\[y\\] a\\\\b\n", nil},
+	{"`a` ``b`c`` ` d ` `e", "`a` `b\\`c` `d` \\`e\n", nil},
+	{"&amp; &copy; &#42; &bogus;", "& © \\* &bogus;\n", nil},
+	{"[a](https://res.nz) [b](</c d>) [](/e) [f](/f)", "[https://res.nz a] [/c%20d b] [/e] [/f f]\n", nil},
+	{"[https://res.nz](https://res.nz) [a](x \"title\")", "[https://res.nz] [x a]\n", []string{"line 1: link title removed at position: 33"}},
+	{"[*a* `b`](/c) [a\\]](/d]) [a]()", "[/c a b] [/d\\] a\\]] a\n", []string{
+		"line 1: formatting in link label removed at position: 0",
+		"line 1: link without URL converted to text at position: 25",
+	}},
+	{"[a] [b][] [c][d] [e]\n\n[a]: /a\n[B]: </b>\n[d]: /d 'title'", "[/a a] [/b b] [/d c] \\[e\\]\n", []string{"line 1: link title removed at position: 10"}},
+	{"![alt](/a.png) <https://res.nz> <a@res.nz>", "[/a.png alt] [https://res.nz] [mailto:a@res.nz a@res.nz]\n", []string{"line 1: image converted to link at position: 0"}},
+	{"a <b>c</b> 1 < 2", "a <b>c</b> 1 < 2\n", []string{
+		"line 1: HTML converted to text at position: 2",
+		"line 1: HTML converted to text at position: 6",
+	}},
+	{"# a\n## *b* ##\n#c\n\nd\n===\n\ne\n---", "*a*\n*b*\n#c\n\n*d*\n\n*e*\n", []string{
+		"line 1: heading converted to bold text at position: 0",
+		"line 2: heading converted to bold text at position: 0",
+		"line 2: italic text converted to plain text at position: 3",
+		"line 5: heading converted to bold text at position: 0",
+		"line 8: heading converted to bold text at position: 0",
+	}},
+	{"# **a** b", "*a b*\n", []string{"line 1: heading converted to bold text at position: 0"}},
+	{"a\n\n***\n\nb", "a\n\nb\n", []string{"line 3: thematic break removed at position: 0"}},
+	{"* a\n* b\n  c\n  - d\n\n+ e\n\n  f\n\ng\n1. h\n2) i", "- a\n- b c\n  - d\n\n- e\n\nf\n\ng\n1. h\n2) i\n", []string{
+		"line 1: list converted to text at position: 0",
+		"line 11: list converted to text at position: 0",
+	}},
+	{"> a\n> b\n\n> c", "a b\n\nc\n", []string{
+		"line 1: block quote converted to text at position: 0",
+		"line 4: block quote converted to text at position: 0",
+	}},
+	{"```go\na *b*\n```\n\n~~~\n```\n~~~", "```\na *b*\n```\n\n```\n``` \n```\n", []string{
+		"line 1: code block info string removed at position: 3",
+		"line 6: code block line ``` written with a trailing space at position: 0",
+	}},
+	{"  ```\n  a\n b\n  ```", "```\na\nb\n```\n", nil},
+	{"    a\n\n    b\n\nc", "```\na\n\nb\n```\n\nc\n", nil},
+	{"```\na", "```\na\n```\n", nil},
+	{"```a``` b", "`a` b\n", nil},
+	{"\r\n\r\na\r\n\r\n\r\nb\r\n\r\n", "a\n\nb\n", nil},
+}
+
+func TestConvertMarkdown(t *testing.T) {
+	for _, tt := range markdowntests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := &strings.Builder{}
+			warnings, err := ConvertMarkdown(strings.NewReader(tt.in), out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != out.String() {
+				t.Errorf("expected: %q got: %q", tt.out, out.String())
+			}
+			var got []string
+			for _, w := range warnings {
+				got = append(got, w.String())
+			}
+			if strings.Join(tt.warnings, "\n") != strings.Join(got, "\n") {
+				t.Errorf("expected: '%v' got: '%v'", tt.warnings, got)
+			}
+			if err := NewRenderer().Render(strings.NewReader(out.String()), &strings.Builder{}); err != nil {
+				t.Errorf("expected valid rnzml: %v", err)
+			}
+		})
+	}
+	t.Run("Should return read errors", func(t *testing.T) {
+		fail := errors.New("fail")
+		if _, err := ConvertMarkdown(&errorReader{err: fail}, &strings.Builder{}); err != fail {
+			t.Errorf("expected: '%v' got: '%v'", fail, err)
+		}
+	})
+}
+
+// markdownAlphabet is the text random Markdown documents are made from
+var markdownAlphabet = []string{"a", " ", "*", "_", "`", "[", "]", "(", ")", "<", ">", "&", "!", "\\", "\n", "  \n", "# ", "- ", "1. ", "    ", "```\n", "~~~\n", "[a]: /a\n", "https://res.nz"}
+
+func TestConvertMarkdownRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	re := NewRenderer()
+	for i := 0; i < 5000; i++ {
+		in := ""
+		for n := rnd.Intn(20); n > 0; n-- {
+			in += markdownAlphabet[rnd.Intn(len(markdownAlphabet))]
+		}
+		out := &strings.Builder{}
+		if _, err := ConvertMarkdown(strings.NewReader(in), out); err != nil {
+			t.Fatal(err)
+		}
+		if err := re.Render(strings.NewReader(out.String()), &strings.Builder{}); err != nil {
+			t.Fatalf("%q converted to %q: %v", in, out.String(), err)
+		}
+	}
+}
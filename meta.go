@@ -0,0 +1,129 @@
+package rnzml
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var frontMatterFence = []byte("---")
+
+// dateLayouts are the layouts accepted for the date of a document
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"}
+
+// Meta is the metadata of a document, read from its front matter
+type Meta struct {
+	Title string
+	Date  time.Time
+	Tags  []string
+	// Draft is true for documents that should not be published yet
+	Draft bool
+	// Params holds the value of every key in the front matter, including
+	// title, date, tags and draft
+	Params map[string]string
+}
+
+// ParseMeta reads the front matter at the start of in and returns its metadata
+// and a reader positioned at the body of the document. Front matter starts and
+// ends with a line containing only ---, with a key: value pair on each line
+// between. Blank lines and lines starting with # are ignored, and values may
+// be quoted. Tags are separated by commas and may be surrounded by [ and ],
+// and draft is true or false.
+//
+// Input that does not start with front matter is returned unchanged with an
+// empty Meta. Line numbers in errors rendering the body are relative to the
+// start of the body.
+func ParseMeta(in io.Reader) (Meta, io.Reader, error) {
+	var meta Meta
+	br := bufio.NewReader(in)
+	first, err := br.ReadSlice('\n')
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return meta, nil, err
+	}
+	if !bytes.Equal(trimLineEnding(bytes.TrimPrefix(first, byteOrderMark)), frontMatterFence) {
+		return meta, io.MultiReader(bytes.NewReader(append([]byte(nil), first...)), br), nil
+	}
+
+	meta.Params = map[string]string{}
+	for lineNumber := 2; ; lineNumber++ {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			return meta, nil, fmt.Errorf("line %d: %w", lineNumber, bufio.ErrTooLong)
+		}
+		if err != nil && err != io.EOF {
+			return meta, nil, err
+		}
+		line = trimLineEnding(line)
+		if bytes.Equal(line, frontMatterFence) {
+			return meta, br, nil
+		}
+		if err == io.EOF {
+			return meta, nil, &SyntaxError{Problem: UnclosedFrontMatter, Line: 1}
+		}
+		if len(bytes.TrimSpace(line)) == 0 || line[0] == '#' {
+			continue
+		}
+		i := bytes.IndexByte(line, ':')
+		if i < 1 {
+			return meta, nil, &SyntaxError{Problem: InvalidMetadata, Line: lineNumber}
+		}
+		key := strings.TrimSpace(string(line[:i]))
+		value := unquote(strings.TrimSpace(string(line[i+1:])))
+		meta.Params[key] = value
+		switch key {
+		case "title":
+			meta.Title = value
+		case "date":
+			if meta.Date, err = parseDate(value); err != nil {
+				return meta, nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+		case "tags":
+			meta.Tags = parseTags(value)
+		case "draft":
+			if meta.Draft, err = strconv.ParseBool(value); err != nil {
+				return meta, nil, fmt.Errorf("line %d: draft: %w", lineNumber, err)
+			}
+		}
+	}
+}
+
+// trimLineEnding removes a trailing \n or \r\n from line
+func trimLineEnding(line []byte) []byte {
+	return bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+}
+
+// unquote removes matching quotes around value
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// parseDate parses value with the first of dateLayouts that matches it
+func parseDate(value string) (time.Time, error) {
+	var err error
+	for _, layout := range dateLayouts {
+		var date time.Time
+		if date, err = time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// parseTags splits a comma separated list of tags
+func parseTags(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = unquote(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,98 @@
+package rnzml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var metatests = []struct {
+	name string
+	in   string
+	meta Meta
+	body string
+}{
+	{"no front matter", "a\n---\nb", Meta{}, "a\n---\nb"},
+	{"empty", "", Meta{}, ""},
+	{"empty front matter", "---\n---\na", Meta{Params: map[string]string{}}, "a"},
+	{
+		"typed keys",
+		"---\ntitle: \"Hello: World\"\ndate: 2021-03-04\ntags: [go, 'web', ]\n---\na\n",
+		Meta{
+			Title:  "Hello: World",
+			Date:   time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC),
+			Tags:   []string{"go", "web"},
+			Params: map[string]string{"title": "Hello: World", "date": "2021-03-04", "tags": "[go, 'web', ]"},
+		},
+		"a\n",
+	},
+	{
+		"draft",
+		"---\ndraft: true\n---\n",
+		Meta{Draft: true, Params: map[string]string{"draft": "true"}},
+		"",
+	},
+	{
+		"arbitrary keys",
+		"\uFEFF---\r\n# comment\r\n\r\nauthor:  Res \r\ndate: 2021-03-04T05:06:07Z\r\n---\r\na",
+		Meta{
+			Date:   time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+			Params: map[string]string{"author": "Res", "date": "2021-03-04T05:06:07Z"},
+		},
+		"a",
+	},
+}
+
+func TestParseMeta(t *testing.T) {
+	for _, tt := range metatests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body, err := ParseMeta(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.meta, meta) {
+				t.Errorf("expected: %+v got: %+v", tt.meta, meta)
+			}
+			if b, _ := io.ReadAll(body); tt.body != string(b) {
+				t.Errorf("expected body: %q got: %q", tt.body, b)
+			}
+		})
+	}
+	t.Run("Should return a reader for a long first line", func(t *testing.T) {
+		in := strings.Repeat("a", 10000)
+		_, body, err := ParseMeta(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := io.ReadAll(body); in != string(b) {
+			t.Errorf("expected %d bytes of body got: %d", len(in), len(b))
+		}
+	})
+	for _, tt := range []struct {
+		in  string
+		err string
+	}{
+		{"---\ntitle: a\n", "unclosed front matter (---) on line: 1"},
+		{"---\ntitle: a\nno value\n---\n", "line 3: front matter must be key: value pairs"},
+		{"---\n: a\n---\n", "line 2: front matter must be key: value pairs"},
+		{"---\ndraft: soon\n---\n", `line 2: draft: strconv.ParseBool: parsing "soon": invalid syntax`},
+		{"---\ndate: 4 March\n---\n", `line 2: parsing time "4 March" as "2006-01-02": cannot parse "4 March" as "2006"`},
+	} {
+		t.Run(fmt.Sprintf("Should return an error for %q", tt.in), func(t *testing.T) {
+			if _, _, err := ParseMeta(strings.NewReader(tt.in)); fmt.Sprint(err) != tt.err {
+				t.Errorf("expected: '%s' got: '%v'", tt.err, err)
+			}
+		})
+	}
+	t.Run("Should return a *SyntaxError for malformed front matter", func(t *testing.T) {
+		_, _, err := ParseMeta(strings.NewReader("---\n"))
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || syntaxErr.Problem != UnclosedFrontMatter {
+			t.Errorf("expected *SyntaxError got: '%v'", err)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package rnzml
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// descriptionWords is the number of words of the excerpt used as the
+// description of a document
+const descriptionWords = 40
+
+// MetaTags returns <meta> tags describing a document with meta and summary
+// for the <head> of a page: a description and Open Graph title, description,
+// published time and tags. The description is the text of summary.
+func MetaTags(meta Meta, summary Summary) string {
+	var b []byte
+	tag := func(attr, name, content string) {
+		b = append(b, `<meta `...)
+		b = append(b, attr...)
+		b = append(b, `="`...)
+		b = append(b, name...)
+		b = append(b, `" content="`...)
+		b = appendHTMLEscaped(b, []byte(content))
+		b = append(b, "\">\n"...)
+	}
+	description := strings.Join(strings.Fields(summary.Text), " ")
+	if description != "" {
+		tag("name", "description", description)
+	}
+	if meta.Title != "" {
+		tag("property", "og:title", meta.Title)
+	}
+	if description != "" {
+		tag("property", "og:description", description)
+	}
+	tag("property", "og:type", "article")
+	if !meta.Date.IsZero() {
+		tag("property", "article:published_time", meta.Date.Format(time.RFC3339))
+	}
+	for _, t := range meta.Tags {
+		tag("property", "article:tag", t)
+	}
+	return string(b)
+}
+
+// DocumentMetaTags reads the front matter and excerpt of in, rendered with a
+// Renderer configured by opts, and returns MetaTags for them. The description
+// is cut after 40 words.
+func DocumentMetaTags(in io.Reader, opts ...Option) (string, error) {
+	meta, body, err := ParseMeta(in)
+	if err != nil {
+		return "", err
+	}
+	summary, err := Excerpt(body, descriptionWords, opts...)
+	if err != nil {
+		return "", err
+	}
+	return MetaTags(meta, summary), nil
+}
@@ -0,0 +1,64 @@
+package rnzml
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var metatagtests = []struct {
+	name    string
+	meta    Meta
+	summary Summary
+	tags    string
+}{
+	{"empty", Meta{}, Summary{}, "<meta property=\"og:type\" content=\"article\">\n"},
+	{
+		"document",
+		Meta{Title: `"Quoted" & <b>`, Date: time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), Tags: []string{"go", "web"}},
+		Summary{Text: "Some\n  text"},
+		strings.Join([]string{
+			`<meta name="description" content="Some text">`,
+			`<meta property="og:title" content="&#34;Quoted&#34; &amp; &lt;b&gt;">`,
+			`<meta property="og:description" content="Some text">`,
+			`<meta property="og:type" content="article">`,
+			`<meta property="article:published_time" content="2021-03-04T00:00:00Z">`,
+			`<meta property="article:tag" content="go">`,
+			`<meta property="article:tag" content="web">`,
+		}, "\n") + "\n",
+	},
+}
+
+func TestMetaTags(t *testing.T) {
+	for _, tt := range metatagtests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tags := MetaTags(tt.meta, tt.summary); tt.tags != tags {
+				t.Errorf("expected: %s got: %s", tt.tags, tags)
+			}
+		})
+	}
+	t.Run("Should describe a document from its front matter and excerpt", func(t *testing.T) {
+		in := "---\ntitle: Hello\n---\n\n*Some* [/a text]\n\nMore"
+		tags, err := DocumentMetaTags(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := strings.Join([]string{
+			`<meta name="description" content="Some text">`,
+			`<meta property="og:title" content="Hello">`,
+			`<meta property="og:description" content="Some text">`,
+			`<meta property="og:type" content="article">`,
+		}, "\n") + "\n"
+		if expected != tags {
+			t.Errorf("expected: %s got: %s", expected, tags)
+		}
+	})
+	t.Run("Should return errors in the document", func(t *testing.T) {
+		if _, err := DocumentMetaTags(strings.NewReader("---\ntitle: a")); err == nil {
+			t.Error("expected an error for unclosed front matter")
+		}
+		if _, err := DocumentMetaTags(strings.NewReader("*a")); err == nil {
+			t.Error("expected an error for unclosed bold text")
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package rnzml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+)
+
+// WithParallelism splits the input into chunks of blocks, renders up to
+// workers chunks concurrently and writes their output in order. Output, errors
+// and warnings are the same as when rendering sequentially, with warnings
+// reported from the goroutine calling Render. The exception is when writing to
+// out fails, when the error is not wrapped with a line number and warnings may
+// have been reported for blocks that were not written. A normalizer or
+// URLRewriter must be safe for concurrent use. Values of workers below 2 render
+// sequentially.
+func WithParallelism(workers int) Option {
+	return func(re *Renderer) {
+		re.parallelism = workers
+	}
+}
+
+// WithPipelining scans the input, renders blocks and writes output to out on
+// separate goroutines, so that waiting on a slow reader or writer overlaps with
+// rendering. Without WithParallelism each block is passed between the stages
+// as soon as it is scanned, and up to pipelineDepth blocks are rendered ahead
+// of those being written. Output, errors and warnings are as described by
+// WithParallelism.
+func WithPipelining() Option {
+	return func(re *Renderer) {
+		re.pipelined = true
+	}
+}
+
+const (
+	// chunkBlocks is the maximum number of blocks in a chunk
+	chunkBlocks = 256
+	// chunkBytes is the size of block content after which a chunk is full
+	chunkBytes = 64 * 1024
+	// pipelineDepth is the number of chunks rendered ahead of the chunk being
+	// written when pipelining without parallelism
+	pipelineDepth = 64
+)
+
+// errStopped stops splitting the input once rendering has failed
+var errStopped = errors.New("rendering stopped")
+
+// chunk is a run of consecutive blocks rendered by one worker
+type chunk struct {
+	blocks []block
+	// data holds the content of the blocks, the content of block i ends at
+	// ends[i]
+	data []byte
+	ends []int
+
+	out bytes.Buffer
+	// outEnds are the lengths of out after each block rendered, when blocks
+	// are indexed
+	outEnds  []int
+	warnings []Warning
+	err      error
+	// done is closed once the chunk is rendered
+	done chan struct{}
+}
+
+func newChunk() *chunk {
+	return &chunk{done: make(chan struct{})}
+}
+
+// add copies b into the chunk and reports whether the chunk holds maxBlocks
+// blocks or is otherwise full
+func (c *chunk) add(b block, maxBlocks int) bool {
+	c.data = append(c.data, b.content...)
+	b.content = nil
+	c.blocks = append(c.blocks, b)
+	c.ends = append(c.ends, len(c.data))
+	return len(c.blocks) >= maxBlocks || len(c.data) >= chunkBytes
+}
+
+// renderParallel renders in to out as described by WithParallelism and
+// WithPipelining. The input is split on one goroutine and rendered on
+// re.parallelism others. At most twice as many chunks as workers are held in
+// memory at once, or pipelineDepth single block chunks when pipelining without
+// parallelism. When flush is not nil it is called after the output of each
+// chunk is written, and when stats is not nil lines and blocks are counted in it
+// as they are scanned.
+func (re *Renderer) renderParallel(in io.Reader, out io.Writer, flush func() error, stats *RenderStats) error {
+	workers, depth, maxBlocks := re.parallelism, re.parallelism*2, chunkBlocks
+	if workers < 2 {
+		workers, depth, maxBlocks = 1, pipelineDepth, 1
+	}
+	jobs := make(chan *chunk, workers)
+	ordered := make(chan *chunk, depth)
+	stop := make(chan struct{})
+	var scanErr error
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			st := getRenderState()
+			defer putRenderState(st)
+			st.collectWarnings = true
+			for c := range jobs {
+				re.renderChunk(st, c)
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(ordered)
+		defer close(jobs)
+		st := getRenderState()
+		defer putRenderState(st)
+		st.stats = stats
+
+		c := newChunk()
+		send := func() error {
+			select {
+			case ordered <- c:
+			case <-stop:
+				return errStopped
+			}
+			jobs <- c
+			c = newChunk()
+			return nil
+		}
+		// With WithCopyableCodeBlocks the worker rendering a code block
+		// collects its lines, so a chunk does not end inside a code block
+		code := false
+		err := re.scanBlocks(in, st, func(b block) error {
+			if b.kind == blockCodeStart || b.kind == blockCodeEnd {
+				code = b.kind == blockCodeStart && re.copyableStart != nil
+			}
+			if c.add(b, maxBlocks) && !code {
+				return send()
+			}
+			return nil
+		})
+		if errors.Is(err, errStopped) {
+			return
+		}
+		// Blocks before an error are still rendered, as they are sequentially
+		if len(c.blocks) > 0 && send() != nil {
+			return
+		}
+		scanErr = err
+	}()
+
+	var err error
+	var ix *indexer
+	if re.blockIndex != nil {
+		ix = &indexer{fn: re.blockIndex}
+	}
+	written := 0
+	for c := range ordered {
+		<-c.done
+		if re.warnings != nil {
+			for _, w := range c.warnings {
+				re.warnings(w)
+			}
+		}
+		if _, err = out.Write(c.out.Bytes()); err != nil {
+			break
+		}
+		start := 0
+		for i, end := range c.outEnds {
+			ix.add(c.blocks[i], written+start, written+end)
+			start = end
+		}
+		written += c.out.Len()
+		if err = c.err; err != nil {
+			break
+		}
+		if flush != nil {
+			if err = flush(); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		close(stop)
+		for range ordered {
+			// Let the input goroutine see stop
+		}
+	} else {
+		// ordered is closed so scanErr has been set
+		err = scanErr
+	}
+	wg.Wait()
+	return err
+}
+
+// renderChunk renders the blocks of c to c.out until a block fails
+func (re *Renderer) renderChunk(st *renderState, c *chunk) {
+	defer close(c.done)
+	start := 0
+	for i, b := range c.blocks {
+		b.content = c.data[start:c.ends[i]]
+		start = c.ends[i]
+		if err := re.renderBlock(st, b, &c.out); err != nil {
+			c.err = err
+			break
+		}
+		if re.blockIndex != nil {
+			c.outEnds = append(c.outEnds, c.out.Len())
+		}
+	}
+	c.warnings = append(c.warnings, st.warnings...)
+	st.warnings = st.warnings[:0]
+}
@@ -0,0 +1,168 @@
+package rnzml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// largeDocument spans many chunks and has a warning in every paragraph
+var largeDocument = strings.Repeat(strings.Join([]string{
+	"Here is *some* text with `formatting` and an empty span **",
+	"```",
+	"func main() {}",
+	"```",
+	"",
+}, "\n")+"\n", 1000)
+
+var paralleltests = []struct {
+	name string
+	in   string
+	opts []Option
+}{
+	{"small", "a *b*\n```\nc\n```", nil},
+	{"large", largeDocument, nil},
+	{"large canonical", largeDocument, []Option{WithCanonicalOutput(), WithTabWidth(2)}},
+	{"continued", strings.Repeat("a\\\nb\\\n", 2000) + "c", []Option{WithTrailingBackslash(TrailingBackslashJoin)}},
+	{"text error", largeDocument + "*unclosed\n" + largeDocument, nil},
+	{"code block error", largeDocument + "```\n", nil},
+	{"line limit", largeDocument, []Option{WithMaxLines(3000)}},
+	{"input limit", largeDocument, []Option{WithMaxInputBytes(50000)}},
+}
+
+// renderWithWarnings renders in and returns the output, error and warnings
+func renderWithWarnings(in string, opts ...Option) (string, error, []Warning) {
+	var warnings []Warning
+	opts = append(opts, WithWarnings(func(w Warning) {
+		warnings = append(warnings, w)
+	}))
+	out := &bytes.Buffer{}
+	err := NewRenderer(opts...).Render(strings.NewReader(in), struct{ io.Writer }{out})
+	return out.String(), err, warnings
+}
+
+func TestParallelism(t *testing.T) {
+	for _, tt := range paralleltests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, expectedErr, expectedWarnings := renderWithWarnings(tt.in, tt.opts...)
+			for _, workers := range []int{2, 3, 8} {
+				opts := append([]Option{WithParallelism(workers)}, tt.opts...)
+				out, err, warnings := renderWithWarnings(tt.in, opts...)
+				if fmt.Sprint(expectedErr) != fmt.Sprint(err) {
+					t.Errorf("%d workers expected error: '%v' got: '%v'", workers, expectedErr, err)
+				}
+				if expected != out {
+					t.Errorf("%d workers expected %d bytes of output got: %d", workers, len(expected), len(out))
+				}
+				if fmt.Sprint(expectedWarnings) != fmt.Sprint(warnings) {
+					t.Errorf("%d workers expected %d warnings got: %d", workers, len(expectedWarnings), len(warnings))
+				}
+			}
+		})
+	}
+	t.Run("Should stop at the output limit", func(t *testing.T) {
+		expected, _, _ := renderWithWarnings(largeDocument, WithMaxOutputBytes(50000))
+		out, err, _ := renderWithWarnings(largeDocument, WithMaxOutputBytes(50000), WithParallelism(4))
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != LimitOutputBytes {
+			t.Errorf("expected *LimitError got: '%v'", err)
+		}
+		if expected != out {
+			t.Errorf("expected %d bytes of output got: %d", len(expected), len(out))
+		}
+	})
+	t.Run("Should return errors from the writer", func(t *testing.T) {
+		r := NewRenderer(WithParallelism(4))
+		writeErr := errors.New("write failed")
+		err := r.Render(strings.NewReader(largeDocument), &failingWriter{err: writeErr, after: 3})
+		if !errors.Is(err, writeErr) {
+			t.Errorf("expected: '%v' got: '%v'", writeErr, err)
+		}
+	})
+}
+
+func TestPipelining(t *testing.T) {
+	for _, tt := range paralleltests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, expectedErr, expectedWarnings := renderWithWarnings(tt.in, tt.opts...)
+			for _, workers := range []int{0, 4} {
+				opts := append([]Option{WithPipelining(), WithParallelism(workers)}, tt.opts...)
+				out, err, warnings := renderWithWarnings(tt.in, opts...)
+				if fmt.Sprint(expectedErr) != fmt.Sprint(err) {
+					t.Errorf("%d workers expected error: '%v' got: '%v'", workers, expectedErr, err)
+				}
+				if expected != out {
+					t.Errorf("%d workers expected %d bytes of output got: %d", workers, len(expected), len(out))
+				}
+				if fmt.Sprint(expectedWarnings) != fmt.Sprint(warnings) {
+					t.Errorf("%d workers expected %d warnings got: %d", workers, len(expectedWarnings), len(warnings))
+				}
+			}
+		})
+	}
+	t.Run("Should return errors from the writer", func(t *testing.T) {
+		r := NewRenderer(WithPipelining())
+		writeErr := errors.New("write failed")
+		err := r.Render(strings.NewReader(largeDocument), &failingWriter{err: writeErr, after: 3})
+		if !errors.Is(err, writeErr) {
+			t.Errorf("expected: '%v' got: '%v'", writeErr, err)
+		}
+	})
+}
+
+// failingWriter fails every write after the first after writes
+type failingWriter struct {
+	err   error
+	after int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.after == 0 {
+		return 0, w.err
+	}
+	w.after--
+	return len(p), nil
+}
+
+func BenchmarkRenderParallelism(b *testing.B) {
+	r := NewRenderer(WithParallelism(4))
+	b.ReportAllocs()
+	b.SetBytes(int64(len(largeDocument)))
+	for i := 0; i < b.N; i++ {
+		if err := r.Render(strings.NewReader(largeDocument), io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// slowWriter discards output after waiting as a network connection might
+type slowWriter struct{}
+
+func (slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(50 * time.Microsecond)
+	return len(p), nil
+}
+
+func BenchmarkRenderSlowWriter(b *testing.B) {
+	for _, opt := range []struct {
+		name string
+		opts []Option
+	}{
+		{"sequential", nil},
+		{"pipelined", []Option{WithPipelining()}},
+	} {
+		b.Run(opt.name, func(b *testing.B) {
+			r := NewRenderer(opt.opts...)
+			b.SetBytes(int64(len(largeDocument)))
+			for i := 0; i < b.N; i++ {
+				if err := r.Render(strings.NewReader(largeDocument), slowWriter{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package rnzml
+
+import "bytes"
+
+// preFence starts and ends a preformatted block with WithPreformattedBlocks
+var preFence = []byte("~~~")
+
+// WithPreformattedBlocks renders blocks between lines containing only ~~~ as
+// preformatted text in a <pre> without <code>, for diagrams, console
+// transcripts and tables that are not source code. Preformatted blocks are
+// code blocks in every other way: their lines are not formatted, and they are
+// passed to a Handler and lexed as code blocks, with ~~~ as the Text of their
+// fence tokens. A line containing only ``` is a line of a preformatted block,
+// as ~~~ is of a code block. Without WithPreformattedBlocks ~~~ is text.
+func WithPreformattedBlocks() Option {
+	return func(re *Renderer) {
+		re.preformatted = true
+	}
+}
+
+// blockStart and blockEnd return the tags around a code block, or around a
+// preformatted block, which are those of a code block without <code>
+func (re *Renderer) blockStart(pre bool) []byte {
+	if pre {
+		return bytes.TrimSuffix(re.codeBlockStart, []byte(codeTextStartString))
+	}
+	return re.codeBlockStart
+}
+
+func (re *Renderer) blockEnd(pre bool) []byte {
+	if pre {
+		return bytes.TrimPrefix(re.codeBlockEnd, []byte(codeTextEndString))
+	}
+	return re.codeBlockEnd
+}
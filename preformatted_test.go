@@ -0,0 +1,86 @@
+package rnzml
+
+import (
+	"strings"
+	"testing"
+)
+
+var preformattedtests = []struct {
+	in   string
+	opts []Option
+	out  string
+}{
+	{"~~~\n+--+\n| *a* |\n~~~", nil, "<pre>+--+\n| *a* |\n</pre>\n"},
+	{"~~~\n```\na\n```\n~~~", nil, "<pre>```\na\n```\n</pre>\n"},
+	{"```\n~~~\n```", nil, "<pre><code>~~~\n</code></pre>\n"},
+	{"~~~\n<a>\n~~~\nb", []Option{WithCanonicalOutput()}, "<pre>&lt;a&gt;\n</pre>\n<p>b</p>\n"},
+	{"~~~\na\n~~~", []Option{WithDirection(DirectionRTL), WithSourceLines()}, "<pre dir=\"ltr\" data-line=\"1\">a\n</pre>\n"},
+	{"!if profile=b\n~~~\n!endif\n~~~\n!endif\nc", []Option{WithProfiles("a")}, "<p>c\n</p>\n"},
+}
+
+func TestPreformattedBlocks(t *testing.T) {
+	for _, tt := range preformattedtests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := NewRenderer(append([]Option{WithPreformattedBlocks()}, tt.opts...)...).RenderToBytes([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != string(out) {
+				t.Errorf("expected: %q got: %q", tt.out, out)
+			}
+		})
+	}
+	t.Run("Should render ~~~ as text without the option", func(t *testing.T) {
+		out, err := NewRenderer().RenderToBytes([]byte("~~~"))
+		if err != nil || string(out) != "<p>~~~\n</p>\n" {
+			t.Errorf("expected text got: %q %v", out, err)
+		}
+	})
+	t.Run("Should return an error for unclosed blocks", func(t *testing.T) {
+		_, err := NewRenderer(WithPreformattedBlocks()).RenderToBytes([]byte("a\n~~~\nb"))
+		if expected := "unclosed preformatted block (~~~) on line: 2"; err == nil || err.Error() != expected {
+			t.Errorf("expected: '%s' got: '%v'", expected, err)
+		}
+	})
+	t.Run("Should lex fences with their text", func(t *testing.T) {
+		tokens, err := lexAll("~~~\na\n~~~", WithPreformattedBlocks())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := `CodeBlockStart@1:0"~~~" CodeBlockLine@2:0"a" CodeBlockEnd@3:0"~~~"`; tokens != expected {
+			t.Errorf("expected: %s got: %s", expected, tokens)
+		}
+	})
+	t.Run("Should close unclosed blocks with error recovery", func(t *testing.T) {
+		tokens, err := lexAll("~~~\na", WithPreformattedBlocks(), WithErrorRecovery())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := `CodeBlockStart@1:0"~~~" CodeBlockLine@2:0"a" Error@1:0"" CodeBlockEnd@3:0"~~~"`; tokens != expected {
+			t.Errorf("expected: %s got: %s", expected, tokens)
+		}
+	})
+	t.Run("Should write fences with a TokenWriter", func(t *testing.T) {
+		in := "~~~\n```\n~~~\n```\n~~~\n```\n"
+		l := NewLexer(strings.NewReader(in), WithPreformattedBlocks())
+		var out strings.Builder
+		w := NewTokenWriter(&out)
+		for {
+			tok, err := l.Next()
+			if err != nil {
+				break
+			}
+			if err := w.Write(tok); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if out.String() != in {
+			t.Errorf("expected: %q got: %q", in, out.String())
+		}
+		w = NewTokenWriter(&out)
+		w.Write(Token{Kind: TokenCodeBlockStart, Text: preFence}) //nolint: errcheck
+		if err := w.Write(Token{Kind: TokenCodeBlockLine, Line: 2, Text: preFence}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package rnzml
+
+import (
+	"bytes"
+)
+
+var (
+	regionDirective    = []byte("!region ")
+	endRegionDirective = []byte("!endregion")
+)
+
+// WithRegions hides region markers, so a document with regions renders as a
+// whole page. A region starts with a line !region name and ends with a line
+// !endregion, and regions can be nested. Markers inside code blocks and joined
+// text blocks are rendered as they are written.
+func WithRegions() Option {
+	return func(re *Renderer) {
+		re.regions = true
+	}
+}
+
+// WithRegion renders only the lines inside the regions called name, including
+// the regions nested in them, so the same document can feed a full page and
+// snippets embedded elsewhere. A document without the region renders nothing.
+// WithRegion hides region markers as WithRegions does.
+func WithRegion(name string) Option {
+	return func(re *Renderer) {
+		re.regions = true
+		re.region = name
+	}
+}
+
+// region is a !region directive being scanned
+type region struct {
+	line int
+	name string
+}
+
+// region handles a !region or !endregion directive, returning true if line is
+// one
+func (s *blockScanner) region(line []byte, lineNumber int) (bool, error) {
+	switch {
+	case bytes.HasPrefix(line, regionDirective):
+		name := string(bytes.TrimSpace(line[len(regionDirective):]))
+		if name == "" {
+			return true, &SyntaxError{Problem: MissingRegionName, Line: lineNumber}
+		}
+		s.regions = append(s.regions, region{line: lineNumber, name: name})
+		if s.named == 0 && name == s.re.region {
+			s.named = len(s.regions)
+		}
+		return true, nil
+	case bytes.Equal(line, endRegionDirective):
+		if len(s.regions) == 0 {
+			return true, &SyntaxError{Problem: UnmatchedRegionEnd, Line: lineNumber}
+		}
+		if s.named == len(s.regions) {
+			s.named = 0
+		}
+		s.regions = s.regions[:len(s.regions)-1]
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,67 @@
+package rnzml
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var regiontests = []struct {
+	in     string
+	region string
+	out    string
+}{
+	{"a\n!region intro\nb\n!endregion\nc", "", "<p>a</p>\n<p>b</p>\n<p>c</p>\n"},
+	{"a\n!region intro\nb\n!endregion\nc", "intro", "<p>b</p>\n"},
+	{"!region intro\nb\n!region example\nc\n!endregion\n!endregion\nd", "intro", "<p>b</p>\n<p>c</p>\n"},
+	{"!region intro\nb\n!region example\nc\n!endregion\n!endregion\nd", "example", "<p>c</p>\n"},
+	{"!region a\nb\n!endregion\nc\n!region a\nd\n!endregion", "a", "<p>b</p>\n<p>d</p>\n"},
+	{"!region a\nb\n!endregion", "missing", ""},
+	{"!region a\n```\n!endregion\n```\n!endregion\nc", "b", ""},
+	{"!region a\n```\n!endregion\n```\n!endregion", "a", "<pre><code>!endregion\n</code></pre>\n"},
+}
+
+var regionerrortests = []struct {
+	in  string
+	err string
+}{
+	{"a\n!region intro\nb", "unclosed region (!region) on line: 2"},
+	{"a\n!endregion", "line 2: !endregion without !region"},
+	{"!region \n!endregion", "line 1: regions must be of the form !region name"},
+}
+
+func TestRegions(t *testing.T) {
+	for _, tt := range regiontests {
+		t.Run(fmt.Sprintf("%s in %q", tt.region, tt.in), func(t *testing.T) {
+			opt := WithRegions()
+			if tt.region != "" {
+				opt = WithRegion(tt.region)
+			}
+			out := &strings.Builder{}
+			if err := NewRenderer(opt, WithCanonicalOutput()).Render(strings.NewReader(tt.in), out); err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != out.String() {
+				t.Errorf("expected: %q got: %q", tt.out, out.String())
+			}
+		})
+	}
+	re := NewRenderer(WithRegions())
+	for _, tt := range regionerrortests {
+		t.Run(fmt.Sprintf("Should return an error for %q", tt.in), func(t *testing.T) {
+			if err := re.Render(strings.NewReader(tt.in), &strings.Builder{}); fmt.Sprint(err) != tt.err {
+				t.Errorf("expected: '%s' got: '%v'", tt.err, err)
+			}
+		})
+	}
+	t.Run("Should render a region inside a condition", func(t *testing.T) {
+		out := &strings.Builder{}
+		in := "!if profile=beta\n!region a\nb\n!endregion\n!else\n!region a\nc\n!endregion\n!endif"
+		if err := NewRenderer(WithRegion("a"), WithProfiles(), WithCanonicalOutput()).Render(strings.NewReader(in), out); err != nil {
+			t.Fatal(err)
+		}
+		if expected := "<p>c</p>\n"; expected != out.String() {
+			t.Errorf("expected: %q got: %q", expected, out.String())
+		}
+	})
+}
@@ -1,11 +1,23 @@
+// Package rnzml parses rnzml content and renders it to a subset of HTML.
+//
+// Rendering streams: the memory used by Render is bounded by the longest line
+// of the input, which WithMaxLineLength limits, rather than by the size of the
+// input. Features that need to hold more than a line are opt-in and document
+// what they hold, such as TrailingBackslashJoin holding the lines of a joined
+// text block, WithCopyableCodeBlocks holding the lines of a code block and
+// WithParallelism holding a fixed number of chunks of blocks.
 package rnzml
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
-	"html/template"
 	"io"
+	"net/url"
 	"strings"
+	"sync"
+	"time"
 	"unicode/utf8"
 )
 
@@ -22,12 +34,16 @@ const (
 	newlineString        = "\n"
 )
 
-var linkTemplate = template.Must(template.New("href").Parse(`<a href="{{.URL}}">{{.Label}}</a>`))
+var byteOrderMark = []byte("\uFEFF")
 
-type link struct {
-	URL   string
-	Label string
-}
+var codeFence = []byte("```")
+
+// space and lineBreakTag separate the lines of a joined text block with
+// LineBreakSpace and LineBreakTag
+var (
+	space        = []byte(" ")
+	lineBreakTag = []byte("<br>\n")
+)
 
 // Renderer provides functionality to parse and render rnzml to HTML
 type Renderer struct {
@@ -40,11 +56,180 @@ type Renderer struct {
 	codeTextStart  []byte
 	codeTextEnd    []byte
 	newline        []byte
+
+	externalLinksInNewTab bool
+	print                 bool
+	rewriteURL            URLRewriter
+	trailingBackslash     TrailingBackslashMode
+	lineBreaks            LineBreakMode
+	normalize             func(string) string
+	maxLineLength         int
+	maxInputBytes         int
+	maxLines              int
+	maxOutputBytes        int
+	tabWidth              int
+	blankWhitespaceLines  bool
+	accessibility         AccessibilityMode
+	lang                  string
+	profiles              map[string]bool
+	shortcodes            map[string]ShortcodeFunc
+	regions               bool
+	region                string
+	dir                   Direction
+	warnings              func(Warning)
+	canonical             bool
+	parallelism           int
+	pipelined             bool
+	flushBlocks           bool
+	flush                 func() error
+	stats                 func(RenderStats)
+	blockIndex            func(IndexEntry)
+	recover               bool
+	sourceLines           bool
+	preformatted          bool
+	typography            Typography
+	lineNumbers           bool
+	copyableStart         []byte
+}
+
+// TrailingBackslashMode controls how a line ending in a lone \ is rendered
+type TrailingBackslashMode int
+
+const (
+	// TrailingBackslashError returns an error with the position of the \
+	TrailingBackslashError TrailingBackslashMode = iota
+	// TrailingBackslashLiteral renders the \ as a literal backslash
+	TrailingBackslashLiteral
+	// TrailingBackslashJoin joins the next line into the same text block.
+	// Error positions in a joined text block are relative to its first line.
+	TrailingBackslashJoin
+)
+
+// LineBreakMode controls how the lines of a text block joined with
+// TrailingBackslashJoin are separated in its output
+type LineBreakMode int
+
+const (
+	// LineBreakNewline keeps the newline between the lines, which browsers
+	// display as a space
+	LineBreakNewline LineBreakMode = iota
+	// LineBreakSpace separates the lines with a space, so the text block is
+	// rendered on one line
+	LineBreakSpace
+	// LineBreakTag writes a <br> tag before the newline, so the lines are
+	// displayed as they are written
+	LineBreakTag
+)
+
+// URLKindLink is the kind passed to a URLRewriter for link URLs
+const URLKindLink = "link"
+
+// URLRewriter is called with the kind of URL and the URL as written in the
+// input. The returned URL is rendered in its place, and a returned error
+// aborts rendering.
+type URLRewriter func(kind, rawURL string) (string, error)
+
+// Option configures a Renderer
+type Option func(*Renderer)
+
+// WithExternalLinksInNewTab makes links to other hosts open in a new tab by
+// rendering them with target="_blank" and rel="noopener"
+func WithExternalLinksInNewTab() Option {
+	return func(re *Renderer) {
+		re.externalLinksInNewTab = true
+	}
+}
+
+// WithPrintOutput renders HTML for printing and archiving, where links cannot
+// be followed. Links are rendered as their label followed by their URL in
+// parentheses instead of as <a> elements, and links labelled with their URL
+// as the URL alone.
+func WithPrintOutput() Option {
+	return func(re *Renderer) {
+		re.print = true
+	}
+}
+
+// WithURLRewriter calls fn for every URL before it is rendered
+func WithURLRewriter(fn URLRewriter) Option {
+	return func(re *Renderer) {
+		re.rewriteURL = fn
+	}
+}
+
+// WithTrailingBackslash sets how a line ending in a lone \ is rendered. The
+// default is TrailingBackslashError.
+func WithTrailingBackslash(mode TrailingBackslashMode) Option {
+	return func(re *Renderer) {
+		re.trailingBackslash = mode
+	}
 }
 
-// NewRenderer returns an initialized Renderer
-func NewRenderer() *Renderer {
-	return &Renderer{
+// WithJoinedLineBreaks sets how the lines of a text block joined with
+// TrailingBackslashJoin are separated, for sites with hard-wrapped prose that
+// expect the lines to flow or to break where they are wrapped. The default is
+// LineBreakNewline. A line break in code text is a <br> tag in the code with
+// LineBreakTag.
+func WithJoinedLineBreaks(mode LineBreakMode) Option {
+	return func(re *Renderer) {
+		re.lineBreaks = mode
+	}
+}
+
+// WithNormalizer calls fn on each line of a text block before it is rendered,
+// e.g. norm.NFC.String from golang.org/x/text/unicode/norm so that documents
+// produce the same output regardless of the editor's composition form. Code
+// blocks are rendered as written.
+func WithNormalizer(fn func(string) string) Option {
+	return func(re *Renderer) {
+		re.normalize = fn
+	}
+}
+
+// WithMaxLineLength allows lines of up to n bytes. Longer lines fail with an
+// error wrapping bufio.ErrTooLong. The default is bufio.MaxScanTokenSize.
+func WithMaxLineLength(n int) Option {
+	return func(re *Renderer) {
+		re.maxLineLength = n
+	}
+}
+
+// MaxTabWidth is the largest tab width, WithTabWidth uses it for wider tabs
+const MaxTabWidth = 16
+
+// WithTabWidth expands tabs in code blocks to spaces, aligning to tab stops
+// every n columns. Widths above MaxTabWidth are limited to MaxTabWidth.
+func WithTabWidth(n int) Option {
+	if n > MaxTabWidth {
+		n = MaxTabWidth
+	}
+	return func(re *Renderer) {
+		re.tabWidth = n
+	}
+}
+
+// WithBlankWhitespaceLines treats lines outside code blocks containing only
+// whitespace as blank lines rather than rendering them as text blocks
+func WithBlankWhitespaceLines() Option {
+	return func(re *Renderer) {
+		re.blankWhitespaceLines = true
+	}
+}
+
+// WithCanonicalOutput renders HTML in a canonical form for golden file tests
+// and diffs. Text blocks are rendered without a newline before </p>, blank
+// lines outside code blocks are not rendered, and link labels are escaped
+// with the same entities as other text.
+func WithCanonicalOutput() Option {
+	return func(re *Renderer) {
+		re.canonical = true
+		re.textBlockEnd = []byte("</p>\n")
+	}
+}
+
+// NewRenderer returns an initialized Renderer configured with opts
+func NewRenderer(opts ...Option) *Renderer {
+	re := &Renderer{
 		codeBlockStart: []byte("<pre><code>"),
 		codeBlockEnd:   []byte("</code></pre>\n"),
 		textBlockStart: []byte("<p>"),
@@ -55,166 +240,705 @@ func NewRenderer() *Renderer {
 		codeTextEnd:    []byte("</code>"),
 		newline:        []byte("\n"),
 	}
+	for _, opt := range opts {
+		opt(re)
+	}
+	return re
 }
 
 // Render iterates over in line by line and either renders a text block or a
 // code block
 func (re *Renderer) Render(in io.Reader, out io.Writer) error {
-	lineCount := 0
+	return re.RenderWith(in, out, nil)
+}
 
-	codeBlockStartLine := -1
-	scanner := bufio.NewScanner(in)
-	for scanner.Scan() {
-		lineCount++
-		line := scanner.Text()
-		if line == "```" {
-			if codeBlockStartLine == -1 {
-				codeBlockStartLine = lineCount
-				if _, err := out.Write(re.codeBlockStart); err != nil {
-					return err
-				}
-			} else {
-				codeBlockStartLine = -1
-				if _, err := out.Write(re.codeBlockEnd); err != nil {
-					return err
-				}
+// RenderWith renders in to out as Render does and, in the same pass, calls h
+// with the structure of the document as Parse does, so the HTML and other
+// outputs of a document, such as a TextHandler and a LinkHandler combined
+// with MultiHandler, are produced without reading it again. Documents are
+// rendered on one goroutine when h is not nil, WithParallelism and
+// WithPipelining are ignored. When rendering fails h has been called with the
+// document up to the error.
+func (re *Renderer) RenderWith(in io.Reader, out io.Writer, h Handler) error {
+	var stats *RenderStats
+	if re.stats != nil {
+		stats = &RenderStats{}
+		start := time.Now()
+		defer func() {
+			stats.Duration = time.Since(start)
+			re.stats(*stats)
+		}()
+		in = &statsReader{r: in, n: &stats.BytesIn}
+	}
+	flush := re.flusher(out)
+	if re.maxOutputBytes > 0 {
+		out = &limitedWriter{w: out, remaining: re.maxOutputBytes, max: re.maxOutputBytes}
+	}
+	if stats != nil {
+		out = &statsWriter{w: out, n: &stats.BytesOut}
+	}
+
+	// Tags, escaped runes and newlines are written separately, so buffer them
+	// unless out already is a buffer
+	switch out.(type) {
+	case *bufio.Writer, *bytes.Buffer, *strings.Builder:
+		return re.render(in, out, flush, stats, h)
+	}
+	buffered := writerPool.Get().(*bufio.Writer)
+	buffered.Reset(out)
+	bufferedFlush := flush
+	if re.flushBlocks {
+		bufferedFlush = func() error {
+			if err := buffered.Flush(); err != nil || flush == nil {
+				return err
 			}
-		} else {
-			if codeBlockStartLine == -1 && line != "" {
-				// Write a text block line
-				if _, err := out.Write(re.textBlockStart); err != nil {
-					return err
-				}
+			return flush()
+		}
+	}
+	err := re.render(in, buffered, bufferedFlush, stats, h)
+	// Flush on error as well so that output rendered before the error is
+	// written, as it is when out is not buffered
+	if flushErr := buffered.Flush(); err == nil {
+		err = flushErr
+	}
+	// Drop the reference to out before pooling
+	buffered.Reset(nil)
+	writerPool.Put(buffered)
+	return err
+}
 
-				if err := re.renderLine(line, out); err != nil {
-					return fmt.Errorf("line %d: %w", lineCount, err)
-				}
+// RenderToBytes renders in and returns the output. The output buffer is sized
+// up front from the length of in so the common case does not grow it. When
+// rendering fails the output rendered before the error is returned with it.
+func (re *Renderer) RenderToBytes(in []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, re.estimateOutputSize(len(in))))
+	err := re.Render(bytes.NewReader(in), out)
+	return out.Bytes(), err
+}
 
-				if _, err := out.Write(re.textBlockEnd); err != nil {
-					return err
-				}
-			} else {
-				// Write a code block line
-				template.HTMLEscape(out, scanner.Bytes())
-				if _, err := out.Write(re.newline); err != nil {
-					return err
-				}
-			}
+// estimateOutputSize returns the expected size of the output for inputSize
+// bytes of input. Tags around text blocks, inline tags and escapes typically
+// add less than half to the size of text.
+func (re *Renderer) estimateOutputSize(inputSize int) int {
+	size := inputSize + inputSize/2 + len(re.textBlockStart) + len(re.textBlockEnd)
+	if re.maxOutputBytes > 0 && size > re.maxOutputBytes {
+		size = re.maxOutputBytes
+	}
+	return size
+}
+
+// render renders in to out block by block, calling flush when it is not nil
+// after each block that can be flushed, counting lines and blocks in stats
+// and calling h with the structure of the document when they are not nil
+func (re *Renderer) render(in io.Reader, out io.Writer, flush func() error, stats *RenderStats, h Handler) error {
+	if h == nil && (re.parallelism > 1 || re.pipelined) {
+		return re.renderParallel(in, out, flush, stats)
+	}
+	st := getRenderState()
+	defer putRenderState(st)
+	st.stats = stats
+	st.events = h
+	var ix *indexer
+	written := 0
+	if re.blockIndex != nil {
+		ix = &indexer{fn: re.blockIndex}
+		out = &statsWriter{w: out, n: &written}
+	}
+	return re.scanBlocks(in, st, func(b block) error {
+		start := written
+		if h != nil {
+			startEvent(h, b)
+		}
+		if err := re.renderBlock(st, b, out); err != nil {
+			return err
+		}
+		if h != nil {
+			re.endEvent(h, b)
+		}
+		ix.add(b, start, written)
+		if flush == nil || !endsBlock(b) {
+			return nil
+		}
+		return flush()
+	})
+}
+
+// blockKind is the kind of a block found in the input
+type blockKind int
+
+const (
+	// blockText is a text block, joined from several lines if continued
+	blockText blockKind = iota
+	// blockBlank is a blank line outside of a code block
+	blockBlank
+	// blockCodeStart and blockCodeEnd are the fences around a code block
+	blockCodeStart
+	blockCodeEnd
+	// blockCodeLine is a line in a code block
+	blockCodeLine
+	// blockShortcode is a line calling a shortcode
+	blockShortcode
+)
+
+// block is a part of the input that renders independently of the rest. The
+// content of a block is only valid until the func it is passed to returns.
+type block struct {
+	kind blockKind
+	// line and lastLine are the lines the block starts and ends on
+	line     int
+	lastLine int
+	content  []byte
+	// pre is true for the fences and lines of a preformatted block
+	pre bool
+	// number is the line number of a code block line with WithLineNumbers
+	number int
+}
+
+// scanBlocks reads in line by line and calls fn with each block
+func (re *Renderer) scanBlocks(in io.Reader, st *renderState, fn func(block) error) error {
+	blocks := re.newBlockScanner(in, st)
+	for {
+		b, ok, err := blocks.next()
+		if err != nil || !ok {
+			return err
+		}
+		if err := fn(b); err != nil {
+			return err
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		return err
+}
+
+// blockScanner splits its input into blocks one at a time
+type blockScanner struct {
+	re        *Renderer
+	st        *renderState
+	scanner   *bufio.Scanner
+	lineCount int
+	eof       bool
+
+	codeBlockStartLine int
+	// fence is the fence of the open code or preformatted block and number
+	// the line number of its next line
+	fence  fence
+	number int
+
+	// Text blocks continued with a trailing \ are joined into st.paragraph
+	paragraphStartLine int
+
+	// conditions are the !if directives not yet closed with WithProfiles, and
+	// skippedFence is the fence of the code block not rendered that is open
+	conditions   []condition
+	skippedFence fence
+	// regions are the !region directives not yet closed with WithRegions,
+	// named is the number of regions open when the region rendered with
+	// WithRegion started, or 0 outside of it
+	regions []region
+	named   int
+}
+
+func (re *Renderer) newBlockScanner(in io.Reader, st *renderState) blockScanner {
+	if re.maxInputBytes > 0 {
+		in = &limitedReader{r: in, remaining: re.maxInputBytes, max: re.maxInputBytes}
 	}
-	if codeBlockStartLine != -1 {
-		return fmt.Errorf("unclosed code block (```) on line: %d", codeBlockStartLine)
+
+	// bufio.ScanLines drops the \r of a \r\n line ending, so Windows line
+	// endings never reach the fence comparison or the rendered output
+	scanner := bufio.NewScanner(in)
+	maxLineLength := bufio.MaxScanTokenSize
+	if re.maxLineLength > 0 {
+		maxLineLength = re.maxLineLength + 1
+	}
+	// The buffer grows as needed, so only pay for long lines when they occur.
+	// bufio.Scanner only checks the maximum when growing, so a limit below
+	// the pooled buffer size has to cap the capacity of the initial buffer
+	buf := st.scan
+	if len(buf) > maxLineLength {
+		buf = buf[:maxLineLength:maxLineLength]
+	}
+	scanner.Buffer(buf, maxLineLength)
+	return blockScanner{
+		re:                 re,
+		st:                 st,
+		scanner:            scanner,
+		lineCount:          st.firstLine - 1,
+		codeBlockStartLine: -1,
+		paragraphStartLine: -1,
 	}
-	return nil
 }
 
-// renderLine renders a single line in a text block
-func (re *Renderer) renderLine(line string, out io.Writer) error {
-	// Reuse rune buffer for encoding to output
-	runeBuffer := []byte{4}
-	writeEscapedRune := func(r rune, out io.Writer) {
-		byteCount := utf8.EncodeRune(runeBuffer, r)
-		template.HTMLEscape(out, runeBuffer[:byteCount])
-	}
-
-	// Track position of last control characters for error reporting.
-	// When a control character occurs again reset the value.
-	lastEscape := -1
-	lastBold := -1
-	lastCode := -1
-	lastLink := -1
-
-	// Links are rendered using html/template to contextually escape content.
-	// When the link is started runes are written to linkContent, when finished
-	// linkContent is rendered to out and reset.
-	linkContent := strings.Builder{}
-
-	for n, r := range line {
-		if lastEscape > -1 {
-			// Always check for escape first
-			if lastLink > -1 {
-				linkContent.WriteRune(r) //nolint: errcheck
-			} else {
-				writeEscapedRune(r, out)
+// next returns the next block, ok is false once all blocks have been returned
+// or an error is returned. The content of the block is only valid until next
+// is called again.
+func (s *blockScanner) next() (b block, ok bool, err error) {
+	re, st := s.re, s.st
+	for !s.eof && s.scanner.Scan() {
+		s.lineCount++
+		lineCount := s.lineCount
+		if st.stats != nil {
+			st.stats.Lines++
+		}
+		if re.maxLines > 0 && lineCount > re.maxLines {
+			return b, false, &LimitError{Limit: LimitLines, Max: re.maxLines}
+		}
+		line := s.scanner.Bytes()
+		if lineCount == 1 {
+			// Editors on Windows may start files with a UTF-8 byte order mark
+			line = bytes.TrimPrefix(line, byteOrderMark)
+		}
+		if (re.profiles != nil || re.regions) && s.codeBlockStartLine == -1 && s.paragraphStartLine == -1 {
+			skip, err := s.directive(line, lineCount)
+			if err != nil {
+				return b, false, err
 			}
-			lastEscape = -1
-		} else if lastLink > -1 {
-			if r == '\\' { // Escapes still work on ] in links
-				lastEscape = n
-			} else if r == ']' { // End link is the only control character in a link
-				lastLink = -1
-
-				// Links are of the format [url label] where label can contain spaces
-				parts := strings.SplitN(linkContent.String(), " ", 2)
-				if len(parts) != 2 {
-					return fmt.Errorf("Links must have a URL and a Label separated by a space. Instead found: %s", linkContent.String())
-				}
-				err := linkTemplate.Execute(out, link{
-					URL:   parts[0],
-					Label: parts[1],
-				})
-				if err != nil {
-					return err
-				}
-				// Reset linkContent for next link
-				linkContent = strings.Builder{}
-			} else {
-				// Write current rune to current link
-				linkContent.WriteRune(r) //nolint: errcheck
+			if skip {
+				continue
+			}
+		}
+		if re.blankWhitespaceLines && s.codeBlockStartLine == -1 && len(bytes.TrimSpace(line)) == 0 {
+			line = nil
+		}
+		b = block{kind: blockCodeLine, line: lineCount, lastLine: lineCount, content: line, pre: s.fence.pre()}
+		if f := re.openingFence(line); s.paragraphStartLine == -1 && s.codeBlockStartLine == -1 && f.n > 0 {
+			s.codeBlockStartLine, s.fence, s.number = lineCount, f, f.start
+			if f.start == 0 {
+				s.number = 1
 			}
-		} else if lastCode > -1 {
-			if r == '\\' { // Escapes still work on `
-				lastEscape = n
-			} else if r == '`' { // End code is the only control character in code
-				if _, err := out.Write(re.codeTextEnd); err != nil {
-					return err
+			b.kind, b.pre = blockCodeStart, f.pre()
+		} else if s.codeBlockStartLine != -1 && s.fence.closedBy(line) {
+			s.codeBlockStartLine, s.fence = -1, fence{}
+			b.kind = blockCodeEnd
+		} else if s.codeBlockStartLine == -1 && (len(line) > 0 || s.paragraphStartLine != -1) {
+			if re.trailingBackslash == TrailingBackslashJoin && endsInEscape(line) {
+				// Join the next line into this text block
+				if s.paragraphStartLine == -1 {
+					s.paragraphStartLine = lineCount
 				}
-				lastCode = -1
-			} else {
-				writeEscapedRune(r, out)
+				st.paragraph = append(st.paragraph, line[:len(line)-1]...)
+				st.paragraph = append(st.paragraph, re.joinSeparator()...)
+				continue
+			}
+			b.kind = blockText
+			if s.paragraphStartLine == -1 && re.shortcodes != nil && isShortcode(line) {
+				b.kind = blockShortcode
+			} else if s.paragraphStartLine != -1 {
+				st.paragraph = append(st.paragraph, line...)
+				b.content = st.paragraph
+				b.line = s.paragraphStartLine
+				st.paragraph = st.paragraph[:0]
+				s.paragraphStartLine = -1
 			}
+		} else if s.codeBlockStartLine == -1 {
+			b.kind = blockBlank
 		} else {
-			switch r {
-			case '\\':
-				lastEscape = n
-			case '*':
-				if lastBold < 0 {
-					if _, err := out.Write(re.boldTextStart); err != nil {
-						return err
-					}
-					lastBold = n
-				} else {
-					if _, err := out.Write(re.boldTextEnd); err != nil {
-						return err
-					}
-					lastBold = -1
-				}
-			case '`':
-				if _, err := out.Write(re.codeTextStart); err != nil {
-					return err
-				}
-				lastCode = n
-			case '[':
-				lastLink = n
-
-			default:
-				writeEscapedRune(r, out)
+			b.number = s.number
+			s.number++
+		}
+		st.stats.count(b)
+		return b, true, nil
+	}
+	if !s.eof {
+		s.eof = true
+		if err := s.scanner.Err(); err != nil {
+			if errors.Is(err, bufio.ErrTooLong) {
+				return b, false, fmt.Errorf("line %d: %w", s.lineCount+1, err)
 			}
+			return b, false, err
 		}
+		if s.paragraphStartLine != -1 {
+			// The last line was continued, render what was joined so far
+			b = block{kind: blockText, line: s.paragraphStartLine, lastLine: s.lineCount, content: bytes.TrimSuffix(st.paragraph, re.joinSeparator())}
+			s.paragraphStartLine = -1
+			st.stats.count(b)
+			return b, true, nil
+		}
+	}
+	if s.codeBlockStartLine != -1 {
+		problem := UnclosedCodeBlock
+		if s.fence.pre() {
+			problem = UnclosedPreformattedBlock
+		}
+		return b, false, &SyntaxError{Problem: problem, Line: s.codeBlockStartLine}
+	}
+	if len(s.conditions) > 0 {
+		return b, false, &SyntaxError{Problem: UnclosedCondition, Line: s.conditions[len(s.conditions)-1].line}
 	}
+	if len(s.regions) > 0 {
+		return b, false, &SyntaxError{Problem: UnclosedRegion, Line: s.regions[len(s.regions)-1].line}
+	}
+	return b, false, nil
+}
 
-	// Check for any unclosed control characters and if so return an error
-	if lastBold > -1 {
-		return fmt.Errorf("unclosed bold text (*) at position: %d", lastBold)
+// renderBlock renders b to out
+func (re *Renderer) renderBlock(st *renderState, b block, out io.Writer) error {
+	switch b.kind {
+	case blockText:
+		return re.renderTextBlock(st, b.content, b.line, out)
+	case blockCodeStart:
+		st.scratch = re.appendBlockStart(append(st.scratch[:0], re.copyableStart...), re.blockStart(b.pre), b.line)
+		st.code = st.code[:0]
+		_, err := out.Write(st.scratch)
+		return err
+	case blockCodeEnd:
+		if re.copyableStart == nil {
+			_, err := out.Write(re.blockEnd(b.pre))
+			return err
+		}
+		st.scratch = append(append(st.scratch[:0], re.blockEnd(b.pre)...), copyableCodeStart...)
+		st.scratch = append(append(st.scratch, st.code...), copyableCodeEnd...)
+		_, err := out.Write(st.scratch)
+		return err
+	case blockShortcode:
+		return re.renderShortcode(b, out)
+	case blockBlank:
+		if re.canonical {
+			// Blank lines only separate text blocks
+			return nil
+		}
 	}
-	if lastCode > -1 {
-		return fmt.Errorf("unclosed code text (`) at position: %d", lastCode)
+
+	// Write a code block line
+	numbered := re.lineNumbers && b.kind == blockCodeLine
+	st.scratch = st.scratch[:0]
+	if numbered {
+		st.scratch = appendLineNumber(st.scratch, b.number)
 	}
-	if lastLink > -1 {
-		return fmt.Errorf("unclosed link ([) at position: %d", lastLink)
+	st.scratch = appendHTMLEscaped(st.scratch, re.codeLine(b.content))
+	if re.copyableStart != nil && b.kind == blockCodeLine {
+		// The copy is the raw line, tabs are copied as tabs
+		st.code = append(appendHTMLEscaped(st.code, validCodeLine(b.content)), '\n')
+	}
+	if numbered {
+		st.scratch = append(st.scratch, lineNumberEnd...)
+	}
+	st.scratch = append(st.scratch, re.newline...)
+	_, err := out.Write(st.scratch)
+	return err
+}
+
+// codeLine returns a line in a code block as it is rendered before escaping
+func (re *Renderer) codeLine(line []byte) []byte {
+	line = validCodeLine(line)
+	if re.tabWidth > 0 {
+		line = expandTabs(line, re.tabWidth)
+	}
+	return line
+}
+
+// validCodeLine returns a line in a code block with invalid bytes replaced
+func validCodeLine(line []byte) []byte {
+	if !utf8.Valid(line) {
+		// Text blocks decode invalid bytes to utf8.RuneError, do the same for
+		// code blocks so output is always valid UTF-8
+		line = bytes.ToValidUTF8(line, []byte(string(utf8.RuneError)))
+	}
+	return line
+}
+
+// renderTextBlock renders line as a text block starting on lineNumber
+func (re *Renderer) renderTextBlock(st *renderState, line []byte, lineNumber int, out io.Writer) error {
+	st.scratch = re.appendBlockStart(st.scratch[:0], re.textBlockStart, lineNumber)
+	if _, err := out.Write(st.scratch); err != nil {
+		return err
+	}
+
+	if re.normalize != nil {
+		line = []byte(re.normalize(string(line)))
+	}
+	if err := re.renderLine(st, line, lineNumber, out); err != nil {
+		return lineError(lineNumber, err)
+	}
+
+	if _, err := out.Write(re.textBlockEnd); err != nil {
+		return err
 	}
 	return nil
 }
+
+// joinSeparator returns what separates the lines of a joined text block
+func (re *Renderer) joinSeparator() []byte {
+	if re.lineBreaks == LineBreakSpace {
+		return space
+	}
+	return re.newline
+}
+
+// endsInEscape reports whether line ends in a \ that is not itself escaped
+func endsInEscape(line []byte) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// expandTabs replaces each tab in line with spaces up to the next multiple of
+// width columns, counting each rune as one column
+func expandTabs(line []byte, width int) []byte {
+	if bytes.IndexByte(line, '\t') == -1 {
+		return line
+	}
+	expanded := make([]byte, 0, len(line)+width)
+	column := 0
+	for len(line) > 0 {
+		r, size := utf8.DecodeRune(line)
+		if r == '\t' {
+			spaces := width - column%width
+			for i := 0; i < spaces; i++ {
+				expanded = append(expanded, ' ')
+			}
+			column += spaces
+		} else {
+			expanded = append(expanded, line[:size]...)
+			column++
+		}
+		line = line[size:]
+	}
+	return expanded
+}
+
+// renderState holds scratch buffers reused for every line rendered by a
+// single call to Render, so that rendering a line does not allocate them
+type renderState struct {
+	runeBuffer [utf8.UTFMax]byte
+	// link collects the content of the current link
+	link []byte
+	// paragraph collects the lines of a text block joined with a trailing \
+	paragraph []byte
+	// scan is the initial buffer of the line scanner
+	scan []byte
+	// scratch holds rendered links before they are written
+	scratch []byte
+	// label holds link labels with WithTypography replacements made
+	label []byte
+	// code collects the escaped lines of a code block with
+	// WithCopyableCodeBlocks
+	code []byte
+	// html renders the inline tokens of the current line
+	html htmlInline
+	// firstLine is the line number of the first line scanned
+	firstLine int
+	// stats counts lines and blocks scanned when it is not nil
+	stats *RenderStats
+	// events is passed the structure of the document rendered by RenderWith
+	events Handler
+	// collectWarnings appends warnings to warnings instead of reporting them
+	collectWarnings bool
+	warnings        []Warning
+}
+
+// Pools of render scratch state, so a Renderer shared by concurrent renders
+// does not allocate them for every call to Render
+var (
+	renderStatePool = sync.Pool{New: func() interface{} {
+		return &renderState{scan: make([]byte, 4096)}
+	}}
+	writerPool = sync.Pool{New: func() interface{} { return bufio.NewWriter(nil) }}
+)
+
+// maxPooledBufferSize is the largest scratch buffer kept when a renderState
+// is returned to the pool, so a single huge link or joined text block does not
+// stay in memory
+const maxPooledBufferSize = 64 * 1024
+
+func getRenderState() *renderState {
+	st := renderStatePool.Get().(*renderState)
+	st.link = st.link[:0]
+	st.paragraph = st.paragraph[:0]
+	st.firstLine = 1
+	st.stats = nil
+	st.events = nil
+	st.collectWarnings = false
+	st.warnings = st.warnings[:0]
+	return st
+}
+
+func putRenderState(st *renderState) {
+	if cap(st.link) > maxPooledBufferSize || cap(st.paragraph) > maxPooledBufferSize || cap(st.scratch) > maxPooledBufferSize || cap(st.label) > maxPooledBufferSize ||
+		cap(st.code) > maxPooledBufferSize {
+		return
+	}
+	renderStatePool.Put(st)
+}
+
+// renderLine renders a single line in a text block, lineNumber is used to
+// report warnings
+func (re *Renderer) renderLine(st *renderState, line []byte, lineNumber int, out io.Writer) error {
+	st.html = htmlInline{re: re, st: st, out: out, line: lineNumber, events: st.events}
+	err := re.scanInline(st, line, lineNumber, &st.html)
+	if err == nil && st.html.pendingDash {
+		_, err = out.Write(dash)
+	}
+	st.html.out = nil
+	return err
+}
+
+// htmlInline renders inline tokens as HTML, passing them to events as well
+// when it is not nil
+type htmlInline struct {
+	re     *Renderer
+	st     *renderState
+	out    io.Writer
+	line   int
+	events Handler
+	// code is true in code text, and pendingDash when a - ending text is
+	// written by the next token with WithTypography
+	code, pendingDash bool
+}
+
+func (h *htmlInline) inline(kind inlineKind, position int, text []byte) error {
+	if h.events != nil && kind != inlineLink {
+		inlineEvent(h.events, kind, text)
+	}
+	if h.pendingDash {
+		h.pendingDash = false
+		if kind == inlineRune && text[0] == '>' {
+			_, err := h.out.Write([]byte(arrow))
+			return err
+		}
+		if _, err := h.out.Write(dash); err != nil {
+			return err
+		}
+	}
+	var err error
+	switch kind {
+	case inlineText:
+		if h.re.typography != 0 && !h.code {
+			h.st.scratch, h.pendingDash = appendTypography(h.st.scratch[:0], text, h.re.typography)
+			text = h.st.scratch
+		}
+		if h.re.lineBreaks == LineBreakTag {
+			err = h.writeLineBreaks(text)
+		} else {
+			_, err = h.out.Write(text)
+		}
+	case inlineRune, inlineEscaped:
+		if c := text[0]; int(c) < len(htmlEscapes) && htmlEscapes[c] != nil {
+			text = htmlEscapes[c]
+		}
+		_, err = h.out.Write(text)
+	case inlineBoldStart:
+		_, err = h.out.Write(h.re.boldTextStart)
+	case inlineBoldEnd:
+		_, err = h.out.Write(h.re.boldTextEnd)
+	case inlineCodeStart:
+		h.code = true
+		_, err = h.out.Write(h.re.codeTextStart)
+	case inlineCodeEnd:
+		h.code = false
+		_, err = h.out.Write(h.re.codeTextEnd)
+	case inlineLink:
+		var href string
+		var label []byte
+		if href, label, err = h.re.link(h.st, h.line, position); err != nil {
+			return err
+		}
+		if h.events != nil {
+			h.events.Link(href, label)
+		}
+		err = h.re.writeLink(h.st, href, label, h.out)
+	}
+	return err
+}
+
+// writeLineBreaks writes text with a <br> tag before each newline
+func (h *htmlInline) writeLineBreaks(text []byte) error {
+	for {
+		i := bytes.IndexByte(text, '\n')
+		if i == -1 {
+			_, err := h.out.Write(text)
+			return err
+		}
+		if _, err := h.out.Write(text[:i]); err != nil {
+			return err
+		}
+		if _, err := h.out.Write(lineBreakTag); err != nil {
+			return err
+		}
+		text = text[i+1:]
+	}
+}
+
+// splitLink splits the content of a link into its URL and label
+func splitLink(content []byte) (rawURL, label []byte) {
+	if i := bytes.IndexByte(content, ' '); i != -1 {
+		return content[:i], content[i+1:]
+	}
+	return content, content
+}
+
+// link splits the content of the link in st.link started at position. Links
+// are of the format [url label] where label can contain spaces, or [url] which
+// uses the url as the label. The returned URL is rewritten and normalized.
+func (re *Renderer) link(st *renderState, lineNumber, position int) (string, []byte, error) {
+	content := st.link
+	rawURL, label := splitLink(content)
+	if len(rawURL) == 0 {
+		return "", nil, &SyntaxError{Problem: MissingLinkURL, Position: position, Link: string(content)}
+	}
+
+	href := string(rawURL)
+	re.warnLink(st, lineNumber, position, href, label)
+	if err := re.checkLinkLabel(st, lineNumber, position, rawURL, label); err != nil {
+		return "", nil, err
+	}
+	if re.rewriteURL != nil {
+		var err error
+		if href, err = re.rewriteURL(URLKindLink, href); err != nil {
+			return "", nil, err
+		}
+	}
+	return NormalizeURL(href), label, nil
+}
+
+// writeLink writes the link to href, a URL returned by link, with label
+func (re *Renderer) writeLink(st *renderState, href string, label []byte, out io.Writer) error {
+	labelIsURL := string(label) == href
+	if re.typography != 0 && !labelIsURL {
+		var pending bool
+		if st.label, pending = appendTypography(st.label[:0], label, re.typography); pending {
+			st.label = append(st.label, '-')
+		}
+		label = st.label
+	}
+	if re.print {
+		b := appendHTMLEscaped(st.scratch[:0], label)
+		if !labelIsURL {
+			b = append(appendHTMLEscaped(append(b, " ("...), []byte(href)), ')')
+		}
+		st.scratch = b
+		_, err := out.Write(b)
+		return err
+	}
+	if !isSafeURL(href) {
+		href = failsafeURL
+	}
+
+	// Write the whole link at once from st.scratch
+	b := append(st.scratch[:0], `<a href="`...)
+	b = appendTemplateEscaped(b, []byte(href))
+	if re.externalLinksInNewTab && isExternalURL(href) {
+		b = append(b, `" target="_blank" rel="noopener">`...)
+	} else {
+		b = append(b, `">`...)
+	}
+	if re.canonical {
+		// Escape labels the same way as the rest of the text
+		b = appendHTMLEscaped(b, label)
+	} else {
+		b = appendTemplateEscaped(b, label)
+	}
+	b = append(b, "</a>"...)
+	st.scratch = b
+	_, err := out.Write(b)
+	return err
+}
+
+// isExternalURL reports whether rawURL points at another host, either as an
+// absolute http(s) URL or a protocol relative one
+func isExternalURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "" || u.Scheme == "http" || u.Scheme == "https"
+}
@@ -1,9 +1,20 @@
 package rnzml
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"go/build"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+
+	"github.com/Resonance1584/rnzml/htmlcheck"
 )
 
 var r = NewRenderer()
@@ -68,6 +79,26 @@ func TestRender(t *testing.T) {
 			t.Errorf("expected: '%s'(%x) got: '%s'(%x)", expected, []byte(expected), out.String(), []byte(out.String()))
 		}
 	})
+	t.Run("Should handle CRLF line endings", func(t *testing.T) {
+		out := &strings.Builder{}
+		expected := "<pre><code>a\n</code></pre>\n<p>b\n</p>\n<p>c\n</p>\n"
+		err := r.Render(strings.NewReader("```\r\na\r\n```\r\nb\r\nc\r"), out)
+		if err != nil {
+			t.Error(err)
+		} else if expected != out.String() {
+			t.Errorf("expected: '%s'(%x) got: '%s'(%x)", expected, []byte(expected), out.String(), []byte(out.String()))
+		}
+	})
+	t.Run("Should strip a leading byte order mark", func(t *testing.T) {
+		out := &strings.Builder{}
+		expected := "<pre><code>a\n</code></pre>\n<p>\uFEFFb\n</p>\n"
+		err := r.Render(strings.NewReader("\uFEFF```\na\n```\n\uFEFFb"), out)
+		if err != nil {
+			t.Error(err)
+		} else if expected != out.String() {
+			t.Errorf("expected: '%s'(%x) got: '%s'(%x)", expected, []byte(expected), out.String(), []byte(out.String()))
+		}
+	})
 	t.Run("Should check for closing code blocks", func(t *testing.T) {
 		out := &strings.Builder{}
 		err := r.Render(strings.NewReader("```"), out)
@@ -92,7 +123,7 @@ func TestRenderLine(t *testing.T) {
 	t.Run("Should make text encased in '*' bold", func(t *testing.T) {
 		out := &strings.Builder{}
 		expected := "a <strong>bold</strong> word"
-		err := r.renderLine("a *bold* word", out)
+		err := r.renderLine(&renderState{}, []byte("a *bold* word"), 1, out)
 		if err != nil {
 			t.Error(err)
 		} else if expected != out.String() {
@@ -101,7 +132,7 @@ func TestRenderLine(t *testing.T) {
 	})
 	t.Run("Should check for unclosed '*'", func(t *testing.T) {
 		out := &strings.Builder{}
-		err := r.renderLine("a *unclosed bold", out)
+		err := r.renderLine(&renderState{}, []byte("a *unclosed bold"), 1, out)
 		if err == nil {
 			t.Errorf("expected error")
 		}
@@ -109,7 +140,7 @@ func TestRenderLine(t *testing.T) {
 	t.Run("Should make text incased in '`' code", func(t *testing.T) {
 		out := &strings.Builder{}
 		expected := "a <code>programmer</code> word"
-		err := r.renderLine("a `programmer` word", out)
+		err := r.renderLine(&renderState{}, []byte("a `programmer` word"), 1, out)
 		if err != nil {
 			t.Error(err)
 		} else if expected != out.String() {
@@ -118,7 +149,7 @@ func TestRenderLine(t *testing.T) {
 	})
 	t.Run("Should check for unclosed '`'", func(t *testing.T) {
 		out := &strings.Builder{}
-		err := r.renderLine("a `unclosed programmer", out)
+		err := r.renderLine(&renderState{}, []byte("a `unclosed programmer"), 1, out)
 		if err == nil {
 			t.Errorf("expected error")
 		}
@@ -126,7 +157,17 @@ func TestRenderLine(t *testing.T) {
 	t.Run("Should not allow other formatting in code blocks", func(t *testing.T) {
 		out := &strings.Builder{}
 		expected := "<code>a := *p</code>"
-		err := r.renderLine("`a := *p`", out)
+		err := r.renderLine(&renderState{}, []byte("`a := *p`"), 1, out)
+		if err != nil {
+			t.Error(err)
+		} else if expected != out.String() {
+			t.Errorf("expected: '%s' got: '%s'", expected, out.String())
+		}
+	})
+	t.Run("Should render multi-byte runes", func(t *testing.T) {
+		out := &strings.Builder{}
+		expected := "<strong>café</strong> 日本"
+		err := r.renderLine(&renderState{}, []byte("*café* 日本"), 1, out)
 		if err != nil {
 			t.Error(err)
 		} else if expected != out.String() {
@@ -136,7 +177,7 @@ func TestRenderLine(t *testing.T) {
 	t.Run("Should escape basic HTML control characters", func(t *testing.T) {
 		out := &strings.Builder{}
 		expected := "&lt;script&gt;alert(&#39;xss&#39;)&lt;/script&gt;"
-		err := r.renderLine("<script>alert('xss')</script>", out)
+		err := r.renderLine(&renderState{}, []byte("<script>alert('xss')</script>"), 1, out)
 		if err != nil {
 			t.Error(err)
 		} else if expected != out.String() {
@@ -149,9 +190,7 @@ var escapetests = []struct {
 	in  string
 	out string
 }{
-	{`\`, ""},
 	{`\\`, `\`},
-	{`\\\`, `\`},
 	{`\\\\`, `\\`},
 	{`\*`, `*`},
 	{`\\**`, `\<strong></strong>`},
@@ -161,7 +200,77 @@ func TestEscapes(t *testing.T) {
 	for _, tt := range escapetests {
 		t.Run(tt.in, func(t *testing.T) {
 			out := &strings.Builder{}
-			err := r.renderLine(tt.in, out)
+			err := r.renderLine(&renderState{}, []byte(tt.in), 1, out)
+			if err != nil {
+				t.Errorf("error: %s", err.Error())
+			} else if tt.out != out.String() {
+				t.Errorf("expected: '%s' got: '%s'", tt.out, out.String())
+			}
+		})
+	}
+}
+
+var trailingbackslashtests = []struct {
+	in   string
+	mode TrailingBackslashMode
+	out  string
+	err  bool
+}{
+	{`\`, TrailingBackslashError, "", true},
+	{`\\\`, TrailingBackslashError, "", true},
+	{`a\\`, TrailingBackslashError, `a\`, false},
+	{`\`, TrailingBackslashLiteral, `\`, false},
+	{`\\\`, TrailingBackslashLiteral, `\\`, false},
+	{`a \`, TrailingBackslashLiteral, `a \`, false},
+}
+
+func TestTrailingBackslash(t *testing.T) {
+	for _, tt := range trailingbackslashtests {
+		t.Run(tt.in, func(t *testing.T) {
+			r := NewRenderer(WithTrailingBackslash(tt.mode))
+			out := &strings.Builder{}
+			err := r.renderLine(&renderState{}, []byte(tt.in), 1, out)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+			} else if err != nil {
+				t.Errorf("error: %s", err.Error())
+			} else if tt.out != out.String() {
+				t.Errorf("expected: '%s' got: '%s'", tt.out, out.String())
+			}
+		})
+	}
+	t.Run("Should report the position of the trailing backslash", func(t *testing.T) {
+		out := &strings.Builder{}
+		expected := "line 1: unclosed escape (\\) at position: 2"
+		err := r.Render(strings.NewReader(`ab\`), out)
+		if err == nil {
+			t.Error("expected error")
+		} else if expected != err.Error() {
+			t.Errorf("expected: '%s' got: '%s'", expected, err.Error())
+		}
+	})
+}
+
+var continuationtests = []struct {
+	in  string
+	out string
+}{
+	{"a\\\nb", "<p>a\nb\n</p>\n"},
+	{"a \\\nb \\\nc\nd", "<p>a \nb \nc\n</p>\n<p>d\n</p>\n"},
+	{"a\\\\\nb", "<p>a\\\n</p>\n<p>b\n</p>\n"},
+	{"*a\\\nb*", "<p><strong>a\nb</strong>\n</p>\n"},
+	{"a\\\n\nb", "<p>a\n\n</p>\n<p>b\n</p>\n"},
+	{"a\\", "<p>a\n</p>\n"},
+}
+
+func TestLineContinuation(t *testing.T) {
+	r := NewRenderer(WithTrailingBackslash(TrailingBackslashJoin))
+	for _, tt := range continuationtests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := &strings.Builder{}
+			err := r.Render(strings.NewReader(tt.in), out)
 			if err != nil {
 				t.Errorf("error: %s", err.Error())
 			} else if tt.out != out.String() {
@@ -169,6 +278,61 @@ func TestEscapes(t *testing.T) {
 			}
 		})
 	}
+	t.Run("Should join a fence as text", func(t *testing.T) {
+		out := &strings.Builder{}
+		expected := "line 1: unclosed code text (`) at position: 4"
+		err := r.Render(strings.NewReader("a\\\n```\nb"), out)
+		if err == nil {
+			t.Error("expected error")
+		} else if expected != err.Error() {
+			t.Errorf("expected: '%s' got: '%s'", expected, err.Error())
+		}
+	})
+	t.Run("Should report errors on the first line of a joined text block", func(t *testing.T) {
+		out := &strings.Builder{}
+		expected := "line 2: unclosed bold text (*) at position: 4"
+		err := r.Render(strings.NewReader("a\nb\\\nc *d"), out)
+		if err == nil {
+			t.Error("expected error")
+		} else if expected != err.Error() {
+			t.Errorf("expected: '%s' got: '%s'", expected, err.Error())
+		}
+	})
+}
+
+var linebreaktests = []struct {
+	in   string
+	mode LineBreakMode
+	out  string
+}{
+	{"a\\\nb", LineBreakNewline, "<p>a\nb\n</p>\n"},
+	{"a\\\nb\\\nc", LineBreakSpace, "<p>a b c\n</p>\n"},
+	{"a\\", LineBreakSpace, "<p>a\n</p>\n"},
+	{"a\\\nb\\\nc", LineBreakTag, "<p>a<br>\nb<br>\nc\n</p>\n"},
+	{"*a\\\nb* `c\\\nd`", LineBreakTag, "<p><strong>a<br>\nb</strong> <code>c<br>\nd</code>\n</p>\n"},
+	{"a<\\\n<b", LineBreakTag, "<p>a&lt;<br>\n&lt;b\n</p>\n"},
+}
+
+func TestJoinedLineBreaks(t *testing.T) {
+	for _, tt := range linebreaktests {
+		t.Run(tt.in, func(t *testing.T) {
+			r := NewRenderer(WithTrailingBackslash(TrailingBackslashJoin), WithJoinedLineBreaks(tt.mode))
+			out, err := r.RenderToBytes([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != string(out) {
+				t.Errorf("expected: %q got: %q", tt.out, out)
+			}
+		})
+	}
+	t.Run("Should keep error positions with spaces", func(t *testing.T) {
+		r := NewRenderer(WithTrailingBackslash(TrailingBackslashJoin), WithJoinedLineBreaks(LineBreakSpace))
+		expected := "line 1: unclosed bold text (*) at position: 4"
+		if _, err := r.RenderToBytes([]byte("a\\\nb *c")); err == nil || err.Error() != expected {
+			t.Errorf("expected: '%s' got: '%v'", expected, err)
+		}
+	})
 }
 
 var linktests = []struct {
@@ -185,9 +349,13 @@ var linktests = []struct {
 	{`[1 \\*2*3]`, `<a href="1">\*2*3</a>`, false},
 	{`[1 \]]`, `<a href="1">]</a>`, false},
 	{`[1 <]`, `<a href="1">&lt;</a>`, false},
-	{`[<a 2]`, `<a href="%3ca">2</a>`, false},
+	{`[<a 2]`, `<a href="%3Ca">2</a>`, false},
+	{`[https://res.nz/%3Ca 2]`, `<a href="https://res.nz/%3Ca">2</a>`, false},
+	{`[https://bücher.example 2]`, `<a href="https://xn--bcher-kva.example">2</a>`, false},
 	{`[1 2`, "", true},
-	{`[1]`, "", true},
+	{`[1]`, `<a href="1">1</a>`, false},
+	{`[https://res.nz/a?b=1&c=2]`, `<a href="https://res.nz/a?b=1&amp;c=2">https://res.nz/a?b=1&amp;c=2</a>`, false},
+	{`[ 1]`, "", true},
 	{`[]`, "", true},
 }
 
@@ -195,7 +363,7 @@ func TestLinks(t *testing.T) {
 	for _, tt := range linktests {
 		t.Run(tt.in, func(t *testing.T) {
 			out := &strings.Builder{}
-			err := r.renderLine(tt.in, out)
+			err := r.renderLine(&renderState{}, []byte(tt.in), 1, out)
 			if tt.err {
 				if err == nil {
 					t.Errorf("expected error")
@@ -208,3 +376,592 @@ func TestLinks(t *testing.T) {
 		})
 	}
 }
+
+var newtablinktests = []struct {
+	in  string
+	out string
+}{
+	{`[https://res.nz a]`, `<a href="https://res.nz" target="_blank" rel="noopener">a</a>`},
+	{`[http://res.nz/path a]`, `<a href="http://res.nz/path" target="_blank" rel="noopener">a</a>`},
+	{`[//res.nz a]`, `<a href="//res.nz" target="_blank" rel="noopener">a</a>`},
+	{`[/path a]`, `<a href="/path">a</a>`},
+	{`[#anchor a]`, `<a href="#anchor">a</a>`},
+	{`[mailto:a@res.nz a]`, `<a href="mailto:a@res.nz">a</a>`},
+}
+
+func TestExternalLinksInNewTab(t *testing.T) {
+	r := NewRenderer(WithExternalLinksInNewTab())
+	for _, tt := range newtablinktests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := &strings.Builder{}
+			err := r.renderLine(&renderState{}, []byte(tt.in), 1, out)
+			if err != nil {
+				t.Errorf("error: %s", err.Error())
+			} else if tt.out != out.String() {
+				t.Errorf("expected: '%s' got: '%s'", tt.out, out.String())
+			}
+		})
+	}
+}
+
+var printlinktests = []struct {
+	in  string
+	out string
+}{
+	{`[https://res.nz The res.nz website]`, `The res.nz website (https://res.nz)`},
+	{`a [https://res.nz]`, `a https://res.nz`},
+	{`[/a?b=1&c=2 <b>]`, `&lt;b&gt; (/a?b=1&amp;c=2)`},
+	{`[javascript:alert(1) a]`, `a (javascript:alert%281%29)`},
+}
+
+func TestPrintOutput(t *testing.T) {
+	r := NewRenderer(WithPrintOutput(), WithExternalLinksInNewTab())
+	for _, tt := range printlinktests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := &strings.Builder{}
+			err := r.renderLine(&renderState{}, []byte(tt.in), 1, out)
+			if err != nil {
+				t.Errorf("error: %s", err.Error())
+			} else if tt.out != out.String() {
+				t.Errorf("expected: '%s' got: '%s'", tt.out, out.String())
+			}
+		})
+	}
+}
+
+func TestURLRewriter(t *testing.T) {
+	t.Run("Should render the rewritten URL", func(t *testing.T) {
+		var kind string
+		r := NewRenderer(WithURLRewriter(func(k, rawURL string) (string, error) {
+			kind = k
+			return "https://cdn.res.nz" + rawURL, nil
+		}))
+		out := &strings.Builder{}
+		expected := `<a href="https://cdn.res.nz/img.png">a</a>`
+		err := r.renderLine(&renderState{}, []byte("[/img.png a]"), 1, out)
+		if err != nil {
+			t.Error(err)
+		} else if expected != out.String() {
+			t.Errorf("expected: '%s' got: '%s'", expected, out.String())
+		}
+		if kind != URLKindLink {
+			t.Errorf("expected: '%s' got: '%s'", URLKindLink, kind)
+		}
+	})
+	t.Run("Should return errors from the rewriter", func(t *testing.T) {
+		r := NewRenderer(WithURLRewriter(func(k, rawURL string) (string, error) {
+			return "", errors.New("rejected")
+		}))
+		out := &strings.Builder{}
+		expected := "line 1: rejected"
+		err := r.Render(strings.NewReader("[/a a]"), out)
+		if err == nil {
+			t.Error("expected error")
+		} else if expected != err.Error() {
+			t.Errorf("expected: '%s' got: '%s'", expected, err.Error())
+		}
+	})
+}
+
+func TestNormalizer(t *testing.T) {
+	// Compose e followed by a combining acute accent as a stand-in for NFC
+	compose := func(s string) string {
+		return strings.ReplaceAll(s, "e\u0301", "\u00e9")
+	}
+	r := NewRenderer(WithNormalizer(compose))
+	in := strings.Join([]string{
+		"cafe\u0301",
+		"```",
+		"cafe\u0301",
+		"```",
+	}, "\n")
+	expected := "<p>caf\u00e9\n</p>\n<pre><code>cafe\u0301\n</code></pre>\n"
+	out := &strings.Builder{}
+	err := r.Render(strings.NewReader(in), out)
+	if err != nil {
+		t.Error(err)
+	} else if expected != out.String() {
+		t.Errorf("expected: '%s'(%x) got: '%s'(%x)", expected, []byte(expected), out.String(), []byte(out.String()))
+	}
+}
+
+func TestMaxLineLength(t *testing.T) {
+	long := strings.Repeat("a", bufio.MaxScanTokenSize)
+	t.Run("Should fail on lines longer than the default", func(t *testing.T) {
+		out := &strings.Builder{}
+		err := r.Render(strings.NewReader("a\n"+long), out)
+		if !errors.Is(err, bufio.ErrTooLong) {
+			t.Errorf("expected: '%s' got: '%v'", bufio.ErrTooLong, err)
+		} else if !strings.HasPrefix(err.Error(), "line 2: ") {
+			t.Errorf("expected line number in error got: '%s'", err.Error())
+		}
+	})
+	t.Run("Should render lines up to the configured maximum", func(t *testing.T) {
+		r := NewRenderer(WithMaxLineLength(len(long)))
+		out := &strings.Builder{}
+		expected := "<p>" + long + "\n</p>\n"
+		err := r.Render(strings.NewReader(long+"\n"), out)
+		if err != nil {
+			t.Error(err)
+		} else if expected != out.String() {
+			t.Errorf("expected %d bytes got: %d", len(expected), out.Len())
+		}
+	})
+	t.Run("Should fail on lines longer than the configured maximum", func(t *testing.T) {
+		r := NewRenderer(WithMaxLineLength(len(long) - 1))
+		out := &strings.Builder{}
+		err := r.Render(strings.NewReader(long), out)
+		if !errors.Is(err, bufio.ErrTooLong) {
+			t.Errorf("expected: '%s' got: '%v'", bufio.ErrTooLong, err)
+		}
+	})
+	t.Run("Should apply limits smaller than the scan buffer", func(t *testing.T) {
+		r := NewRenderer(WithMaxLineLength(10))
+		if out, err := r.RenderToBytes([]byte(strings.Repeat("a", 10) + "\n")); err != nil {
+			t.Error(err)
+		} else if expected := "<p>aaaaaaaaaa\n</p>\n"; string(out) != expected {
+			t.Errorf("expected: '%s' got: '%s'", expected, out)
+		}
+		_, err := r.RenderToBytes([]byte(strings.Repeat("a", 1000)))
+		if !errors.Is(err, bufio.ErrTooLong) {
+			t.Errorf("expected: '%s' got: '%v'", bufio.ErrTooLong, err)
+		}
+	})
+}
+
+var tabtests = []struct {
+	in  string
+	out string
+}{
+	{"\ta", "    a"},
+	{"a\tb", "a   b"},
+	{"abcd\te", "abcd    e"},
+	{"\t\ta", "        a"},
+	{"é\tb", "é   b"},
+	{"a b", "a b"},
+}
+
+func TestTabWidth(t *testing.T) {
+	r := NewRenderer(WithTabWidth(4))
+	for _, tt := range tabtests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := &strings.Builder{}
+			expected := "<pre><code>" + tt.out + "\n</code></pre>\n"
+			err := r.Render(strings.NewReader("```\n"+tt.in+"\n```"), out)
+			if err != nil {
+				t.Errorf("error: %s", err.Error())
+			} else if expected != out.String() {
+				t.Errorf("expected: '%s' got: '%s'", expected, out.String())
+			}
+		})
+	}
+	t.Run("Should not expand tabs in text blocks", func(t *testing.T) {
+		out := &strings.Builder{}
+		expected := "<p>a\tb\n</p>\n"
+		err := r.Render(strings.NewReader("a\tb"), out)
+		if err != nil {
+			t.Error(err)
+		} else if expected != out.String() {
+			t.Errorf("expected: '%s' got: '%s'", expected, out.String())
+		}
+	})
+	t.Run("Should limit tabs to MaxTabWidth", func(t *testing.T) {
+		r := NewRenderer(WithTabWidth(1 << 62))
+		out := &strings.Builder{}
+		expected := "<pre><code>" + strings.Repeat(" ", MaxTabWidth) + "a\n</code></pre>\n"
+		err := r.Render(strings.NewReader("```\n\ta\n```"), out)
+		if err != nil {
+			t.Error(err)
+		} else if expected != out.String() {
+			t.Errorf("expected: '%s' got: '%s'", expected, out.String())
+		}
+	})
+}
+
+func TestBlankWhitespaceLines(t *testing.T) {
+	in := strings.Join([]string{
+		"a",
+		"   ",
+		"\t",
+		"```",
+		"  ",
+		"```",
+	}, "\n")
+	t.Run("Should render whitespace-only lines as text blocks by default", func(t *testing.T) {
+		out := &strings.Builder{}
+		expected := "<p>a\n</p>\n<p>   \n</p>\n<p>\t\n</p>\n<pre><code>  \n</code></pre>\n"
+		err := r.Render(strings.NewReader(in), out)
+		if err != nil {
+			t.Error(err)
+		} else if expected != out.String() {
+			t.Errorf("expected: '%s' got: '%s'", expected, out.String())
+		}
+	})
+	t.Run("Should treat whitespace-only lines as blank", func(t *testing.T) {
+		r := NewRenderer(WithBlankWhitespaceLines())
+		out := &strings.Builder{}
+		expected := "<p>a\n</p>\n\n\n<pre><code>  \n</code></pre>\n"
+		err := r.Render(strings.NewReader(in), out)
+		if err != nil {
+			t.Error(err)
+		} else if expected != out.String() {
+			t.Errorf("expected: '%s' got: '%s'", expected, out.String())
+		}
+	})
+}
+
+func TestCanonicalOutput(t *testing.T) {
+	r := NewRenderer(WithCanonicalOutput())
+	in := strings.Join([]string{
+		"a+b [/c a+b's]",
+		"",
+		"```",
+		"",
+		"```",
+		"",
+		"d",
+	}, "\n")
+	expected := strings.Join([]string{
+		`<p>a+b <a href="/c">a+b&#39;s</a></p>`,
+		"<pre><code>",
+		"</code></pre>",
+		"<p>d</p>\n",
+	}, "\n")
+	out := &strings.Builder{}
+	err := r.Render(strings.NewReader(in), out)
+	if err != nil {
+		t.Error(err)
+	} else if expected != out.String() {
+		t.Errorf("expected: '%s' got: '%s'", expected, out.String())
+	}
+}
+
+func TestWellFormed(t *testing.T) {
+	in := strings.Join([]string{
+		"Here is *some* text with `formatting`",
+		"Here is a [https://res.nz/<a> *label*] link and [/path]",
+		"",
+		"```",
+		"<p>code</p> *not bold*",
+		"```",
+		"`<strong>` \\*\\`\\[",
+	}, "\n")
+	renderers := []*Renderer{
+		NewRenderer(),
+		NewRenderer(WithExternalLinksInNewTab(), WithCanonicalOutput()),
+	}
+	for _, r := range renderers {
+		out := &strings.Builder{}
+		if err := r.Render(strings.NewReader(in), out); err != nil {
+			t.Error(err)
+		} else if err := htmlcheck.CheckBytes([]byte(out.String())); err != nil {
+			t.Errorf("%s in: '%s'", err.Error(), out.String())
+		}
+	}
+}
+
+var benchmarkDocument = strings.Repeat(strings.Join([]string{
+	"Here is some text with no formatting at all, as most lines of a comment are",
+	"Here is *some* text with `formatting` and a [https://res.nz/path?a=1 link]",
+	"```",
+	"func main() {",
+	"	fmt.Println(\"<html>\")",
+	"}",
+	"```",
+	"",
+}, "\n"), 100)
+
+func BenchmarkRender(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkDocument)))
+	for i := 0; i < b.N; i++ {
+		if err := r.Render(strings.NewReader(benchmarkDocument), io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRenderLineAllocations(t *testing.T) {
+	st := &renderState{}
+	line := []byte("Here is *some* text with `formatting` and \\*escapes\\* in 日本語")
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := r.renderLine(st, line, 1, io.Discard); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected: 0 allocations got: %v", allocs)
+	}
+}
+
+// countingWriter counts calls to Write
+type countingWriter struct {
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return len(p), nil
+}
+
+func TestOutputBuffering(t *testing.T) {
+	in := strings.Repeat("Here is *some* text with `formatting`\n", 10)
+	t.Run("Should buffer writes to out", func(t *testing.T) {
+		out := &countingWriter{}
+		err := r.Render(strings.NewReader(in), out)
+		if err != nil {
+			t.Error(err)
+		} else if out.writes != 1 {
+			t.Errorf("expected: 1 write got: %d", out.writes)
+		}
+	})
+	t.Run("Should flush output rendered before an error", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		expected := "<p>a\n</p>\n<p><strong>b"
+		err := r.Render(strings.NewReader("a\n*b"), struct{ io.Writer }{out})
+		if err == nil {
+			t.Error("expected error")
+		} else if expected != out.String() {
+			t.Errorf("expected: '%s' got: '%s'", expected, out.String())
+		}
+	})
+}
+
+func TestConcurrentRender(t *testing.T) {
+	r := NewRenderer()
+	expected := &strings.Builder{}
+	if err := r.Render(strings.NewReader(benchmarkDocument), expected); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				out := &bytes.Buffer{}
+				if err := r.Render(strings.NewReader(benchmarkDocument), struct{ io.Writer }{out}); err != nil {
+					t.Error(err)
+				} else if expected.String() != out.String() {
+					t.Error("expected concurrent renders to match")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPlainSpans(t *testing.T) {
+	t.Run("Should write a line without markup in one write", func(t *testing.T) {
+		out := &countingWriter{}
+		err := r.renderLine(&renderState{}, []byte("Here is some plain text in 日本語 with no markup"), 1, out)
+		if err != nil {
+			t.Error(err)
+		} else if out.writes != 1 {
+			t.Errorf("expected: 1 write got: %d", out.writes)
+		}
+	})
+	t.Run("Should decode invalid UTF-8 in a plain span", func(t *testing.T) {
+		out := &strings.Builder{}
+		expected := "a�b <code>�</code>"
+		err := r.renderLine(&renderState{}, []byte("a\xffb `\xff`"), 1, out)
+		if err != nil {
+			t.Error(err)
+		} else if expected != out.String() {
+			t.Errorf("expected: '%s' got: '%s'", expected, out.String())
+		}
+	})
+}
+
+func BenchmarkRenderParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkDocument)))
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := r.Render(strings.NewReader(benchmarkDocument), io.Discard); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRenderLine(b *testing.B) {
+	st := &renderState{}
+	line := []byte("Here is some text with *formatting* and `code`, as most lines of a comment are")
+	b.ReportAllocs()
+	b.SetBytes(int64(len(line)))
+	for i := 0; i < b.N; i++ {
+		if err := r.renderLine(st, line, 1, io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// streamReader generates size bytes of rnzml, sampling the live heap every
+// sampleEvery bytes
+type streamReader struct {
+	size, read  int
+	sampleEvery int
+	nextSample  int
+	maxHeap     uint64
+	pending     []byte
+}
+
+var streamChunk = []byte(strings.Join([]string{
+	"Here is *some* text with `formatting` and a [https://res.nz/path link]",
+	"```",
+	"code",
+	"```",
+	"",
+}, "\n") + "\n")
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	if s.read >= s.size {
+		return 0, io.EOF
+	}
+	if s.read >= s.nextSample {
+		runtime.GC()
+		stats := runtime.MemStats{}
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc > s.maxHeap {
+			s.maxHeap = stats.HeapAlloc
+		}
+		s.nextSample += s.sampleEvery
+	}
+	if len(s.pending) == 0 {
+		s.pending = streamChunk
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	s.read += n
+	return n, nil
+}
+
+func TestStreamingMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("renders large inputs")
+	}
+	renderers := map[string]*Renderer{
+		"sequential": NewRenderer(),
+		"parallel":   NewRenderer(WithParallelism(4)),
+	}
+	for name, r := range renderers {
+		t.Run(name, func(t *testing.T) {
+			heap := func(size int) uint64 {
+				in := &streamReader{size: size, sampleEvery: 1 << 20}
+				if err := r.Render(in, io.Discard); err != nil {
+					t.Fatal(err)
+				}
+				return in.maxHeap
+			}
+			small := heap(2 << 20)
+			large := heap(32 << 20)
+			// Allow for noise, but not for growth with the size of the input
+			if large > small+(4<<20) {
+				t.Errorf("expected live heap not to grow with input, 2MB: %d bytes 32MB: %d bytes", small, large)
+			}
+		})
+	}
+}
+
+// notImported are packages rnzml must not depend on, html/template uses
+// reflection heavily and makes TinyGo and WASM binaries much larger
+var notImported = []string{"html/template", "text/template"}
+
+func TestImports(t *testing.T) {
+	t.Run("Should not depend on html/template", func(t *testing.T) {
+		seen := map[string]bool{}
+		var visit func(path, dir string)
+		visit = func(path, dir string) {
+			if seen[path] {
+				return
+			}
+			seen[path] = true
+			pkg, err := build.Import(path, dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, imp := range pkg.Imports {
+				if imp == "C" || imp == "unsafe" {
+					continue
+				}
+				visit(imp, pkg.Dir)
+			}
+		}
+		visit(".", ".")
+		for _, path := range notImported {
+			if seen[path] {
+				t.Errorf("expected no dependency on %s", path)
+			}
+		}
+	})
+}
+
+func TestRenderToBytes(t *testing.T) {
+	t.Run("Should render the same as Render", func(t *testing.T) {
+		expected := &bytes.Buffer{}
+		if err := r.Render(strings.NewReader(benchmarkDocument), expected); err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.RenderToBytes([]byte(benchmarkDocument))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected.String() != string(got) {
+			t.Errorf("expected: '%s' got: '%s'", expected.String(), got)
+		}
+	})
+	t.Run("Should not grow the output buffer", func(t *testing.T) {
+		allocs := testing.AllocsPerRun(10, func() {
+			if _, err := r.RenderToBytes([]byte(benchmarkDocument)); err != nil {
+				t.Fatal(err)
+			}
+		})
+		withoutGrowing := testing.AllocsPerRun(10, func() {
+			if err := r.Render(strings.NewReader(benchmarkDocument), io.Discard); err != nil {
+				t.Fatal(err)
+			}
+		})
+		// The output buffer, and the reader and buffer wrapping it
+		if allocs > withoutGrowing+3 {
+			t.Errorf("expected at most %v allocations got: %v", withoutGrowing+3, allocs)
+		}
+	})
+	t.Run("Should return output rendered before an error", func(t *testing.T) {
+		got, err := r.RenderToBytes([]byte("text\n*bold"))
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if string(got) != "<p>text\n</p>\n<p><strong>bold" {
+			t.Errorf("expected: '<p>text\n</p>\n<p><strong>bold' got: '%s'", got)
+		}
+	})
+}
+
+func BenchmarkRenderToBytes(b *testing.B) {
+	b.ReportAllocs()
+	in := []byte(benchmarkDocument)
+	b.SetBytes(int64(len(in)))
+	for i := 0; i < b.N; i++ {
+		if _, err := r.RenderToBytes(in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestWASMBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the module for js/wasm")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	t.Run("Should build for js/wasm", func(t *testing.T) {
+		cmd := exec.Command(goTool, "build", "-o", os.DevNull, ".", "./wasm")
+		cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("error: %s\n%s", err.Error(), out)
+		}
+	})
+}
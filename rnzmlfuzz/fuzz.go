@@ -0,0 +1,123 @@
+// Package rnzmlfuzz provides fuzz entry points and invariant checks for the
+// rnzml renderer.
+//
+// Rendering any input with a Renderer must not panic, and when rendering
+// succeeds the output must be valid UTF-8 with every tag closed in the order
+// it was opened and elements nested as htmlcheck allows. Check verifies these
+// invariants for a single input so they can be asserted by fuzzers, tests and
+// pipelines rendering untrusted input.
+package rnzmlfuzz
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/Resonance1584/rnzml"
+	"github.com/Resonance1584/rnzml/htmlcheck"
+)
+
+// Seeds returns inputs covering each construct of the syntax, for seeding a
+// fuzzing corpus
+func Seeds() [][]byte {
+	return [][]byte{
+		[]byte("Here is some text"),
+		[]byte("Here is *some* text with `formatting`"),
+		[]byte("Here is a [url label eh] link and [https://res.nz]"),
+		[]byte("```\nHere is some code\nthat is preformatted\n```"),
+		[]byte("\\* \\` \\[ \\\\"),
+		[]byte("a\r\nb\r\n"),
+		[]byte("\uFEFF```\n\t\n```"),
+		[]byte("*unclosed"),
+		[]byte("[<a href=\"x\">]"),
+	}
+}
+
+// Fuzz renders data with the default options and panics if an invariant does
+// not hold. It follows the go-fuzz convention of returning 1 when data
+// rendered successfully and 0 otherwise.
+func Fuzz(data []byte) int {
+	rendered, err := Check(data)
+	if err != nil {
+		panic(err)
+	}
+	if !rendered {
+		return 0
+	}
+	return 1
+}
+
+// Check renders data with a Renderer configured with opts and returns an error
+// if an invariant does not hold. rendered reports whether data rendered
+// without an error, the invariants on the output are only checked when it did.
+func Check(data []byte, opts ...rnzml.Option) (rendered bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			rendered = false
+			err = fmt.Errorf("render panicked: %v", r)
+		}
+	}()
+
+	out := &bytes.Buffer{}
+	if renderErr := rnzml.NewRenderer(opts...).Render(bytes.NewReader(data), out); renderErr != nil {
+		return false, nil
+	}
+	if !utf8.Valid(out.Bytes()) {
+		return true, fmt.Errorf("output is not valid UTF-8: %q", out.Bytes())
+	}
+	if err := CheckTags(out.Bytes()); err != nil {
+		return true, err
+	}
+	if err := htmlcheck.CheckBytes(out.Bytes()); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// CheckTags returns an error if a tag in html is not closed, or is closed out
+// of the order tags were opened in. Void elements such as <br> are not closed.
+// Every < in html is expected to start a tag as it does in rendered output.
+func CheckTags(html []byte) error {
+	var open []string
+	for len(html) > 0 {
+		start := bytes.IndexByte(html, '<')
+		if start == -1 {
+			break
+		}
+		end := bytes.IndexByte(html[start:], '>')
+		if end == -1 {
+			return fmt.Errorf("unterminated tag at: %q", html[start:])
+		}
+		tag := html[start+1 : start+end]
+		html = html[start+end+1:]
+
+		closing := bytes.HasPrefix(tag, []byte("/"))
+		if closing {
+			tag = tag[1:]
+		}
+		if i := bytes.IndexAny(tag, " \t\n"); i != -1 {
+			tag = tag[:i]
+		}
+		name := string(tag)
+		if name == "" {
+			return fmt.Errorf("empty tag name")
+		}
+		if !closing {
+			if !htmlcheck.IsVoidElement(name) {
+				open = append(open, name)
+			}
+			continue
+		}
+		if len(open) == 0 {
+			return fmt.Errorf("closing tag </%s> was never opened", name)
+		}
+		if last := open[len(open)-1]; last != name {
+			return fmt.Errorf("closing tag </%s> does not match open <%s>", name, last)
+		}
+		open = open[:len(open)-1]
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("unclosed tag <%s>", open[len(open)-1])
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package rnzmlfuzz
+
+import (
+	"testing"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+var options = [][]rnzml.Option{
+	nil,
+	{rnzml.WithTrailingBackslash(rnzml.TrailingBackslashJoin)},
+	{rnzml.WithTrailingBackslash(rnzml.TrailingBackslashLiteral), rnzml.WithCanonicalOutput()},
+	{rnzml.WithExternalLinksInNewTab(), rnzml.WithBlankWhitespaceLines(), rnzml.WithTabWidth(4)},
+	{rnzml.WithParallelism(2)},
+	{rnzml.WithTrailingBackslash(rnzml.TrailingBackslashJoin), rnzml.WithJoinedLineBreaks(rnzml.LineBreakTag)},
+}
+
+func FuzzRender(f *testing.F) {
+	for _, seed := range Seeds() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, opts := range options {
+			if _, err := Check(data, opts...); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+}
+
+var checktests = []struct {
+	in string
+}{
+	{"\xff\xfe"},
+	{"```\n\xff\n```"},
+	{"*\xc3*"},
+	{"[\xff \xfe]"},
+	{"`\xe2\x82`"},
+}
+
+func TestCheck(t *testing.T) {
+	for _, tt := range checktests {
+		t.Run(tt.in, func(t *testing.T) {
+			for _, opts := range options {
+				rendered, err := Check([]byte(tt.in), opts...)
+				if err != nil {
+					t.Error(err)
+				} else if !rendered {
+					t.Errorf("expected input to render")
+				}
+			}
+		})
+	}
+	t.Run("Should accept line break tags", func(t *testing.T) {
+		opts := []rnzml.Option{rnzml.WithTrailingBackslash(rnzml.TrailingBackslashJoin), rnzml.WithJoinedLineBreaks(rnzml.LineBreakTag)}
+		if rendered, err := Check([]byte("a\\\nb"), opts...); err != nil {
+			t.Error(err)
+		} else if !rendered {
+			t.Errorf("expected input to render")
+		}
+	})
+}
+
+var tagtests = []struct {
+	in  string
+	err bool
+}{
+	{"<p>a</p>", false},
+	{`<p><a href="b">c</a></p>`, false},
+	{"<pre><code>&lt;</code></pre>", false},
+	{"<p>a<br>\nb</p>", false},
+	{"<p>", true},
+	{"</p>", true},
+	{"<p><strong></p></strong>", true},
+	{"<p", true},
+}
+
+func TestCheckTags(t *testing.T) {
+	for _, tt := range tagtests {
+		t.Run(tt.in, func(t *testing.T) {
+			err := CheckTags([]byte(tt.in))
+			if tt.err && err == nil {
+				t.Errorf("expected error")
+			} else if !tt.err && err != nil {
+				t.Errorf("error: %s", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+// Package rnzmlqtpl renders rnzml documents in quicktemplate templates.
+//
+// The functions follow the functions quicktemplate generates for templates,
+// HTML returns the output as a string and WriteHTML writes it to an
+// io.Writer such as the writer of a template, without rnzml depending on
+// quicktemplate:
+//
+//	{% func Page(p Page) %}
+//		<main>{%s= rnzmlqtpl.HTML(p.Body) %}</main>
+//	{% endfunc %}
+//
+// Templates cannot return errors, so a document that cannot be rendered is
+// written as its source escaped in a pre element and the page still renders.
+// Check documents before they reach a template, such as with the lint
+// package when they are saved.
+package rnzmlqtpl
+
+import (
+	"html"
+	"io"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+// HTML returns the rnzml document src rendered with a Renderer configured by
+// opts
+func HTML(src string, opts ...rnzml.Option) string {
+	return string(render(src, opts))
+}
+
+// WriteHTML writes the rnzml document src rendered with a Renderer configured
+// by opts to w
+func WriteHTML(w io.Writer, src string, opts ...rnzml.Option) {
+	w.Write(render(src, opts)) //nolint: errcheck
+}
+
+// render returns the output of src, or src escaped when it cannot be
+// rendered
+func render(src string, opts []rnzml.Option) []byte {
+	out, err := rnzml.NewRenderer(opts...).RenderToBytes([]byte(src))
+	if err != nil {
+		return []byte("<pre>" + html.EscapeString(src) + "</pre>\n")
+	}
+	return out
+}
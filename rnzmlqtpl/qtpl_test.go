@@ -0,0 +1,33 @@
+package rnzmlqtpl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+var qtpltests = []struct {
+	src  string
+	opts []rnzml.Option
+	out  string
+}{
+	{"a *b*", nil, "<p>a <strong>b</strong>\n</p>\n"},
+	{"a\tb", []rnzml.Option{rnzml.WithCanonicalOutput()}, "<p>a\tb</p>\n"},
+	{"*<a>", nil, "<pre>*&lt;a&gt;</pre>\n"},
+}
+
+func TestHTML(t *testing.T) {
+	for _, tt := range qtpltests {
+		t.Run(tt.src, func(t *testing.T) {
+			if out := HTML(tt.src, tt.opts...); tt.out != out {
+				t.Errorf("expected: %q got: %q", tt.out, out)
+			}
+			out := &strings.Builder{}
+			WriteHTML(out, tt.src, tt.opts...)
+			if tt.out != out.String() {
+				t.Errorf("expected: %q got: %q", tt.out, out.String())
+			}
+		})
+	}
+}
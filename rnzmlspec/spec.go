@@ -0,0 +1,126 @@
+// Package rnzmlspec reads spec test files and runs their examples against a
+// renderer, so implementations of rnzml and extensions to it can check that
+// they render documents as the reference renderer does.
+//
+// A spec file is text with examples in it, in the format of the CommonMark
+// spec. An example starts with a line of 32 backticks followed by " example"
+// and ends with a line of 32 backticks. Its input and expected output are
+// separated by a line containing only a dot, and tabs are written as →. The
+// expected output is HTML rendered with rnzml.WithCanonicalOutput, or error:
+// followed by the error message when the input is invalid. Lines outside of
+// examples starting with # are headings naming the section of the examples
+// after them, other lines are prose.
+package rnzmlspec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+const (
+	exampleStart = "```````````````````````````````` example"
+	exampleEnd   = "````````````````````````````````"
+	// errorPrefix starts the expected output of an invalid input
+	errorPrefix = "error: "
+)
+
+// Example is an input and its expected output
+type Example struct {
+	// Number counts the examples of a file from 1
+	Number int
+	// Section is the last heading before the example
+	Section string
+	// Line is the line of the file the example starts on
+	Line   int
+	Input  string
+	Output string
+}
+
+// RenderFunc renders the input of an example, returning the output or the
+// error for an invalid input
+type RenderFunc func(input string) (string, error)
+
+// Failure is an example rendered differently than expected
+type Failure struct {
+	Example Example
+	// Got is the output or error: followed by the error rendering the example
+	Got string
+}
+
+func (f Failure) String() string {
+	return fmt.Sprintf("example %d (line %d, %s): input: %q expected: %q got: %q",
+		f.Example.Number, f.Example.Line, f.Example.Section, f.Example.Input, f.Example.Output, f.Got)
+}
+
+// Parse reads the examples of the spec file in
+func Parse(in io.Reader) ([]Example, error) {
+	var examples []Example
+	var example *Example
+	var b strings.Builder
+	section, separated := "", false
+	scanner := bufio.NewScanner(in)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		switch {
+		case example == nil && line == exampleStart:
+			example = &Example{Number: len(examples) + 1, Section: section, Line: lineNumber}
+			separated = false
+			b.Reset()
+		case example == nil:
+			if strings.HasPrefix(line, "#") {
+				section = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			}
+		case line == "." && !separated:
+			example.Input = strings.ReplaceAll(b.String(), "→", "\t")
+			separated = true
+			b.Reset()
+		case line == exampleEnd:
+			if !separated {
+				return nil, fmt.Errorf("line %d: example without a . between its input and output", lineNumber)
+			}
+			example.Output = strings.ReplaceAll(b.String(), "→", "\t")
+			examples = append(examples, *example)
+			example = nil
+		default:
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if example != nil {
+		return nil, fmt.Errorf("unclosed example on line: %d", example.Line)
+	}
+	return examples, nil
+}
+
+// Run renders the input of each example with render and returns the examples
+// rendered differently than expected
+func Run(examples []Example, render RenderFunc) []Failure {
+	var failures []Failure
+	for _, example := range examples {
+		got, err := render(example.Input)
+		if err != nil {
+			got = errorPrefix + err.Error() + "\n"
+		}
+		if got != example.Output {
+			failures = append(failures, Failure{Example: example, Got: got})
+		}
+	}
+	return failures
+}
+
+// Reference renders input with the reference renderer, rnzml configured with
+// WithCanonicalOutput
+func Reference(input string) (string, error) {
+	var b strings.Builder
+	if err := rnzml.NewRenderer(rnzml.WithCanonicalOutput()).Render(strings.NewReader(input), &b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
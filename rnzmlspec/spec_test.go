@@ -0,0 +1,76 @@
+package rnzmlspec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSpec(t *testing.T) {
+	f, err := os.Open("spec.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	examples, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(examples) == 0 {
+		t.Fatal("expected examples")
+	}
+	for _, failure := range Run(examples, Reference) {
+		t.Error(failure)
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Should read examples and their sections", func(t *testing.T) {
+		in := "# A\nprose\n" + exampleStart + "\na→b\n\nc\n.\n<p>a→b</p>\n" + exampleEnd + "\n## B\n" + exampleStart + "\n.\n.\n" + exampleEnd + "\n"
+		examples, err := Parse(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []Example{
+			{Number: 1, Section: "A", Line: 3, Input: "a\tb\n\nc\n", Output: "<p>a\tb</p>\n"},
+			{Number: 2, Section: "B", Line: 11, Input: "", Output: ".\n"},
+		}
+		if fmt.Sprint(expected) != fmt.Sprint(examples) {
+			t.Errorf("expected: %v got: %v", expected, examples)
+		}
+	})
+	var errortests = []struct {
+		in  string
+		err string
+	}{
+		{exampleStart + "\na\n", "unclosed example on line: 1"},
+		{"\n" + exampleStart + "\na\n" + exampleEnd, "line 4: example without a . between its input and output"},
+	}
+	for _, tt := range errortests {
+		t.Run(fmt.Sprintf("Should return an error for %q", tt.in), func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.in)); fmt.Sprint(err) != tt.err {
+				t.Errorf("expected: '%s' got: '%v'", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	examples := []Example{
+		{Number: 1, Input: "a\n", Output: "<p>a</p>\n"},
+		{Number: 2, Input: "*a\n", Output: "error: fail\n"},
+		{Number: 3, Input: "b\n", Output: "<p>b</p>\n"},
+	}
+	render := func(input string) (string, error) {
+		if input == "*a\n" {
+			return "", errors.New("fail")
+		}
+		return "<p>a</p>\n", nil
+	}
+	failures := Run(examples, render)
+	if len(failures) != 1 || failures[0].Example.Number != 3 || failures[0].Got != "<p>a</p>\n" {
+		t.Errorf("expected example 3 to fail got: %v", failures)
+	}
+}
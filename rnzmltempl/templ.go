@@ -0,0 +1,47 @@
+// Package rnzmltempl renders rnzml documents in templ components.
+//
+// The Component returned by HTML has the Render method of templ.Component, so
+// it can be used in templ templates without rnzml depending on templ:
+//
+//	templ Page(p Page) {
+//		<main>
+//			@rnzmltempl.HTML(p.Body)
+//		</main>
+//	}
+//
+// The document is rendered straight to the writer templ streams the page to,
+// and an error rendering it is returned from Render as templ expects.
+package rnzmltempl
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+// Component is templ.Component
+type Component interface {
+	Render(ctx context.Context, w io.Writer) error
+}
+
+// component renders a document with a Renderer
+type component struct {
+	src string
+	re  *rnzml.Renderer
+}
+
+// HTML returns a Component rendering the rnzml document src with a Renderer
+// configured by opts
+func HTML(src string, opts ...rnzml.Option) Component {
+	return component{src: src, re: rnzml.NewRenderer(opts...)}
+}
+
+// Render renders the document to w, unless ctx is done
+func (c component) Render(ctx context.Context, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.re.Render(strings.NewReader(c.src), w)
+}
@@ -0,0 +1,48 @@
+package rnzmltempl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+var templtests = []struct {
+	src  string
+	opts []rnzml.Option
+	out  string
+}{
+	{"a *b*", nil, "<p>a <strong>b</strong>\n</p>\n"},
+	{"[https://res.nz res.nz]", []rnzml.Option{rnzml.WithExternalLinksInNewTab()}, "<p><a href=\"https://res.nz\" target=\"_blank\" rel=\"noopener\">res.nz</a>\n</p>\n"},
+}
+
+func TestHTML(t *testing.T) {
+	for _, tt := range templtests {
+		t.Run(tt.src, func(t *testing.T) {
+			out := &strings.Builder{}
+			if err := HTML(tt.src, tt.opts...).Render(context.Background(), out); err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != out.String() {
+				t.Errorf("expected: %q got: %q", tt.out, out.String())
+			}
+		})
+	}
+	t.Run("Should return syntax errors", func(t *testing.T) {
+		err := HTML("*a").Render(context.Background(), &strings.Builder{})
+		var syntaxErr *rnzml.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("expected *SyntaxError got: '%v'", err)
+		}
+	})
+	t.Run("Should not render when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		out := &strings.Builder{}
+		if err := HTML("a").Render(ctx, out); err != context.Canceled || out.Len() > 0 {
+			t.Errorf("expected: '%v' got: '%v' %q", context.Canceled, err, out.String())
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package rnzml
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+)
+
+var (
+	shortcodeStart = []byte("{{<")
+	shortcodeEnd   = []byte(">}}")
+)
+
+// ShortcodeFunc renders a shortcode called with args to out. Its output is
+// written as it is, so it must be safe HTML.
+type ShortcodeFunc func(args []string, out io.Writer) error
+
+// WithShortcode registers fn as the shortcode name, so embeds specific to an
+// application can be rendered without changing the syntax. A line containing
+// only {{< name args >}} outside of code blocks and joined text blocks is
+// rendered by calling fn with args, which are separated by spaces. Once a
+// shortcode is registered a shortcode line with an unknown name is an error.
+// Parse passes shortcodes to Handler.Shortcode instead of calling fn.
+func WithShortcode(name string, fn ShortcodeFunc) Option {
+	return func(re *Renderer) {
+		if re.shortcodes == nil {
+			re.shortcodes = map[string]ShortcodeFunc{}
+		}
+		re.shortcodes[name] = fn
+	}
+}
+
+// isShortcode reports whether line is a shortcode
+func isShortcode(line []byte) bool {
+	return len(line) >= len(shortcodeStart)+len(shortcodeEnd) &&
+		bytes.HasPrefix(line, shortcodeStart) && bytes.HasSuffix(line, shortcodeEnd)
+}
+
+// shortcode returns the func and arguments of the shortcode in b
+func (re *Renderer) shortcode(b block) (ShortcodeFunc, []string, error) {
+	fields := shortcodeFields(b)
+	if len(fields) == 0 || re.shortcodes[fields[0]] == nil {
+		return nil, nil, &SyntaxError{Problem: UnknownShortcode, Line: b.line}
+	}
+	return re.shortcodes[fields[0]], fields[1:], nil
+}
+
+// shortcodeFields returns the name and arguments of the shortcode in b
+func shortcodeFields(b block) []string {
+	return strings.Fields(string(b.content[len(shortcodeStart) : len(b.content)-len(shortcodeEnd)]))
+}
+
+// renderShortcode renders the shortcode in b to out
+func (re *Renderer) renderShortcode(b block, out io.Writer) error {
+	fn, args, err := re.shortcode(b)
+	if err != nil {
+		return err
+	}
+	if err := fn(args, out); err != nil {
+		return lineError(b.line, err)
+	}
+	return nil
+}
+
+// shortcodeNames returns the names of the shortcodes of re in order
+func (re *Renderer) shortcodeNames() []string {
+	names := make([]string, 0, len(re.shortcodes))
+	for name := range re.shortcodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
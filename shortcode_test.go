@@ -0,0 +1,81 @@
+package rnzml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// youtube renders a youtube shortcode
+func youtube(args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("youtube takes a video id")
+	}
+	_, err := fmt.Fprintf(out, "<iframe src=\"https://www.youtube.com/embed/%s\"></iframe>\n", args[0])
+	return err
+}
+
+var shortcodetests = []struct {
+	in  string
+	out string
+}{
+	{"{{< youtube abc >}}", "<iframe src=\"https://www.youtube.com/embed/abc\"></iframe>\n"},
+	{"a\n{{<youtube  abc>}}\nb", "<p>a</p>\n<iframe src=\"https://www.youtube.com/embed/abc\"></iframe>\n<p>b</p>\n"},
+	{"a {{< youtube abc >}}", "<p>a {{&lt; youtube abc &gt;}}</p>\n"},
+	{"```\n{{< youtube abc >}}\n```", "<pre><code>{{&lt; youtube abc &gt;}}\n</code></pre>\n"},
+}
+
+var shortcodeerrortests = []struct {
+	in  string
+	err string
+}{
+	{"a\n{{< vimeo abc >}}", "line 2: unknown shortcode"},
+	{"{{< >}}", "line 1: unknown shortcode"},
+	{"a\n\n{{< youtube >}}", "line 3: youtube takes a video id"},
+}
+
+func TestShortcodes(t *testing.T) {
+	re := NewRenderer(WithShortcode("youtube", youtube), WithCanonicalOutput())
+	for _, tt := range shortcodetests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := &strings.Builder{}
+			if err := re.Render(strings.NewReader(tt.in), out); err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != out.String() {
+				t.Errorf("expected: %q got: %q", tt.out, out.String())
+			}
+		})
+	}
+	for _, tt := range shortcodeerrortests {
+		t.Run(fmt.Sprintf("Should return an error for %q", tt.in), func(t *testing.T) {
+			if err := re.Render(strings.NewReader(tt.in), io.Discard); fmt.Sprint(err) != tt.err {
+				t.Errorf("expected: '%s' got: '%v'", tt.err, err)
+			}
+		})
+	}
+	t.Run("Should render shortcodes as text without WithShortcode", func(t *testing.T) {
+		out := &strings.Builder{}
+		if err := NewRenderer(WithCanonicalOutput()).Render(strings.NewReader("{{< youtube abc >}}"), out); err != nil {
+			t.Fatal(err)
+		}
+		if expected := "<p>{{&lt; youtube abc &gt;}}</p>\n"; expected != out.String() {
+			t.Errorf("expected: %q got: %q", expected, out.String())
+		}
+	})
+	t.Run("Should index shortcodes", func(t *testing.T) {
+		var entries []IndexEntry
+		re := NewRenderer(WithShortcode("youtube", youtube), WithBlockIndex(func(e IndexEntry) {
+			entries = append(entries, e)
+		}))
+		if err := re.Render(strings.NewReader("a\n{{< youtube abc >}}"), io.Discard); err != nil {
+			t.Fatal(err)
+		}
+		expected := IndexEntry{Kind: ShortcodeBlock, StartLine: 2, EndLine: 2, OutputStart: 10, OutputEnd: 68}
+		if len(entries) != 2 || entries[1] != expected {
+			t.Errorf("expected: %+v got: %+v", expected, entries)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package rnzml
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// WithSourceLines adds a data-line attribute with the line number of each text
+// block and code block to the tag starting it, so a preview pane can scroll
+// with the editor and point at the source of an element. Text blocks joined
+// with TrailingBackslashJoin have the line of their first line.
+func WithSourceLines() Option {
+	return func(re *Renderer) {
+		re.sourceLines = true
+	}
+}
+
+// appendBlockStart appends start, the tags starting a block on line, to b,
+// with a data-line attribute on its first tag when WithSourceLines is set
+func (re *Renderer) appendBlockStart(b, start []byte, line int) []byte {
+	if !re.sourceLines {
+		return append(b, start...)
+	}
+	i := bytes.IndexByte(start, '>')
+	b = append(append(b, start[:i]...), ` data-line="`...)
+	b = strconv.AppendInt(b, int64(line), 10)
+	return append(append(b, '"'), start[i:]...)
+}
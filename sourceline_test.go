@@ -0,0 +1,57 @@
+package rnzml
+
+import (
+	"strings"
+	"testing"
+)
+
+var sourcelinetests = []struct {
+	in   string
+	opts []Option
+	out  string
+}{
+	{"a\n\n```\nb\n```\nc", nil, "<p data-line=\"1\">a\n</p>\n\n<pre data-line=\"3\"><code>b\n</code></pre>\n<p data-line=\"6\">c\n</p>\n"},
+	{"a\\\nb\nc", []Option{WithTrailingBackslash(TrailingBackslashJoin)}, "<p data-line=\"1\">a\nb\n</p>\n<p data-line=\"3\">c\n</p>\n"},
+	{"a\n```\nb\n```", []Option{WithLanguage("en"), WithDirection(DirectionRTL)}, "<p lang=\"en\" dir=\"rtl\" data-line=\"1\">a\n</p>\n<pre dir=\"ltr\" data-line=\"2\"><code>b\n</code></pre>\n"},
+	{"!if profile=b\na\n!endif\nb", []Option{WithProfiles("a")}, "<p data-line=\"4\">b\n</p>\n"},
+}
+
+func TestSourceLines(t *testing.T) {
+	for _, tt := range sourcelinetests {
+		t.Run(tt.in, func(t *testing.T) {
+			out := &strings.Builder{}
+			if err := NewRenderer(append(tt.opts, WithSourceLines())...).Render(strings.NewReader(tt.in), out); err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != out.String() {
+				t.Errorf("expected: %q got: %q", tt.out, out.String())
+			}
+		})
+	}
+	t.Run("Should render the same output in parallel", func(t *testing.T) {
+		serial, err := NewRenderer(WithSourceLines()).RenderToBytes([]byte(benchmarkDocument))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parallel, err := NewRenderer(WithSourceLines(), WithParallelism(4)).RenderToBytes([]byte(benchmarkDocument))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(serial) != string(parallel) {
+			t.Error("expected parallel output to match serial output")
+		}
+	})
+	t.Run("Should update the lines of a Document after an edit", func(t *testing.T) {
+		d, err := NewRenderer(WithSourceLines()).NewDocument([]byte("a\n\nb\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := d.Edit(0, 0, []byte("c\n")); err != nil {
+			t.Fatal(err)
+		}
+		expected := "<p data-line=\"1\">c\n</p>\n<p data-line=\"2\">a\n</p>\n\n<p data-line=\"4\">b\n</p>\n"
+		if string(d.Output()) != expected {
+			t.Errorf("expected: %q got: %q", expected, d.Output())
+		}
+	})
+}
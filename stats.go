@@ -0,0 +1,76 @@
+package rnzml
+
+import (
+	"io"
+	"time"
+)
+
+// RenderStats describes a call to Render
+type RenderStats struct {
+	// BytesIn is the number of bytes read from the input, and BytesOut the
+	// number of bytes written to the output
+	BytesIn  int
+	BytesOut int
+	// Lines is the number of lines read
+	Lines int
+	// TextBlocks, BlankLines and CodeBlocks count the blocks of each kind,
+	// CodeLines counts the lines inside code blocks
+	TextBlocks int
+	BlankLines int
+	CodeBlocks int
+	CodeLines  int
+	// Duration is the time taken by Render
+	Duration time.Duration
+}
+
+// WithStats calls fn with the statistics of each call to Render once it
+// returns, including calls that fail. When rendering fails the statistics
+// describe the input read and output written before the error, with
+// WithParallelism or WithPipelining they include lines scanned ahead of the
+// block that failed.
+func WithStats(fn func(RenderStats)) Option {
+	return func(re *Renderer) {
+		re.stats = fn
+	}
+}
+
+// count counts b in s, s may be nil
+func (s *RenderStats) count(b block) {
+	if s == nil {
+		return
+	}
+	switch b.kind {
+	case blockText:
+		s.TextBlocks++
+	case blockBlank:
+		s.BlankLines++
+	case blockCodeStart:
+		s.CodeBlocks++
+	case blockCodeLine:
+		s.CodeLines++
+	}
+}
+
+// statsReader counts the bytes read from r in n
+type statsReader struct {
+	r io.Reader
+	n *int
+}
+
+func (s *statsReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	*s.n += n
+	return n, err
+}
+
+// statsWriter counts the bytes written to w in n
+type statsWriter struct {
+	w io.Writer
+	n *int
+}
+
+func (s *statsWriter) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	*s.n += n
+	return n, err
+}
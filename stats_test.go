@@ -0,0 +1,53 @@
+package rnzml
+
+import (
+	"strings"
+	"testing"
+)
+
+var statstests = []struct {
+	in    string
+	opts  []Option
+	stats RenderStats
+}{
+	{"", nil, RenderStats{}},
+	{"a\n\nb", nil, RenderStats{BytesIn: 4, BytesOut: 21, Lines: 3, TextBlocks: 2, BlankLines: 1}},
+	{"```\na\nb\n```\nc", nil, RenderStats{BytesIn: 13, BytesOut: 39, Lines: 5, TextBlocks: 1, CodeBlocks: 1, CodeLines: 2}},
+	{"a\\\nb", []Option{WithTrailingBackslash(TrailingBackslashJoin)}, RenderStats{BytesIn: 4, BytesOut: 12, Lines: 2, TextBlocks: 1}},
+}
+
+func TestStats(t *testing.T) {
+	for _, tt := range statstests {
+		for _, workers := range []int{0, 2} {
+			t.Run(tt.in, func(t *testing.T) {
+				var got RenderStats
+				calls := 0
+				opts := append([]Option{WithParallelism(workers), WithStats(func(s RenderStats) {
+					got = s
+					calls++
+				})}, tt.opts...)
+				NewRenderer(opts...).Render(strings.NewReader(tt.in), &strings.Builder{}) //nolint: errcheck
+				if calls != 1 {
+					t.Errorf("expected: 1 call got: %d", calls)
+				}
+				if got.Duration <= 0 {
+					t.Errorf("expected a duration got: %v", got.Duration)
+				}
+				got.Duration = 0
+				if tt.stats != got {
+					t.Errorf("%d workers expected: %+v got: %+v", workers, tt.stats, got)
+				}
+			})
+		}
+	}
+	t.Run("Should describe rendering before an error", func(t *testing.T) {
+		var got RenderStats
+		r := NewRenderer(WithStats(func(s RenderStats) { got = s }))
+		r.Render(strings.NewReader("a\n*b\nc"), &strings.Builder{}) //nolint: errcheck
+		got.Duration = 0
+		expected := RenderStats{BytesIn: 6, BytesOut: 22, Lines: 2, TextBlocks: 2}
+		if expected != got {
+			t.Errorf("expected: %+v got: %+v", expected, got)
+		}
+	})
+}
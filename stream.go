@@ -0,0 +1,74 @@
+package rnzml
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// RenderStream renders a stream of documents separated by lines containing only
+// delimiter, such as a concatenated export. Each document is rendered as
+// Render renders it to the writer returned by out for its index, from 0, and
+// the error of each document is returned at its index. An error in one
+// document does not stop the documents after it, except an error reading from
+// in which ends the stream. Line numbers in errors and warnings are relative
+// to the start of each document. A delimiter at the end of in does not start
+// another document.
+func (re *Renderer) RenderStream(in io.Reader, delimiter string, out func(doc int) io.Writer) []error {
+	br := bufio.NewReader(in)
+	var errs []error
+	for doc := 0; ; doc++ {
+		if doc > 0 {
+			if _, err := br.Peek(1); err != nil {
+				if err != io.EOF {
+					errs = append(errs, err)
+				}
+				return errs
+			}
+		}
+		r := &documentReader{br: br, delimiter: []byte(delimiter), lineStart: true}
+		err := re.Render(r, out(doc))
+		// Skip what is left of a document that failed
+		if _, skipErr := io.Copy(io.Discard, r); err == nil {
+			err = skipErr
+		}
+		errs = append(errs, err)
+		if !r.delimited {
+			return errs
+		}
+	}
+}
+
+// documentReader reads a document from a stream, up to the next delimiter line
+type documentReader struct {
+	br        *bufio.Reader
+	delimiter []byte
+	// line is the part of the current line not yet read
+	line      []byte
+	lineStart bool
+	delimited bool
+	err       error
+}
+
+func (r *documentReader) Read(p []byte) (int, error) {
+	for len(r.line) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		line, err := r.br.ReadSlice('\n')
+		if r.lineStart && err != bufio.ErrBufferFull && bytes.Equal(trimLineEnding(line), r.delimiter) {
+			r.delimited = err == nil
+			r.err = io.EOF
+			return 0, r.err
+		}
+		// A line longer than the buffer of br is read in parts
+		r.lineStart = err == nil
+		if err != nil && err != bufio.ErrBufferFull {
+			r.err = err
+		}
+		r.line = line
+	}
+	n := copy(p, r.line)
+	r.line = r.line[n:]
+	return n, nil
+}
@@ -0,0 +1,78 @@
+package rnzml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+var streamtests = []struct {
+	name string
+	in   string
+	out  []string
+	errs []string
+}{
+	{"empty", "", []string{""}, []string{"<nil>"}},
+	{"one document", "a", []string{"<p>a</p>\n"}, []string{"<nil>"}},
+	{"documents", "a\n---\n\n---\r\nb\n---\n", []string{"<p>a</p>\n", "", "<p>b</p>\n"}, []string{"<nil>", "<nil>", "<nil>"}},
+	{"delimiter without a line ending", "a\n---", []string{"<p>a</p>\n"}, []string{"<nil>"}},
+	{"delimiter in a line", "a ---\n--- b", []string{"<p>a ---</p>\n<p>--- b</p>\n"}, []string{"<nil>"}},
+	{
+		"errors",
+		"*a\nb\n---\n```\n---\nc\n",
+		[]string{"<p><strong>a", "<pre><code>", "<p>c</p>\n"},
+		[]string{"line 1: unclosed bold text (*) at position: 0", "unclosed code block (```) on line: 1", "<nil>"},
+	},
+	{
+		"long lines",
+		strings.Repeat("-", 5000) + "\n---\n",
+		[]string{"<p>" + strings.Repeat("-", 5000) + "</p>\n"},
+		[]string{"<nil>"},
+	},
+}
+
+func TestRenderStream(t *testing.T) {
+	re := NewRenderer(WithCanonicalOutput())
+	for _, tt := range streamtests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outs []*strings.Builder
+			errs := re.RenderStream(strings.NewReader(tt.in), "---", func(doc int) io.Writer {
+				if doc != len(outs) {
+					t.Errorf("expected document: %d got: %d", len(outs), doc)
+				}
+				outs = append(outs, &strings.Builder{})
+				return outs[doc]
+			})
+			if len(tt.errs) != len(errs) || len(tt.out) != len(outs) {
+				t.Fatalf("expected %d documents got: %d outputs and %d errors", len(tt.out), len(outs), len(errs))
+			}
+			for i := range errs {
+				if tt.errs[i] != fmt.Sprint(errs[i]) {
+					t.Errorf("document %d expected error: '%s' got: '%v'", i, tt.errs[i], errs[i])
+				}
+				if tt.out[i] != outs[i].String() {
+					t.Errorf("document %d expected: %q got: %q", i, tt.out[i], outs[i].String())
+				}
+			}
+		})
+	}
+	t.Run("Should end the stream at a read error", func(t *testing.T) {
+		readErr := errors.New("read failed")
+		in := io.MultiReader(strings.NewReader("a\n---\nb"), &errorReader{err: readErr})
+		errs := re.RenderStream(in, "---", func(int) io.Writer { return io.Discard })
+		if len(errs) != 2 || errs[0] != nil || !errors.Is(errs[1], readErr) {
+			t.Errorf("expected: [<nil> %v] got: %v", readErr, errs)
+		}
+	})
+}
+
+// errorReader fails every read with err
+type errorReader struct {
+	err error
+}
+
+func (r *errorReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,114 @@
+package rnzml
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Problem is the reason input is malformed
+type Problem int
+
+const (
+	// UnclosedBold is a * without a closing *
+	UnclosedBold Problem = iota + 1
+	// UnclosedCode is a ` without a closing `
+	UnclosedCode
+	// UnclosedLink is a [ without a closing ]
+	UnclosedLink
+	// UnclosedEscape is a \ at the end of a line
+	UnclosedEscape
+	// MissingLinkURL is a link starting with a space
+	MissingLinkURL
+	// UnclosedCodeBlock is a ``` without a closing ```
+	UnclosedCodeBlock
+	// UnclosedFrontMatter is front matter without a closing ---
+	UnclosedFrontMatter
+	// InvalidMetadata is a line of front matter that is not a key: value pair
+	InvalidMetadata
+	// VagueLinkLabel is a link with a label that fails WithAccessibilityChecks
+	VagueLinkLabel
+	// UnclosedCondition is an !if without a closing !endif
+	UnclosedCondition
+	// UnmatchedDirective is an !else or !endif without an !if
+	UnmatchedDirective
+	// InvalidCondition is an !if that is not followed by profile=name
+	InvalidCondition
+	// UnknownShortcode is a shortcode that is not registered
+	UnknownShortcode
+	// UnclosedRegion is a !region without a closing !endregion
+	UnclosedRegion
+	// UnmatchedRegionEnd is an !endregion without a !region
+	UnmatchedRegionEnd
+	// MissingRegionName is a !region without a name
+	MissingRegionName
+	// UnclosedPreformattedBlock is a ~~~ without a closing ~~~ with
+	// WithPreformattedBlocks
+	UnclosedPreformattedBlock
+)
+
+// SyntaxError is returned when the input is malformed. Its message is only
+// formatted when Error is called, so discarding a SyntaxError costs a single
+// allocation.
+type SyntaxError struct {
+	Problem Problem
+	// Line is the line of the text block or code block with the problem
+	Line int
+	// Position is the byte offset in the text block of the character that is
+	// not closed, or of the [ of a link
+	Position int
+	// Link is the content of a link missing a URL, or the label of a link
+	// that fails WithAccessibilityChecks
+	Link string
+}
+
+func (e *SyntaxError) Error() string {
+	switch e.Problem {
+	case UnclosedCodeBlock:
+		return "unclosed code block (```) on line: " + strconv.Itoa(e.Line)
+	case UnclosedPreformattedBlock:
+		return "unclosed preformatted block (~~~) on line: " + strconv.Itoa(e.Line)
+	case UnclosedFrontMatter:
+		return "unclosed front matter (---) on line: " + strconv.Itoa(e.Line)
+	case InvalidMetadata:
+		return "line " + strconv.Itoa(e.Line) + ": front matter must be key: value pairs"
+	case UnclosedCondition:
+		return "unclosed condition (!if) on line: " + strconv.Itoa(e.Line)
+	case UnmatchedDirective:
+		return "line " + strconv.Itoa(e.Line) + ": !else or !endif without !if"
+	case InvalidCondition:
+		return "line " + strconv.Itoa(e.Line) + ": conditions must be of the form !if profile=name"
+	case UnclosedRegion:
+		return "unclosed region (!region) on line: " + strconv.Itoa(e.Line)
+	case UnmatchedRegionEnd:
+		return "line " + strconv.Itoa(e.Line) + ": !endregion without !region"
+	case MissingRegionName:
+		return "line " + strconv.Itoa(e.Line) + ": regions must be of the form !region name"
+	case UnknownShortcode:
+		return "line " + strconv.Itoa(e.Line) + ": unknown shortcode"
+	case VagueLinkLabel:
+		return "line " + strconv.Itoa(e.Line) + ": link label does not describe the link at position: " + strconv.Itoa(e.Position)
+	case MissingLinkURL:
+		return "line " + strconv.Itoa(e.Line) + ": Links must have a URL optionally followed by a space and a Label. Instead found: " + e.Link
+	}
+	unclosed := ""
+	switch e.Problem {
+	case UnclosedBold:
+		unclosed = "bold text (*)"
+	case UnclosedCode:
+		unclosed = "code text (`)"
+	case UnclosedLink:
+		unclosed = "link ([)"
+	case UnclosedEscape:
+		unclosed = "escape (\\)"
+	}
+	return "line " + strconv.Itoa(e.Line) + ": unclosed " + unclosed + " at position: " + strconv.Itoa(e.Position)
+}
+
+// lineError adds line to an error rendering a text block starting on line
+func lineError(line int, err error) error {
+	if syntaxErr, ok := err.(*SyntaxError); ok {
+		syntaxErr.Line = line
+		return syntaxErr
+	}
+	return fmt.Errorf("line %d: %w", line, err)
+}
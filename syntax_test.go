@@ -0,0 +1,51 @@
+package rnzml
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var syntaxerrortests = []struct {
+	in    string
+	err   SyntaxError
+	error string
+}{
+	{"a\n*b", SyntaxError{Problem: UnclosedBold, Line: 2, Position: 0}, "line 2: unclosed bold text (*) at position: 0"},
+	{"a `b", SyntaxError{Problem: UnclosedCode, Line: 1, Position: 2}, "line 1: unclosed code text (`) at position: 2"},
+	{"[a", SyntaxError{Problem: UnclosedLink, Line: 1, Position: 0}, "line 1: unclosed link ([) at position: 0"},
+	{"ab\\", SyntaxError{Problem: UnclosedEscape, Line: 1, Position: 2}, "line 1: unclosed escape (\\) at position: 2"},
+	{"a [ b]", SyntaxError{Problem: MissingLinkURL, Line: 1, Position: 2, Link: " b"}, "line 1: Links must have a URL optionally followed by a space and a Label. Instead found:  b"},
+	{"a\n```\nb", SyntaxError{Problem: UnclosedCodeBlock, Line: 2}, "unclosed code block (```) on line: 2"},
+}
+
+func TestSyntaxError(t *testing.T) {
+	for _, tt := range syntaxerrortests {
+		t.Run(tt.in, func(t *testing.T) {
+			err := r.Render(strings.NewReader(tt.in), io.Discard)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected *SyntaxError got: '%v'", err)
+			}
+			if tt.err != *syntaxErr {
+				t.Errorf("expected: %+v got: %+v", tt.err, *syntaxErr)
+			}
+			if tt.error != err.Error() {
+				t.Errorf("expected: '%s' got: '%s'", tt.error, err.Error())
+			}
+		})
+	}
+	t.Run("Should allocate once for malformed text", func(t *testing.T) {
+		st := &renderState{}
+		line := []byte("Here is *some text that is not closed")
+		allocs := testing.AllocsPerRun(100, func() {
+			if err := r.renderTextBlock(st, line, 1, io.Discard); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+		if allocs != 1 {
+			t.Errorf("expected: 1 allocation got: %v", allocs)
+		}
+	})
+}
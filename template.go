@@ -0,0 +1,84 @@
+package rnzml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Lines delimiting the rnzml regions of a template, they are template comments
+// so a template that is not preprocessed still parses
+const (
+	TemplateRegionStart = "{{/* rnzml */}}"
+	TemplateRegionEnd   = "{{/* end rnzml */}}"
+)
+
+// PreprocessTemplate replaces the rnzml regions of the HTML or Go template src
+// with their HTML rendered with a Renderer configured by opts, before the
+// template is parsed, so layout markup and rnzml copy can be written in one
+// file. A region is the lines between a line containing only
+// TemplateRegionStart and a line containing only TemplateRegionEnd. The
+// indentation of the start line is removed from the lines of the region and
+// added to the lines of its HTML that are not blank.
+//
+// Template actions in a region are rendered as text, so only actions without
+// characters that are escaped in HTML, such as {{.Name}}, keep working. The
+// lines of syntax errors are lines of src.
+func PreprocessTemplate(src []byte, opts ...Option) ([]byte, error) {
+	re := NewRenderer(opts...)
+	var out, region []byte
+	// start is the line of the start of the current region, or 0
+	start := 0
+	var indent []byte
+	for n := 1; len(src) > 0; n++ {
+		line := src
+		if i := bytes.IndexByte(src, '\n'); i != -1 {
+			line = src[:i+1]
+		}
+		src = src[len(line):]
+		content := bytes.TrimSpace(line)
+		switch {
+		case start == 0 && string(content) == TemplateRegionStart:
+			start, region = n, region[:0]
+			indent = line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+		case start == 0:
+			out = append(out, line...)
+		case string(content) == TemplateRegionStart:
+			return nil, fmt.Errorf("line %d: rnzml region in the region started on line %d", n, start)
+		case string(content) == TemplateRegionEnd:
+			html, err := re.RenderToBytes(region)
+			if err != nil {
+				return nil, regionError(start, err)
+			}
+			for len(html) > 0 {
+				i := bytes.IndexByte(html, '\n') + 1
+				if i == 0 {
+					i = len(html)
+				}
+				if html[0] != '\n' {
+					out = append(out, indent...)
+				}
+				out = append(out, html[:i]...)
+				html = html[i:]
+			}
+			start = 0
+		default:
+			region = append(region, bytes.TrimPrefix(line, indent)...)
+		}
+	}
+	if start != 0 {
+		return nil, fmt.Errorf("unclosed rnzml region on line: %d", start)
+	}
+	return out, nil
+}
+
+// regionError returns err, an error rendering the region of a template
+// starting on line start, with the lines of the template
+func regionError(start int, err error) error {
+	var syntaxErr *SyntaxError
+	if errors.As(err, &syntaxErr) {
+		syntaxErr.Line += start
+		return syntaxErr
+	}
+	return fmt.Errorf("rnzml region on line %d: %w", start, err)
+}
@@ -0,0 +1,75 @@
+package rnzml
+
+import (
+	"errors"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+var templatetests = []struct {
+	in  string
+	out string
+}{
+	{"<p>{{.}}</p>\n", "<p>{{.}}</p>\n"},
+	{"<main>\n  {{/* rnzml */}}\n  Hello *{{.Name}}*\n\n  [/about About]\n  {{/* end rnzml */}}\n</main>\n",
+		"<main>\n  <p>Hello <strong>{{.Name}}</strong>\n  </p>\n\n  <p><a href=\"/about\">About</a>\n  </p>\n</main>\n"},
+	{"{{/* rnzml */}}\r\na\r\n{{/* end rnzml */}}\r\nb", "<p>a\n</p>\nb"},
+	{"{{/* rnzml */}}\n{{/* end rnzml */}}\n", ""},
+	{"\t{{/* rnzml */}}\n\t```\n\t\tx\n\t```\n\t{{/* end rnzml */}}", "\t<pre><code>\tx\n\t</code></pre>\n"},
+}
+
+func TestPreprocessTemplate(t *testing.T) {
+	for _, tt := range templatetests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := PreprocessTemplate([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.out != string(out) {
+				t.Errorf("expected: %q got: %q", tt.out, out)
+			}
+		})
+	}
+	t.Run("Should return errors on the lines of the template", func(t *testing.T) {
+		for _, tt := range []struct {
+			in       string
+			expected string
+		}{
+			{"a\n{{/* rnzml */}}\nb\n*c\n{{/* end rnzml */}}", "line 4: unclosed bold text (*) at position: 0"},
+			{"a\n{{/* rnzml */}}\nb", "unclosed rnzml region on line: 2"},
+			{"{{/* rnzml */}}\n{{/* rnzml */}}", "line 2: rnzml region in the region started on line 1"},
+		} {
+			_, err := PreprocessTemplate([]byte(tt.in))
+			if fmtErr(err) != tt.expected {
+				t.Errorf("expected: '%s' got: '%v'", tt.expected, err)
+			}
+		}
+		_, err := PreprocessTemplate([]byte("a\n{{/* rnzml */}}\nabc\n{{/* end rnzml */}}"), WithMaxOutputBytes(2))
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || !strings.HasPrefix(err.Error(), "rnzml region on line 2: ") {
+			t.Errorf("expected *LimitError got: '%v'", err)
+		}
+	})
+	t.Run("Should parse as a template", func(t *testing.T) {
+		out, err := PreprocessTemplate([]byte("{{/* rnzml */}}\nHi *{{.}}*\n{{/* end rnzml */}}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := &strings.Builder{}
+		if err := template.Must(template.New("").Parse(string(out))).Execute(b, "<you>"); err != nil {
+			t.Fatal(err)
+		}
+		if expected := "<p>Hi <strong>&lt;you&gt;</strong>\n</p>\n"; expected != b.String() {
+			t.Errorf("expected: %q got: %q", expected, b.String())
+		}
+	})
+}
+
+// fmtErr returns the message of err, or "" when it is nil
+func fmtErr(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,160 @@
+package rnzml
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// TextFinding is a problem found by a TextChecker in a run of text, Offset and
+// Length are in bytes of the text
+type TextFinding struct {
+	Offset  int
+	Length  int
+	Message string
+}
+
+// TextChecker checks a run of text, such as a spell or grammar checker
+type TextChecker func(text string) []TextFinding
+
+// TextIssue is a TextFinding mapped to the input. Line and Position are the
+// line of the text block and the byte offset in it the finding starts at, as
+// in a Warning, and Length is the length of the finding in the input.
+type TextIssue struct {
+	Line     int
+	Position int
+	Length   int
+	Message  string
+}
+
+// CheckText passes the text a reader sees in the document in, rendered with a
+// Renderer configured by opts, to check and returns its findings at their
+// positions in in. Code text, code blocks and link URLs are not checked, so
+// checkers do not report identifiers and URLs. Each text block is passed as
+// runs of text, split at code text and links, with escapes removed and bold
+// text included. The label of a link is a run of its own, and links labelled
+// with their URL are not passed.
+func CheckText(in io.Reader, check TextChecker, opts ...Option) ([]TextIssue, error) {
+	re := NewRenderer(opts...)
+	st := getRenderState()
+	defer putRenderState(st)
+	c := &textChecker{check: check}
+	err := re.scanBlocks(in, st, func(b block) error {
+		if b.kind != blockText {
+			return nil
+		}
+		line := b.content
+		if re.normalize != nil {
+			line = []byte(re.normalize(string(line)))
+		}
+		c.line, c.lineNumber, c.code = line, b.line, false
+		if err := re.scanInline(st, line, b.line, c); err != nil {
+			return lineError(b.line, err)
+		}
+		c.flush()
+		return nil
+	})
+	return c.issues, err
+}
+
+// textSpan is text of a run starting at offset that is at position in its
+// text block
+type textSpan struct {
+	offset, position int
+}
+
+// textChecker collects the runs of text of a text block, it is the
+// inlineHandler of the text blocks CheckText checks
+type textChecker struct {
+	check      TextChecker
+	issues     []TextIssue
+	line       []byte
+	lineNumber int
+	code       bool
+	text       []byte
+	spans      []textSpan
+}
+
+func (c *textChecker) inline(kind inlineKind, position int, text []byte) error {
+	switch kind {
+	case inlineText, inlineRune:
+		if !c.code {
+			c.add(text, position)
+		}
+	case inlineEscaped:
+		if !c.code {
+			c.add(text, position+1)
+		}
+	case inlineCodeStart:
+		c.flush()
+		c.code = true
+	case inlineCodeEnd:
+		c.code = false
+	case inlineLink:
+		c.flush()
+		if rawURL, label := splitLink(text); string(rawURL) != string(label) {
+			c.label(position, text)
+			c.flush()
+		}
+	}
+	return nil
+}
+
+// add adds text at position to the current run
+func (c *textChecker) add(text []byte, position int) {
+	if n := len(c.spans); n == 0 || c.spans[n-1].position+len(c.text)-c.spans[n-1].offset != position {
+		c.spans = append(c.spans, textSpan{offset: len(c.text), position: position})
+	}
+	c.text = append(c.text, text...)
+}
+
+// label adds the label of the link with the [ at position and content text
+// as a run. The link is read from the line alongside text so escapes in the
+// link are mapped to their positions, the label starts after the first space
+// of text as splitLink splits it.
+func (c *textChecker) label(position int, text []byte) {
+	split := bytes.IndexByte(text, ' ')
+	for i, j := position+1, 0; j < len(text); {
+		if c.line[i] == '\\' {
+			i++
+		}
+		// Invalid bytes are written to links as utf8.RuneError
+		r, size := utf8.DecodeRune(c.line[i:])
+		n := utf8.RuneLen(r)
+		if j > split {
+			c.add(text[j:j+n], i)
+		}
+		i, j = i+size, j+n
+	}
+}
+
+// flush passes the current run to the checker
+func (c *textChecker) flush() {
+	if len(c.text) == 0 {
+		return
+	}
+	for _, f := range c.check(string(c.text)) {
+		if f.Offset < 0 || f.Length < 0 || f.Offset+f.Length > len(c.text) {
+			continue
+		}
+		start := c.position(f.Offset)
+		length := 0
+		if f.Length > 0 {
+			length = c.position(f.Offset+f.Length-1) + 1 - start
+		}
+		c.issues = append(c.issues, TextIssue{Line: c.lineNumber, Position: start, Length: length, Message: f.Message})
+	}
+	c.text, c.spans = c.text[:0], c.spans[:0]
+}
+
+// position returns the position in the text block of offset in the run
+func (c *textChecker) position(offset int) int {
+	s := c.spans[0]
+	for _, span := range c.spans[1:] {
+		if span.offset > offset {
+			break
+		}
+		s = span
+	}
+	return s.position + offset - s.offset
+}
@@ -0,0 +1,82 @@
+package rnzml
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// misspelled finds the words "teh" and "recieve"
+var misspelled = regexp.MustCompile(`teh|recieve`)
+
+func spellCheck(runs *[]string) TextChecker {
+	return func(text string) []TextFinding {
+		*runs = append(*runs, text)
+		var findings []TextFinding
+		for _, m := range misspelled.FindAllStringIndex(text, -1) {
+			findings = append(findings, TextFinding{Offset: m[0], Length: m[1] - m[0], Message: text[m[0]:m[1]]})
+		}
+		return findings
+	}
+}
+
+var textchecktests = []struct {
+	in     string
+	runs   []string
+	issues []string
+}{
+	{"teh cat", []string{"teh cat"}, []string{"1:0:3 teh"}},
+	{"a *teh* b", []string{"a teh b"}, []string{"1:3:3 teh"}},
+	{"t*e*h", []string{"teh"}, []string{"1:0:5 teh"}},
+	{"a \\* te\\h", []string{"a * teh"}, []string{"1:5:4 teh"}},
+	{"a `teh` b", []string{"a ", " b"}, nil},
+	{"```\nteh\n```\nx", []string{"x"}, nil},
+	{"see [https://teh.nz recieve \\] teh] and [https://teh.nz]", []string{"see ", "recieve ] teh", " and "}, []string{"1:20:7 recieve", "1:31:3 teh"}},
+	{"[/a\\ b teh]", []string{"b teh"}, []string{"1:7:3 teh"}},
+	{"[a\\ b]", []string{"b"}, nil},
+	{"[a\\ b c]", []string{"b c"}, nil},
+	{"[a\\ teh c]", []string{"teh c"}, []string{"1:4:3 teh"}},
+	{"[a\\ b \xff teh]", []string{"b \ufffd teh"}, []string{"1:8:3 teh"}},
+	{"a\n\nb teh", []string{"a", "b teh"}, []string{"3:2:3 teh"}},
+}
+
+func TestCheckText(t *testing.T) {
+	for _, tt := range textchecktests {
+		t.Run(tt.in, func(t *testing.T) {
+			var runs []string
+			issues, err := CheckText(strings.NewReader(tt.in), spellCheck(&runs))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprintf("%q", tt.runs) != fmt.Sprintf("%q", runs) {
+				t.Errorf("expected runs: %q got: %q", tt.runs, runs)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, fmt.Sprintf("%d:%d:%d %s", issue.Line, issue.Position, issue.Length, issue.Message))
+			}
+			if strings.Join(tt.issues, "\n") != strings.Join(got, "\n") {
+				t.Errorf("expected: '%v' got: '%v'", tt.issues, got)
+			}
+		})
+	}
+	t.Run("Should ignore findings outside of the text", func(t *testing.T) {
+		issues, err := CheckText(strings.NewReader("abc"), func(string) []TextFinding {
+			return []TextFinding{{Offset: 2, Length: 2}, {Offset: -1}, {Offset: 3, Length: 0, Message: "end"}}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []TextIssue{{Line: 1, Position: 3, Message: "end"}}; fmt.Sprint(expected) != fmt.Sprint(issues) {
+			t.Errorf("expected: %v got: %v", expected, issues)
+		}
+	})
+	t.Run("Should return syntax errors", func(t *testing.T) {
+		var runs []string
+		_, err := CheckText(strings.NewReader("teh\n*a"), spellCheck(&runs))
+		if expected := "line 2: unclosed bold text (*) at position: 0"; fmt.Sprint(err) != expected {
+			t.Errorf("expected: '%s' got: '%v'", expected, err)
+		}
+	})
+}
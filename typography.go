@@ -0,0 +1,74 @@
+package rnzml
+
+// Typography is a set of typographic replacements made with WithTypography
+type Typography uint
+
+const (
+	// TypographyDashes replaces -- with an en dash and --- with an em dash
+	TypographyDashes Typography = 1 << iota
+	// TypographyEllipsis replaces ... with an ellipsis
+	TypographyEllipsis
+	// TypographyArrows replaces -> with an arrow
+	TypographyArrows
+	// TypographyAll makes every replacement
+	TypographyAll = TypographyDashes | TypographyEllipsis | TypographyArrows
+)
+
+// Replacements of Typography
+const (
+	enDash   = "–"
+	emDash   = "—"
+	ellipsis = "…"
+	arrow    = "→"
+)
+
+// dash is a - held back by appendTypography
+var dash = []byte("-")
+
+// WithTypography replaces the characters of text blocks and link labels
+// matching replacements with their typographic forms in rendered HTML, for
+// publication quality text. Code text, code blocks and links labelled with
+// their URL are never changed, and an escaped character is not part of a
+// replacement, so \-- is rendered as --. Replacements are made from left to
+// right, --> is an en dash and >. A Handler is passed the text as written.
+func WithTypography(replacements Typography) Option {
+	return func(re *Renderer) {
+		re.typography = replacements
+	}
+}
+
+// appendTypography appends text to b with the replacements of t made. When t
+// replaces arrows and text ends with a - that is not part of a dash, the - is
+// not appended and pending is true, so a > starting the next token can make
+// an arrow.
+func appendTypography(b, text []byte, t Typography) (_ []byte, pending bool) {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		next := func(n int, want byte) bool {
+			return i+n < len(text) && text[i+n] == want
+		}
+		switch {
+		case c == '-' && t&TypographyDashes != 0 && next(1, '-'):
+			if next(2, '-') {
+				b = append(b, emDash...)
+				i += 2
+			} else {
+				b = append(b, enDash...)
+				i++
+			}
+			continue
+		case c == '-' && t&TypographyArrows != 0 && next(1, '>'):
+			b = append(b, arrow...)
+			i++
+			continue
+		case c == '-' && t&TypographyArrows != 0 && i == len(text)-1:
+			return b, true
+		case c == '.' && t&TypographyEllipsis != 0 && next(1, '.') && next(2, '.'):
+			b = append(b, ellipsis...)
+			i += 2
+			continue
+		}
+		b = append(b, c)
+	}
+	return b, false
+}
@@ -0,0 +1,57 @@
+package rnzml
+
+import (
+	"testing"
+)
+
+var typographytests = []struct {
+	in           string
+	replacements Typography
+	out          string
+}{
+	{"a -- b --- c ... d -> e", TypographyAll, "a – b — c … d → e"},
+	{"a -- b ... c -> d", TypographyDashes, "a – b ... c -&gt; d"},
+	{"a -- b ... c -> d", TypographyEllipsis, "a -- b … c -&gt; d"},
+	{"a -- b ... c -> d", TypographyArrows, "a -- b ... c → d"},
+	{"a----b .... -->", TypographyAll, "a—-b …. –&gt;"},
+	{"`a -- b` and *c--d*", TypographyAll, "<code>a -- b</code> and <strong>c–d</strong>"},
+	{"\\-- a-\\> -*b*", TypographyAll, "-- a-&gt; -<strong>b</strong>"},
+	{"a-", TypographyAll, "a-"},
+	{"[/a a--b->c]", TypographyAll, `<a href="/a">a–b→c</a>`},
+	{"a -< b", TypographyAll, "a -&lt; b"},
+}
+
+func TestTypography(t *testing.T) {
+	for _, tt := range typographytests {
+		t.Run(tt.in, func(t *testing.T) {
+			out, err := NewRenderer(WithTypography(tt.replacements), WithCanonicalOutput()).RenderToBytes([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := "<p>" + tt.out + "</p>\n"; expected != string(out) {
+				t.Errorf("expected: %q got: %q", expected, out)
+			}
+		})
+	}
+	t.Run("Should not change code blocks", func(t *testing.T) {
+		out, err := NewRenderer(WithTypography(TypographyAll)).RenderToBytes([]byte("```\na -- b\n```"))
+		if expected := "<pre><code>a -- b\n</code></pre>\n"; err != nil || string(out) != expected {
+			t.Errorf("expected: %q got: %q %v", expected, out, err)
+		}
+	})
+	t.Run("Should not change text without the option", func(t *testing.T) {
+		out, err := NewRenderer(WithCanonicalOutput()).RenderToBytes([]byte("a -- b"))
+		if expected := "<p>a -- b</p>\n"; err != nil || string(out) != expected {
+			t.Errorf("expected: %q got: %q %v", expected, out, err)
+		}
+	})
+	t.Run("Should not change links labelled with their URL", func(t *testing.T) {
+		out, err := NewRenderer(WithTypography(TypographyAll), WithPrintOutput(), WithCanonicalOutput()).RenderToBytes([]byte("[/a--b] [/a--b c--d]"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "<p>/a--b c–d (/a--b)</p>\n"; string(out) != expected {
+			t.Errorf("expected: %q got: %q", expected, out)
+		}
+	})
+}
@@ -0,0 +1,271 @@
+package rnzml
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const upperhex = "0123456789ABCDEF"
+
+// NormalizeURL returns rawURL in the form it is rendered in a link.
+//
+// The scheme and host are lower cased, and host labels containing non-ASCII
+// characters are converted to their punycode (xn--) form. Full width letters,
+// digits and dots in hosts are written as their ASCII forms first, and a host
+// with a label containing characters other than letters, digits, marks and -
+// is percent-encoded instead. In the rest of the URL existing
+// percent-encodings are kept with their hex digits upper cased, a % that does
+// not start a percent-encoding is encoded as %25, and any other byte that is
+// not a letter, a digit or one of -._~!#$&*+,/:;=?@[] is percent-encoded.
+// Normalizing a normalized URL does not change it.
+//
+// Hosts are not mapped with the full IDNA rules of UTS #46, which would need
+// their tables in every binary. A URLRewriter can convert hosts with
+// idna.Lookup.ToASCII from golang.org/x/net/idna where they are needed.
+func NormalizeURL(rawURL string) string {
+	b := strings.Builder{}
+	b.Grow(len(rawURL))
+
+	rest := rawURL
+	if i := schemeEnd(rest); i > 0 {
+		b.WriteString(strings.ToLower(rest[:i+1]))
+		rest = rest[i+1:]
+	}
+	if strings.HasPrefix(rest, "//") {
+		b.WriteString("//")
+		rest = rest[2:]
+		end := strings.IndexAny(rest, "/?#")
+		if end == -1 {
+			end = len(rest)
+		}
+		writeAuthority(&b, rest[:end])
+		rest = rest[end:]
+	}
+	writeEncoded(&b, rest)
+	return b.String()
+}
+
+// schemeEnd returns the index of the : ending the scheme of rawURL, or -1 if
+// rawURL does not start with a scheme
+func schemeEnd(rawURL string) int {
+	for i := 0; i < len(rawURL); i++ {
+		c := rawURL[i]
+		switch {
+		case 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' || c == '+' || c == '-' || c == '.':
+			if i == 0 {
+				return -1
+			}
+		case c == ':':
+			if i == 0 {
+				return -1
+			}
+			return i
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// writeAuthority writes the [userinfo@]host[:port] authority to b
+func writeAuthority(b *strings.Builder, authority string) {
+	if i := strings.LastIndexByte(authority, '@'); i != -1 {
+		writeEncoded(b, authority[:i+1])
+		authority = authority[i+1:]
+	}
+	host, port := authority, ""
+	if i := strings.LastIndexByte(authority, ':'); i != -1 && !strings.HasSuffix(authority, "]") {
+		host, port = authority[:i], authority[i:]
+	}
+	if strings.HasPrefix(host, "[") {
+		// IPv6 literals are written as is
+		writeEncoded(b, host)
+	} else if isASCII(host) || !utf8.ValidString(host) {
+		writeEncoded(b, strings.ToLower(host))
+	} else if ascii, ok := punycodeHost(host); ok {
+		b.WriteString(ascii)
+	} else {
+		writeEncoded(b, strings.ToLower(host))
+	}
+	writeEncoded(b, port)
+}
+
+// punycodeHost returns host lower cased with its full width forms mapped to
+// ASCII and its non-ASCII labels in punycode, ok is false when a label is not
+// a valid internationalized label
+func punycodeHost(host string) (ascii string, ok bool) {
+	host = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\u3002' || r == '\uff61':
+			// Ideographic and halfwidth ideographic full stops
+			return '.'
+		case '\uff01' <= r && r <= '\uff5e':
+			return r - 0xfee0
+		}
+		return unicode.ToLower(r)
+	}, host)
+	var b strings.Builder
+	for i, label := range strings.Split(host, ".") {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		if isASCII(label) {
+			b.WriteString(label)
+			continue
+		}
+		for _, r := range label {
+			if r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) {
+				return "", false
+			}
+		}
+		b.WriteString("xn--")
+		b.WriteString(punycode(label))
+	}
+	return b.String(), true
+}
+
+// writeEncoded writes s to b percent-encoding bytes that are not allowed in a
+// URL and upper casing existing percent-encodings
+func writeEncoded(b *strings.Builder, s string) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%':
+			if i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+				b.WriteByte('%')
+				b.WriteString(strings.ToUpper(s[i+1 : i+3]))
+				i += 2
+			} else {
+				b.WriteString("%25")
+			}
+		case isURLByte(c):
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(upperhex[c>>4])
+			b.WriteByte(upperhex[c&15])
+		}
+	}
+}
+
+// isURLByte reports whether c can be written in a URL without encoding. The
+// set matches the bytes html/template leaves unencoded.
+func isURLByte(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	switch c {
+	case '-', '.', '_', '~', '!', '#', '$', '&', '*', '+', ',', '/', ':', ';', '=', '?', '@', '[', ']':
+		return true
+	}
+	return false
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// Punycode parameters from RFC 3492
+const (
+	punycodeBase        = 36
+	punycodeTMin        = 1
+	punycodeTMax        = 26
+	punycodeSkew        = 38
+	punycodeDamp        = 700
+	punycodeInitialBias = 72
+	punycodeInitialN    = 128
+)
+
+// punycode encodes label as described in RFC 3492, without the xn-- prefix
+func punycode(label string) string {
+	runes := []rune(label)
+	out := make([]byte, 0, len(label))
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	handled := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+
+	n := rune(punycodeInitialN)
+	delta := 0
+	bias := punycodeInitialBias
+	for handled < len(runes) {
+		// Find the smallest code point not yet handled
+		m := rune(utf8.MaxRune)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		delta += int(m-n) * (handled + 1)
+		n = m
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+			q := delta
+			for k := punycodeBase; ; k += punycodeBase {
+				t := k - bias
+				if t < punycodeTMin {
+					t = punycodeTMin
+				} else if t > punycodeTMax {
+					t = punycodeTMax
+				}
+				if q < t {
+					break
+				}
+				out = append(out, punycodeDigit(t+(q-t)%(punycodeBase-t)))
+				q = (q - t) / (punycodeBase - t)
+			}
+			out = append(out, punycodeDigit(q))
+			bias = punycodeAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return string(out)
+}
+
+func punycodeAdapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((punycodeBase-punycodeTMin)*punycodeTMax)/2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+	return k + (punycodeBase-punycodeTMin+1)*delta/(delta+punycodeSkew)
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
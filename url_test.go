@@ -0,0 +1,71 @@
+package rnzml
+
+import (
+	"testing"
+)
+
+var normalizeurltests = []struct {
+	in  string
+	out string
+}{
+	{"https://res.nz/path", "https://res.nz/path"},
+	{"HTTPS://Res.NZ/Path", "https://res.nz/Path"},
+	{"https://res.nz/a%3cb", "https://res.nz/a%3Cb"},
+	{"https://res.nz/a%3Cb", "https://res.nz/a%3Cb"},
+	{"https://res.nz/a<b", "https://res.nz/a%3Cb"},
+	{"https://res.nz/100%", "https://res.nz/100%25"},
+	{"https://res.nz/%zz", "https://res.nz/%25zz"},
+	{"https://res.nz/a b", "https://res.nz/a%20b"},
+	{"https://res.nz/café", "https://res.nz/caf%C3%A9"},
+	{"https://res.nz/?q=a&b=c#frag", "https://res.nz/?q=a&b=c#frag"},
+	{"https://münchen.de/", "https://xn--mnchen-3ya.de/"},
+	{"https://bücher.example:8080/x", "https://xn--bcher-kva.example:8080/x"},
+	{"https://user@日本.jp", "https://user@xn--wgv71a.jp"},
+	{"https://BÜCHER.Example/", "https://xn--bcher-kva.example/"},
+	{"https://ｒｅｓ.ｎｚ/", "https://res.nz/"},
+	{"https://a\u200db.example/", "https://a%E2%80%8Db.example/"},
+	{"https://\xffa.example/", "https://%EF%BF%BDa.example/"},
+	{"https://[::1]:80/", "https://[::1]:80/"},
+	{"//cdn.res.nz/a", "//cdn.res.nz/a"},
+	{"/path/to", "/path/to"},
+	{"#anchor", "#anchor"},
+	{"mailto:a@res.nz", "mailto:a@res.nz"},
+	{"<a", "%3Ca"},
+	{"1:a", "1:a"},
+	{"it's", "it%27s"},
+}
+
+func TestNormalizeURL(t *testing.T) {
+	for _, tt := range normalizeurltests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := NormalizeURL(tt.in)
+			if tt.out != got {
+				t.Errorf("expected: '%s' got: '%s'", tt.out, got)
+			}
+			if again := NormalizeURL(got); again != got {
+				t.Errorf("expected normalizing '%s' to be stable got: '%s'", got, again)
+			}
+		})
+	}
+}
+
+var punycodetests = []struct {
+	in  string
+	out string
+}{
+	{"münchen", "mnchen-3ya"},
+	{"bücher", "bcher-kva"},
+	{"日本", "wgv71a"},
+	{"ليهمابتكلموشعربي؟", "egbpdaj6bu4bxfgehfvwxn"},
+}
+
+func TestPunycode(t *testing.T) {
+	for _, tt := range punycodetests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := punycode(tt.in)
+			if tt.out != got {
+				t.Errorf("expected: '%s' got: '%s'", tt.out, got)
+			}
+		})
+	}
+}
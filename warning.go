@@ -0,0 +1,59 @@
+package rnzml
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Warning describes a recoverable issue in the input. Rendering continues
+// after a Warning.
+type Warning struct {
+	Line     int
+	Position int
+	Message  string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("line %d: %s at position: %d", w.Line, w.Message, w.Position)
+}
+
+// WithWarnings calls fn for each Warning found while rendering
+func WithWarnings(fn func(Warning)) Option {
+	return func(re *Renderer) {
+		re.warnings = fn
+	}
+}
+
+// warn reports a Warning if a warnings func is configured, or collects it in
+// st to be reported later
+func (re *Renderer) warn(st *renderState, line, position int, message string) {
+	if re.warnings == nil {
+		return
+	}
+	w := Warning{Line: line, Position: position, Message: message}
+	if st.collectWarnings {
+		st.warnings = append(st.warnings, w)
+	} else {
+		re.warnings(w)
+	}
+}
+
+// warnLink reports suspicious links, position is the position of the [
+func (re *Renderer) warnLink(st *renderState, line, position int, href string, label []byte) {
+	if re.warnings == nil {
+		return
+	}
+	if i := schemeEnd(href); i > 0 {
+		switch scheme := strings.ToLower(href[:i]); scheme {
+		case "http", "https", "mailto":
+		default:
+			re.warn(st, line, position, fmt.Sprintf("link URL has unsupported scheme %s", scheme))
+		}
+	}
+	if len(label) == 0 {
+		re.warn(st, line, position, "empty link label")
+	} else if len(bytes.TrimSpace(label)) != len(label) {
+		re.warn(st, line, position, "link label starts or ends with whitespace")
+	}
+}
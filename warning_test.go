@@ -0,0 +1,42 @@
+package rnzml
+
+import (
+	"strings"
+	"testing"
+)
+
+var warningtests = []struct {
+	in       string
+	warnings []string
+}{
+	{"*a* `b` [https://res.nz c]", nil},
+	{"a\n**", []string{"line 2: empty bold text (*) at position: 0"}},
+	{"a ``", []string{"line 1: empty code text (`) at position: 2"}},
+	{"[javascript:alert(1) a]", []string{"line 1: link URL has unsupported scheme javascript at position: 0"}},
+	{"a [/b  c]", []string{"line 1: link label starts or ends with whitespace at position: 2"}},
+	{"[/b ]", []string{"line 1: empty link label at position: 0"}},
+	{"** [ftp://a ]", []string{
+		"line 1: empty bold text (*) at position: 0",
+		"line 1: link URL has unsupported scheme ftp at position: 3",
+		"line 1: empty link label at position: 3",
+	}},
+	{"```\n**\n```", nil},
+}
+
+func TestWarnings(t *testing.T) {
+	for _, tt := range warningtests {
+		t.Run(tt.in, func(t *testing.T) {
+			var warnings []string
+			r := NewRenderer(WithWarnings(func(w Warning) {
+				warnings = append(warnings, w.String())
+			}))
+			out := &strings.Builder{}
+			err := r.Render(strings.NewReader(tt.in), out)
+			if err != nil {
+				t.Errorf("error: %s", err.Error())
+			} else if strings.Join(tt.warnings, "\n") != strings.Join(warnings, "\n") {
+				t.Errorf("expected: '%v' got: '%v'", tt.warnings, warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command wasm exposes rnzml to JavaScript, so a browser can preview
+// documents with the same renderer used on the server. Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o rnzml.wasm ./wasm
+//
+// and load it with the wasm_exec.js shipped with Go. Once running it defines
+// a global rnzml object:
+//
+//	rnzml.render(source, options) // {html, error, warnings}
+//	rnzml.parse(source, options)  // {tokens, error, warnings}
+//
+// where options is an optional object of booleans canonical,
+// externalLinksInNewTab, blankWhitespaceLines and sourceLines, and the numbers
+// tabWidth and maxOutputBytes. error is null when rendering succeeds and
+// warnings is an array of strings. parse returns the tokens of the Lexer
+// before any error as objects {kind, line, position, text}, where kind is the
+// name of the TokenKind such as "TextStart".
+package main
+
+import (
+	"io"
+	"strings"
+	"syscall/js"
+
+	"github.com/Resonance1584/rnzml"
+)
+
+func main() {
+	js.Global().Set("rnzml", js.ValueOf(map[string]interface{}{
+		"render": js.FuncOf(render),
+		"parse":  js.FuncOf(parse),
+	}))
+	// Keep the functions callable
+	select {}
+}
+
+// render implements rnzml.render(source, options)
+func render(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 || args[0].Type() != js.TypeString {
+		return result("", "rnzml.render expects a source string", nil)
+	}
+	var warnings []interface{}
+	opts := []rnzml.Option{rnzml.WithWarnings(func(w rnzml.Warning) {
+		warnings = append(warnings, w.String())
+	})}
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		opts = append(opts, options(args[1])...)
+	}
+	out := &strings.Builder{}
+	errMessage := ""
+	if err := rnzml.NewRenderer(opts...).Render(strings.NewReader(args[0].String()), out); err != nil {
+		errMessage = err.Error()
+	}
+	return result(out.String(), errMessage, warnings)
+}
+
+// parse implements rnzml.parse(source, options)
+func parse(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 || args[0].Type() != js.TypeString {
+		return parseResult(nil, "rnzml.parse expects a source string", nil)
+	}
+	var warnings []interface{}
+	opts := []rnzml.Option{rnzml.WithWarnings(func(w rnzml.Warning) {
+		warnings = append(warnings, w.String())
+	})}
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		opts = append(opts, options(args[1])...)
+	}
+	tokens := []interface{}{}
+	errMessage := ""
+	l := rnzml.NewLexer(strings.NewReader(args[0].String()), opts...)
+	for {
+		t, err := l.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errMessage = err.Error()
+			break
+		}
+		tokens = append(tokens, map[string]interface{}{
+			"kind":     t.Kind.String(),
+			"line":     t.Line,
+			"position": t.Position,
+			"text":     string(t.Text),
+		})
+	}
+	return parseResult(tokens, errMessage, warnings)
+}
+
+// options returns the Options set on a JavaScript options object
+func options(o js.Value) []rnzml.Option {
+	var opts []rnzml.Option
+	if o.Get("canonical").Truthy() {
+		opts = append(opts, rnzml.WithCanonicalOutput())
+	}
+	if o.Get("externalLinksInNewTab").Truthy() {
+		opts = append(opts, rnzml.WithExternalLinksInNewTab())
+	}
+	if o.Get("blankWhitespaceLines").Truthy() {
+		opts = append(opts, rnzml.WithBlankWhitespaceLines())
+	}
+	if o.Get("sourceLines").Truthy() {
+		opts = append(opts, rnzml.WithSourceLines())
+	}
+	if v := o.Get("tabWidth"); v.Type() == js.TypeNumber {
+		opts = append(opts, rnzml.WithTabWidth(v.Int()))
+	}
+	if v := o.Get("maxOutputBytes"); v.Type() == js.TypeNumber {
+		opts = append(opts, rnzml.WithMaxOutputBytes(v.Int()))
+	}
+	return opts
+}
+
+// result returns the object returned by rnzml.render
+func result(html, errMessage string, warnings []interface{}) interface{} {
+	var jsErr interface{}
+	if errMessage != "" {
+		jsErr = errMessage
+	}
+	if warnings == nil {
+		warnings = []interface{}{}
+	}
+	return js.ValueOf(map[string]interface{}{
+		"html":     html,
+		"error":    jsErr,
+		"warnings": warnings,
+	})
+}
+
+// parseResult returns the object returned by rnzml.parse
+func parseResult(tokens []interface{}, errMessage string, warnings []interface{}) interface{} {
+	var jsErr interface{}
+	if errMessage != "" {
+		jsErr = errMessage
+	}
+	if tokens == nil {
+		tokens = []interface{}{}
+	}
+	if warnings == nil {
+		warnings = []interface{}{}
+	}
+	return js.ValueOf(map[string]interface{}{
+		"tokens":   tokens,
+		"error":    jsErr,
+		"warnings": warnings,
+	})
+}